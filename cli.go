@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	archive "github.com/bezalel-media-core/v2/service/archive"
+	ingestion "github.com/bezalel-media-core/v2/service/ingestion"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	orchestration "github.com/bezalel-media-core/v2/service/orchestration"
+)
+
+// Command-line modes run a single task against the configured environment, then exit instead of serving.
+var bulkIngestPath = flag.String("bulk-ingest", "",
+	"Ingest a JSONL file of {\"source\", \"payload\"} lines, printing one JSON result per line; - reads stdin.")
+
+var createCredentialSource = flag.String("create-source-credential", "",
+	"Issue a new signing credential for the given source, printing its keyId and secret.")
+var revokeCredential = flag.String("revoke-source-credential", "",
+	"Revoke a signing credential, given as <source>:<keyId>.")
+
+var redriveLedgerId = flag.String("redrive-ledger", "",
+	"Redrive the given ledger from -redrive-stage, printing the invalidated events as JSON.")
+var redriveStage = flag.String("redrive-stage", "", "Stage to redrive from: Script, Enrichment, Assignment, FinalRender or Publish.")
+var redriveChannel = flag.String("redrive-channel", "", "Optional distribution channel to redrive, from the Assignment stage on.")
+var redriveForce = flag.Bool("redrive-force", false, "Reopen a Finished ledger.")
+
+var restoreArchiveKey = flag.String("restore-archive", "",
+	"Restore the ledgers of the given archive object key into the ledger table, printing the restored ledgerIds as JSON.")
+var restoreLedgerId = flag.String("restore-ledger", "", "Optional ledger to restore from -restore-archive; all by default.")
+
+func runCommandLineMode() bool {
+	flag.Parse()
+	if *bulkIngestPath != "" {
+		runBulkIngest(*bulkIngestPath)
+		return true
+	}
+	if *createCredentialSource != "" {
+		runCreateSourceCredential(*createCredentialSource)
+		return true
+	}
+	if *revokeCredential != "" {
+		runRevokeSourceCredential(*revokeCredential)
+		return true
+	}
+	if *redriveLedgerId != "" {
+		runRedriveLedger(*redriveLedgerId)
+		return true
+	}
+	if *restoreArchiveKey != "" {
+		runRestoreLedgers(*restoreArchiveKey, *restoreLedgerId)
+		return true
+	}
+	return false
+}
+
+func runBulkIngest(path string) {
+	input := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("failed to open bulk ingestion file: %s", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	err := ingestion.IngestJsonLines(input, func(result models_v1.IngestionResult) error {
+		return encoder.Encode(result)
+	})
+	if err != nil {
+		log.Fatalf("bulk ingestion aborted: %s", err)
+	}
+}
+
+func runCreateSourceCredential(source string) {
+	if _, ok := manifest.GetManifestLoader().GetSource(source); !ok {
+		log.Fatalf("unknown source: %s", source)
+	}
+	credential, err := dal.CreateSourceCredential(source)
+	if err != nil {
+		log.Fatalf("failed to create source credential: %s", err)
+	}
+	json.NewEncoder(os.Stdout).Encode(map[string]string{
+		"source": credential.Source,
+		"keyId":  credential.KeyID,
+		"secret": credential.Secret,
+	})
+}
+
+func runRevokeSourceCredential(sourceAndKey string) {
+	separator := strings.LastIndex(sourceAndKey, ":")
+	if separator <= 0 || separator == len(sourceAndKey)-1 {
+		log.Fatalf("expected <source>:<keyId>, given: %s", sourceAndKey)
+	}
+	err := dal.RevokeSourceCredential(sourceAndKey[:separator], sourceAndKey[separator+1:])
+	if err != nil {
+		log.Fatalf("failed to revoke source credential: %s", err)
+	}
+}
+
+func runRedriveLedger(ledgerId string) {
+	stage, err := orchestration.ParseRedriveStage(*redriveStage)
+	if err != nil {
+		log.Fatalf("invalid -redrive-stage: %s", err)
+	}
+	result, found, err := orchestration.RedriveLedger(orchestration.RedriveRequest{
+		LedgerID: ledgerId,
+		Stage:    stage,
+		Channel:  *redriveChannel,
+		Force:    *redriveForce,
+	})
+	if err != nil {
+		log.Fatalf("failed to redrive ledger: %s", err)
+	}
+	if !found {
+		log.Fatalf("ledger not found: %s", ledgerId)
+	}
+	json.NewEncoder(os.Stdout).Encode(result)
+}
+
+func runRestoreLedgers(objectKey string, ledgerId string) {
+	result, err := archive.RestoreLedgers(objectKey, ledgerId)
+	if err != nil {
+		log.Fatalf("failed to restore archived ledgers: %s", err)
+	}
+	json.NewEncoder(os.Stdout).Encode(result)
+}
@@ -11,11 +11,21 @@ import (
 
 const TABLE_ACCOUNTS = "Accounts"
 const TABLE_EVENT_LEDGER = "EventLedger"
+const TABLE_LEDGER_EVENTS = "LedgerEvents"
+const TABLE_LEDGER_HISTORY = "LedgerHistory"
 const TABLE_OVERRIDE_TEMPLATES = "OverrideTemplates"
 const TABLE_DEDUPE_EVENTS = "DedupeEvents"
+const TABLE_DEDUPE_FINGERPRINTS = "DedupeFingerprints"
 const SYSTEM_DAEMON = "SystemDaemon"
 const TABLE_HEARTBEAT = "Heartbeat"
-const TABLE_RATE_LIMIT = "RateLimit"
+const TABLE_RATE_LIMIT = "RateLimit" // Per-minute publisher API limits (dal.IsCallable); source admission uses AdmissionBuckets.
+const TABLE_REACTION_BUFFERS = "ReactionBuffers"
+const TABLE_CRON_SCHEDULES = "CronSchedules"
+const TABLE_SOURCE_CREDENTIALS = "SourceCredentials"
+const TABLE_SIGNATURE_NONCES = "SignatureNonces"
+const TABLE_ADMISSION_BUCKETS = "AdmissionBuckets"
+const TABLE_PARKED_EVENTS = "ParkedEvents"
+const TABLE_MODERATION_REJECTIONS = "ModerationRejections"
 
 // Although status is derivable from ledger data, needed for index-lookup replayability.
 const EVENT_LEDGER_STATE_GSI_NAME = "LedgerStatusIndex"   // {Status, StartedAtEpochMilli}
@@ -29,15 +39,32 @@ func Init() {
 	svc := dynamodb.New(aws_configuration.GetAwsSession())
 	createTableAccounts(svc)
 	createEventLedgerTables(svc)
+	createLedgerEvents(svc)
+	createLedgerHistory(svc)
 	createOverrideTemplates(svc)
 	createEventDedupeTable(svc)
+	createDedupeFingerprints(svc)
 	createSystemDaemon(svc)
 	createHeartbeat(svc)
 	createRateLimit(svc)
+	createReactionBuffers(svc)
+	createCronSchedules(svc)
+	createSourceCredentials(svc)
+	createSignatureNonces(svc)
+	createAdmissionBuckets(svc)
+	createParkedEvents(svc)
+	createModerationRejections(svc)
 	setTTL(svc, TABLE_DEDUPE_EVENTS)
+	setTTL(svc, TABLE_DEDUPE_FINGERPRINTS)
 	setTTL(svc, TABLE_EVENT_LEDGER)
+	setTTL(svc, TABLE_LEDGER_EVENTS)
+	setTTL(svc, TABLE_LEDGER_HISTORY)
 	setTTL(svc, TABLE_HEARTBEAT)
 	setTTL(svc, TABLE_RATE_LIMIT)
+	setTTL(svc, TABLE_REACTION_BUFFERS)
+	setTTL(svc, TABLE_SIGNATURE_NONCES)
+	setTTL(svc, TABLE_PARKED_EVENTS)
+	setTTL(svc, TABLE_MODERATION_REJECTIONS)
 }
 
 // Creates Accounts Table + PublisherProfile details.
@@ -166,6 +193,70 @@ func createEventLedgerTables(svc *dynamodb.DynamoDB) {
 	createTable(svc, input, tableName)
 }
 
+// Media and publish events of a ledger, one item each.
+// PK: LedgerID
+// SK: EventKey - Media#<EventID> or Publish#<EventID>
+func createLedgerEvents(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_LEDGER_EVENTS
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("LedgerID"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("EventKey"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("LedgerID"),
+				KeyType:       aws.String("HASH"),
+			},
+			{
+				AttributeName: aws.String("EventKey"),
+				KeyType:       aws.String("RANGE"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
+// Append-only audit of ledger appends.
+// PK: LedgerID
+// SK: HistoryVersion - the ledger's HistoryVersion after the append.
+func createLedgerHistory(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_LEDGER_HISTORY
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("LedgerID"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("HistoryVersion"),
+				AttributeType: aws.String("N"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("LedgerID"),
+				KeyType:       aws.String("HASH"),
+			},
+			{
+				AttributeName: aws.String("HistoryVersion"),
+				KeyType:       aws.String("RANGE"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
 func createOverrideTemplates(svc *dynamodb.DynamoDB) {
 	tableName := TABLE_OVERRIDE_TEMPLATES
 	input := &dynamodb.CreateTableInput{
@@ -216,6 +307,36 @@ func createEventDedupeTable(svc *dynamodb.DynamoDB) {
 	createTable(svc, input, tableName)
 }
 
+func createDedupeFingerprints(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_DEDUPE_FINGERPRINTS
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				// <Source>#<BandKey>
+				AttributeName: aws.String("FingerprintBand"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("LedgerID"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("FingerprintBand"),
+				KeyType:       aws.String("HASH"),
+			},
+			{
+				AttributeName: aws.String("LedgerID"),
+				KeyType:       aws.String("RANGE"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
 func createSystemDaemon(svc *dynamodb.DynamoDB) {
 	tableName := SYSTEM_DAEMON
 	input := &dynamodb.CreateTableInput{
@@ -289,6 +410,177 @@ func createRateLimit(svc *dynamodb.DynamoDB) {
 	createTable(svc, input, tableName)
 }
 
+func createReactionBuffers(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_REACTION_BUFFERS
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("Source"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("Source"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
+func createCronSchedules(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_CRON_SCHEDULES
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("ScheduleName"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("ScheduleName"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
+func createSourceCredentials(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_SOURCE_CREDENTIALS
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("Source"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("KeyID"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("Source"),
+				KeyType:       aws.String("HASH"),
+			},
+			{
+				AttributeName: aws.String("KeyID"),
+				KeyType:       aws.String("RANGE"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
+func createSignatureNonces(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_SIGNATURE_NONCES
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("Signature"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("Signature"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
+func createAdmissionBuckets(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_ADMISSION_BUCKETS
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("BucketKey"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("BucketKey"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
+func createParkedEvents(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_PARKED_EVENTS
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("Lane"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("ParkedKey"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("Lane"),
+				KeyType:       aws.String("HASH"),
+			},
+			{
+				AttributeName: aws.String("ParkedKey"),
+				KeyType:       aws.String("RANGE"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
+func createModerationRejections(svc *dynamodb.DynamoDB) {
+	tableName := TABLE_MODERATION_REJECTIONS
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("Source"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("RejectionKey"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("Source"),
+				KeyType:       aws.String("HASH"),
+			},
+			{
+				AttributeName: aws.String("RejectionKey"),
+				KeyType:       aws.String("RANGE"),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   aws.String(tableName),
+	}
+	createTable(svc, input, tableName)
+}
+
 func setTTL(svc *dynamodb.DynamoDB, tableName string) {
 	_, err := svc.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
 		TableName: aws.String(tableName),
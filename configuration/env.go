@@ -16,8 +16,6 @@ type EnvConfigVals struct {
 	DefaultPublisherWatermarkText string `yaml:"DefaultPublisherWatermarkText"`
 	AssignmentLockMilliTTL        int64  `yaml:"AssignmentLockMilliTTL"`
 	PublishLockMilliTTL           int64  `yaml:"PublishLockMilliTTL"`
-	AppendLedgerMaxRetries        int    `yaml:"AppendLedgerMaxRetries"`
-	AppendLedgerRetryDelaySec     int    `yaml:"AppendLedgerRetryDelaySec"`
 	LedgerQueueName               string `yaml:"LedgerQueueName"`
 	MediaTextQueueName            string `yaml:"MediaTextQueueName"`   // TODO
 	MediaRenderQueueName          string `yaml:"MediaRenderQueueName"` // TODO
@@ -41,7 +39,45 @@ type EnvConfigVals struct {
 	MaxRequestsTwitterMinute   int64 `yaml:"MaxRequestsTwitterMinute"`
 	MaxRequestsMediumMinute    int64 `yaml:"MaxRequestsMediumMinute"`
 	MaxRequestsRedditMinute    int64 `yaml:"MaxRequestsRedditMinute"`
-	MaxSourceOverflow          int64 `yaml:"MaxSourceOverflow"`
+
+	ParkedRetryPeriodSec int   `yaml:"ParkedRetryPeriodSec"` // How often parked events are retried; see manifest admission_policies.
+	ParkedEventTTLHours  int64 `yaml:"ParkedEventTTLHours"`
+
+	LedgerHistoryTTLHours int64 `yaml:"LedgerHistoryTTLHours"` // Outlives the ledger to audit finished publishes.
+
+	SignatureReplayWindowSec    int64 `yaml:"SignatureReplayWindowSec"`    // Max clock skew of signed source requests.
+	AllowUnsignedSourceRequests bool  `yaml:"AllowUnsignedSourceRequests"` // Migration: accept the legacy Authorization header on source routes.
+
+	ArticleFetchTimeoutSec int `yaml:"ArticleFetchTimeoutSec"`
+
+	RedactionHashKey string `yaml:"RedactionHashKey"` // HMAC key of redacted value hashes; empty omits the hashes.
+
+	ForumCommentCharBudget int `yaml:"ForumCommentCharBudget"` // About 4 characters per prompt token.
+	ForumMaxComments       int `yaml:"ForumMaxComments"`
+
+	MaxUploadFileBytes int64 `yaml:"MaxUploadFileBytes"`
+	MaxUploadFiles     int   `yaml:"MaxUploadFiles"`
+
+	DefaultTargetLanguage string `yaml:"DefaultTargetLanguage"` // BCP-47; used when a payload has no language and none is detected.
+
+	FeedPollPeriodMin   int `yaml:"FeedPollPeriodMin"`
+	FeedFetchTimeoutSec int `yaml:"FeedFetchTimeoutSec"`
+	FeedItemMaxAgeHours int `yaml:"FeedItemMaxAgeHours"` // Keep below the dedupe-hash TTL so expired hashes don't re-ingest old items.
+
+	BatchFlushPeriodSec int `yaml:"BatchFlushPeriodSec"` // How often stale batches are checked against manifest batch_policies.
+
+	CronTickPeriodSec  int `yaml:"CronTickPeriodSec"`
+	CronMaxCatchUpRuns int `yaml:"CronMaxCatchUpRuns"` // Cap on missed runs fired by catchUp "all" schedules.
+	CronMissedGraceSec int `yaml:"CronMissedGraceSec"` // How late a catchUp "skip" schedule may still fire.
+
+	LedgerArchiveBucket    string `yaml:"LedgerArchiveBucket"`
+	LedgerArchivePrefix    string `yaml:"LedgerArchivePrefix"`
+	LedgerArchivePeriodMin int    `yaml:"LedgerArchivePeriodMin"`
+	LedgerArchiveLeadHours int64  `yaml:"LedgerArchiveLeadHours"` // Finished ledgers are archived once their TTL is this close.
+	LedgerArchiveBatchSize int    `yaml:"LedgerArchiveBatchSize"` // Max ledgers archived per period.
+
+	QuarantineMaxHeartbeats int64 `yaml:"QuarantineMaxHeartbeats"` // At most 25, where heartbeats stop; 0 disables.
+	QuarantineMaxAgeHours   int64 `yaml:"QuarantineMaxAgeHours"`   // Ledger age since creation; 0 disables.
 }
 
 var configSync sync.Once
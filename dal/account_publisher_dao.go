@@ -3,6 +3,7 @@ package dal
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -16,6 +17,11 @@ import (
 )
 
 func CreatePublisherAccount(item tables.AccountPublisher) error {
+	canonicalLanguage, err := tables.NormalizeLanguageCode(item.PublisherLanguage)
+	if err == nil {
+		item.PublisherLanguage = canonicalLanguage
+	}
+
 	av, err := dynamodbattribute.MarshalMap(item)
 	if err != nil {
 		log.Printf("got error marshalling ledger item: %s", err)
@@ -314,9 +320,6 @@ func queryActivePublisherProfile(distributionChannelName string, lastPageKeyPK s
 			":n": {
 				N: aws.String(strconv.FormatInt(time.Now().UnixMilli(), 10)),
 			},
-			":l": {
-				S: aws.String(publisherLanguage),
-			},
 			":i": {
 				S: aws.String(publisherNiche),
 			},
@@ -324,10 +327,17 @@ func queryActivePublisherProfile(distributionChannelName string, lastPageKeyPK s
 				BOOL: aws.Bool(false),
 			},
 		},
-		FilterExpression: aws.String(`NOT contains(AccountSubscriptionStatus, :e) AND AssignmentLockTTL < :n 
-		AND PublisherLanguage = :l AND PublisherNiche = :i AND IsStaleProfile = :b`),
 		Limit: aws.Int64(maxRecordsPerQuery),
 	}
+	// Match canonical BCP-47 and legacy uppercase profile languages.
+	languagePlaceholders := []string{}
+	for i, l := range tables.LanguageCodeVariants(publisherLanguage) {
+		placeholder := fmt.Sprintf(":l%d", i)
+		languagePlaceholders = append(languagePlaceholders, placeholder)
+		queryInput.ExpressionAttributeValues[placeholder] = &dynamodb.AttributeValue{S: aws.String(l)}
+	}
+	queryInput.SetFilterExpression(fmt.Sprintf(`NOT contains(AccountSubscriptionStatus, :e) AND AssignmentLockTTL < :n 
+		AND PublisherLanguage IN (%s) AND PublisherNiche = :i AND IsStaleProfile = :b`, strings.Join(languagePlaceholders, ", ")))
 	if lastPageKeyPK != "" {
 		queryInput.SetExclusiveStartKey(map[string]*dynamodb.AttributeValue{
 			"ChannelName": {
@@ -383,6 +393,22 @@ func takeAssignmentLock(accountId string, publisherProfileId string, processId s
 	return err
 }
 
+// Moves the assignment lock from oldProcessId to processId with a new expiry, e.g. for a redrive renewing the
+// assignment. Fails when another process holds an unexpired lock on the profile.
+func RenewAssignment(accountId string, publisherProfileId string, oldProcessId string, processId string, expiryTimeMilli int64) error {
+	account, err := GetPublisherAccount(accountId, publisherProfileId)
+	if err != nil {
+		log.Printf("error getting publisher account: %s", err)
+		return err
+	}
+
+	if account.AssignmentLockID != oldProcessId && !canTakeAssignmentLock(processId, account) {
+		return fmt.Errorf("unable to renew assignment lock. accountId: %s publisherProfileId: %s held by: %s",
+			accountId, publisherProfileId, account.AssignmentLockID)
+	}
+	return takeLock(processId, account, "AssignmentLockID", "AssignmentLockTTL", account.AssignmentLockID, expiryTimeMilli)
+}
+
 func TakePublishLock(accountId string, publisherProfileId string, processId string) error {
 	account, err := GetPublisherAccount(accountId, publisherProfileId)
 	if err != nil {
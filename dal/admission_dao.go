@@ -0,0 +1,276 @@
+package dal
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+const global_admission_bucket = "global"
+
+var ErrAdmissionConflict = errors.New("admission bucket modified concurrently")
+var ErrLaneAtCapacity = errors.New("global in-flight events at lane ceiling")
+
+// Token bucket and in-flight count of a source; the global entry only counts in-flight events.
+type AdmissionBucketEntry struct {
+	BucketKey            string // source#<sourceName> or global
+	TokensMilli          int64  // Thousandths of a token, as of LastRefillEpochMilli.
+	LastRefillEpochMilli int64
+	InFlight             int64 // Admitted events not yet completed.
+	Version              int64
+}
+
+func sourceAdmissionBucketKey(source string) string {
+	return "source#" + source
+}
+
+func GetSourceAdmissionBucket(source string) (AdmissionBucketEntry, error) {
+	result, err := svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_ADMISSION_BUCKETS),
+		Key: map[string]*dynamodb.AttributeValue{
+			"BucketKey": {
+				S: aws.String(sourceAdmissionBucketKey(source)),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	resultItem := AdmissionBucketEntry{}
+	if err != nil {
+		log.Printf("got error calling GetItem admission bucket: %s", err)
+		return resultItem, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &resultItem)
+	if err != nil {
+		log.Printf("error unmarshalling admission bucket: %s", err)
+		return resultItem, err
+	}
+	return resultItem, err
+}
+
+// Atomically takes a token and an in-flight slot of the source and of the global pool.
+// The source entry must be unchanged since read; the global pool must be below globalCeiling.
+func ReserveAdmission(source string, observed AdmissionBucketEntry, tokensMilli int64, nowMilli int64, globalCeiling int64) error {
+	_, err := svc.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Update: &dynamodb.Update{
+					TableName: aws.String(dynamo_configuration.TABLE_ADMISSION_BUCKETS),
+					Key: map[string]*dynamodb.AttributeValue{
+						"BucketKey": {
+							S: aws.String(sourceAdmissionBucketKey(source)),
+						},
+					},
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+						":tokens": {
+							N: aws.String(strconv.FormatInt(tokensMilli, 10)),
+						},
+						":now": {
+							N: aws.String(strconv.FormatInt(nowMilli, 10)),
+						},
+						":one": {
+							N: aws.String("1"),
+						},
+						":ov": {
+							N: aws.String(strconv.FormatInt(observed.Version, 10)),
+						},
+					},
+					UpdateExpression:    aws.String("SET TokensMilli = :tokens, LastRefillEpochMilli = :now ADD InFlight :one, Version :one"),
+					ConditionExpression: aws.String("attribute_not_exists(Version) OR Version = :ov"),
+				},
+			},
+			{
+				Update: &dynamodb.Update{
+					TableName: aws.String(dynamo_configuration.TABLE_ADMISSION_BUCKETS),
+					Key: map[string]*dynamodb.AttributeValue{
+						"BucketKey": {
+							S: aws.String(global_admission_bucket),
+						},
+					},
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+						":one": {
+							N: aws.String("1"),
+						},
+						":ceiling": {
+							N: aws.String(strconv.FormatInt(globalCeiling, 10)),
+						},
+					},
+					UpdateExpression:    aws.String("ADD InFlight :one"),
+					ConditionExpression: aws.String("attribute_not_exists(InFlight) OR InFlight < :ceiling"),
+				},
+			},
+		},
+	})
+
+	var canceled *dynamodb.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		reasons := canceled.CancellationReasons
+		if len(reasons) == 2 && aws.StringValue(reasons[1].Code) == "ConditionalCheckFailed" {
+			return ErrLaneAtCapacity
+		}
+		return ErrAdmissionConflict
+	}
+	if err != nil {
+		log.Printf("got error reserving admission for source %s: %s", source, err)
+		return err
+	}
+	return nil
+}
+
+// Frees the in-flight slots a saved ledger took on admission, once per admission: the ledger is marked
+// AdmissionReleased in the same transaction. A no-op for ledgers admitted without admission control.
+func ReleaseLedgerAdmission(ledgerItem tables.Ledger) error {
+	if !ledgerItem.AdmissionReserved {
+		return nil
+	}
+	markReleased := &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			TableName: aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+			Key: map[string]*dynamodb.AttributeValue{
+				"LedgerID": {
+					S: aws.String(ledgerItem.LedgerID),
+				},
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":true": {
+					BOOL: aws.Bool(true),
+				},
+			},
+			UpdateExpression:    aws.String("SET AdmissionReleased = :true"),
+			ConditionExpression: aws.String("AdmissionReserved = :true AND attribute_not_exists(AdmissionReleased)"),
+		},
+	}
+	return releaseAdmission(ledgerItem.TriggerEventSource, markReleased)
+}
+
+// Atomically frees the in-flight slots of the source and of the global pool taken by ReserveAdmission,
+// e.g. when the ledger admitted for them couldn't be saved.
+func ReleaseAdmission(source string) error {
+	return releaseAdmission(source, nil)
+}
+
+// Skips buckets already at zero, e.g. of ledgers admitted before admission control was deployed;
+// releases nothing once the optional ledgerUpdate fails its condition.
+func releaseAdmission(source string, ledgerUpdate *dynamodb.TransactWriteItem) error {
+	keys := []string{sourceAdmissionBucketKey(source), global_admission_bucket}
+	for {
+		items := []*dynamodb.TransactWriteItem{}
+		if ledgerUpdate != nil {
+			items = append(items, ledgerUpdate)
+		}
+		for _, key := range keys {
+			items = append(items, newReleaseInFlightItem(key))
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		_, err := svc.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: items})
+		var canceled *dynamodb.TransactionCanceledException
+		if !errors.As(err, &canceled) {
+			if err != nil {
+				log.Printf("got error releasing admission of source %s: %s", source, err)
+			}
+			return err
+		}
+
+		reasons := canceled.CancellationReasons
+		if ledgerUpdate != nil {
+			if len(reasons) > 0 && isConditionalCheckFailed(reasons[0]) {
+				return nil
+			}
+			reasons = reasons[min(1, len(reasons)):]
+		}
+		remaining := []string{}
+		for i, key := range keys {
+			if i < len(reasons) && !isConditionalCheckFailed(reasons[i]) {
+				remaining = append(remaining, key)
+			}
+		}
+		if len(remaining) == len(keys) {
+			log.Printf("got error releasing admission of source %s: %s", source, err)
+			return ErrAdmissionConflict
+		}
+		keys = remaining
+	}
+}
+
+// Takes the in-flight slots of a reopened ledger whose admission was released, e.g. on a redrive of a finished
+// or quarantined ledger, so it releases them again on completion. Slots are taken regardless of the limits.
+func ReadmitLedger(ledgerItem tables.Ledger) error {
+	items := []*dynamodb.TransactWriteItem{
+		{
+			Update: &dynamodb.Update{
+				TableName: aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+				Key: map[string]*dynamodb.AttributeValue{
+					"LedgerID": {
+						S: aws.String(ledgerItem.LedgerID),
+					},
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":true": {
+						BOOL: aws.Bool(true),
+					},
+				},
+				UpdateExpression:    aws.String("REMOVE AdmissionReleased"),
+				ConditionExpression: aws.String("AdmissionReleased = :true"),
+			},
+		},
+	}
+	for _, key := range []string{sourceAdmissionBucketKey(ledgerItem.TriggerEventSource), global_admission_bucket} {
+		items = append(items, &dynamodb.TransactWriteItem{
+			Update: &dynamodb.Update{
+				TableName: aws.String(dynamo_configuration.TABLE_ADMISSION_BUCKETS),
+				Key: map[string]*dynamodb.AttributeValue{
+					"BucketKey": {
+						S: aws.String(key),
+					},
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":one": {
+						N: aws.String("1"),
+					},
+				},
+				UpdateExpression: aws.String("ADD InFlight :one"),
+			},
+		})
+	}
+	_, err := svc.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: items})
+	var canceled *dynamodb.TransactionCanceledException
+	if errors.As(err, &canceled) && len(canceled.CancellationReasons) > 0 && isConditionalCheckFailed(canceled.CancellationReasons[0]) {
+		return nil // Not released, or readmitted by another redrive.
+	}
+	if err != nil {
+		log.Printf("correlationID: %s got error readmitting ledger: %s", ledgerItem.LedgerID, err)
+	}
+	return err
+}
+
+func newReleaseInFlightItem(bucketKey string) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			TableName: aws.String(dynamo_configuration.TABLE_ADMISSION_BUCKETS),
+			Key: map[string]*dynamodb.AttributeValue{
+				"BucketKey": {
+					S: aws.String(bucketKey),
+				},
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":negOne": {
+					N: aws.String("-1"),
+				},
+				":zero": {
+					N: aws.String("0"),
+				},
+			},
+			UpdateExpression:    aws.String("ADD InFlight :negOne"),
+			ConditionExpression: aws.String("InFlight > :zero"),
+		},
+	}
+}
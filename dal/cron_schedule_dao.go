@@ -0,0 +1,78 @@
+package dal
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+
+	"log"
+)
+
+// Last-run record of a manifest cron schedule; used to catch up occurrences missed during downtime.
+type CronScheduleEntry struct {
+	ScheduleName        string
+	LastRunAtEpochMilli int64  // Scheduled time of the last fired, or deliberately skipped, occurrence.
+	LastOutcome         string // Ingestion outcome of the last run; "skipped" or "initialized" otherwise.
+	LastLedgerID        string
+	Version             int64
+}
+
+func GetCronScheduleEntry(scheduleName string) (CronScheduleEntry, error) {
+	result, err := svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_CRON_SCHEDULES),
+		Key: map[string]*dynamodb.AttributeValue{
+			"ScheduleName": {
+				S: aws.String(scheduleName),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	resultItem := CronScheduleEntry{}
+	if err != nil {
+		log.Printf("got error calling GetItem cron schedule entry: %s", err)
+		return resultItem, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &resultItem)
+	if err != nil {
+		log.Printf("error unmarshalling cron schedule entry: %s", err)
+		return resultItem, err
+	}
+	return resultItem, err
+}
+
+// Overwrites the last-run record if it is still at expectedVersion; 0 for a schedule without a record.
+func RecordCronScheduleRun(scheduleName string, runAtEpochMilli int64, outcome string, ledgerID string, expectedVersion int64) error {
+	entry := CronScheduleEntry{
+		ScheduleName:        scheduleName,
+		LastRunAtEpochMilli: runAtEpochMilli,
+		LastOutcome:         outcome,
+		LastLedgerID:        ledgerID,
+		Version:             expectedVersion + 1,
+	}
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		log.Printf("got error marshalling cron schedule entry: %s", err)
+		return err
+	}
+
+	_, err = svc.PutItem(&dynamodb.PutItemInput{
+		Item:                av,
+		TableName:           aws.String(dynamo_configuration.TABLE_CRON_SCHEDULES),
+		ConditionExpression: aws.String("attribute_not_exists(ScheduleName) OR Version = :v"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":v": {
+				N: aws.String(strconv.FormatInt(expectedVersion, 10)),
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("got error calling PutItem cron schedule entry %s: %s", scheduleName, err)
+		return err
+	}
+	return nil
+}
@@ -22,6 +22,11 @@ type DaemonLockEntry struct {
 
 const SYSTEM_RENDER_FARM = "RenderFarmScaler"
 const SYSTEM_HEARTBEAT_MONITOR = "HeartbeatMonitor"
+const SYSTEM_FEED_POLLER = "FeedPoller"
+const SYSTEM_BATCH_FLUSHER = "BatchFlusher"
+const SYSTEM_CRON_SCHEDULER = "CronScheduler"
+const SYSTEM_PARKED_EVENT_RETRIER = "ParkedEventRetrier"
+const SYSTEM_LEDGER_ARCHIVER = "LedgerArchiver"
 
 func InitDaemonEntry(systemId string) error {
 	existingLock, err := GetLockEntry(systemId)
@@ -137,6 +142,20 @@ func TakeSystemLockOwnership(systemId string, processId string, expiryTimeMilli
 	return true, err
 }
 
+// Blocks until the process owns the system lock, retrying every retryPeriod.
+func WaitForSystemLockOwnership(systemId string, processId string, expiryTimeMilli int64, retryPeriod time.Duration) {
+	for {
+		hasOwnership, err := TakeSystemLockOwnership(systemId, processId, expiryTimeMilli)
+		if err != nil {
+			log.Printf("error verifying lock ownership for system %s: %s", systemId, err)
+		}
+		if hasOwnership {
+			return
+		}
+		time.Sleep(retryPeriod)
+	}
+}
+
 func canTakeLock(lockEntry DaemonLockEntry, processId string) bool {
 	now := time.Now().UnixMilli()
 	if lockEntry.ExpiryTimeEpochMilli < now {
@@ -12,16 +12,24 @@ import (
 )
 
 type HashEntry struct {
-	EventHash string
-	TTL       int64 // epoch seconds
+	EventHash      string
+	LedgerID       string // Ledger created for the first occurrence; empty for entries written before tracking.
+	RejectedReason string `dynamodbav:",omitempty"` // Moderation reason of content that was rejected rather than saved.
+	TTL            int64  // epoch seconds
 }
 
-func CreateHashEntry(rawContentHash string) error {
+func CreateHashEntry(rawContentHash string, ledgerID string) error {
+	return putHashEntry(HashEntry{EventHash: rawContentHash, LedgerID: ledgerID})
+}
+
+// Remembers rejected content so resubmissions are rejected without moderating them again.
+func CreateRejectedHashEntry(rawContentHash string, reason string) error {
+	return putHashEntry(HashEntry{EventHash: rawContentHash, RejectedReason: reason})
+}
+
+func putHashEntry(entry HashEntry) error {
 	const threeDaysTTL = 259200
-	entry := HashEntry{
-		EventHash: rawContentHash,
-		TTL:       time.Now().Unix() + threeDaysTTL,
-	}
+	entry.TTL = time.Now().Unix() + threeDaysTTL
 	av, err := dynamodbattribute.MarshalMap(entry)
 	if err != nil {
 		log.Printf("got error marshalling hash entry: %s", err)
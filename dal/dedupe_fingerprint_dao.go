@@ -0,0 +1,97 @@
+package dal
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+
+	"log"
+)
+
+// LSH band entry for near-duplicate lookup; one entry per band of a ledger's similarity signature.
+type FingerprintEntry struct {
+	FingerprintBand string // <Source>#<BandKey>
+	LedgerID        string
+	Signature       string // Encoded full signature for verifying candidates.
+	TTL             int64  // epoch seconds
+}
+
+func fingerprintBandKey(source string, band string) string {
+	return source + "#" + band
+}
+
+func CreateFingerprintEntries(source string, ledgerID string, signature string, bands []string) error {
+	const threeDaysTTL = 259200 // Matches DedupeEvents hash entries.
+	ttl := time.Now().Unix() + threeDaysTTL
+	requests := []*dynamodb.WriteRequest{}
+	for _, band := range bands {
+		entry := FingerprintEntry{
+			FingerprintBand: fingerprintBandKey(source, band),
+			LedgerID:        ledgerID,
+			Signature:       signature,
+			TTL:             ttl,
+		}
+		av, err := dynamodbattribute.MarshalMap(entry)
+		if err != nil {
+			log.Printf("correlationID: %s got error marshalling fingerprint entry: %s", ledgerID, err)
+			return err
+		}
+		requests = append(requests, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: av}})
+	}
+
+	const maxBatchWrite = 25
+	for len(requests) > 0 {
+		batch := requests[:min(maxBatchWrite, len(requests))]
+		requests = requests[len(batch):]
+		output, err := svc.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{
+				dynamo_configuration.TABLE_DEDUPE_FINGERPRINTS: batch,
+			},
+		})
+		if err != nil {
+			log.Printf("correlationID: %s got error calling BatchWriteItem fingerprint entries: %s", ledgerID, err)
+			return err
+		}
+		// Throttled writes are returned unprocessed; requeue them.
+		requests = append(requests, output.UnprocessedItems[dynamo_configuration.TABLE_DEDUPE_FINGERPRINTS]...)
+	}
+	return nil
+}
+
+// Entries of other ledgers sharing at least one band with the given bands; unique by LedgerID.
+func GetFingerprintCandidates(source string, bands []string) ([]FingerprintEntry, error) {
+	seen := map[string]bool{}
+	result := []FingerprintEntry{}
+	for _, band := range bands {
+		output, err := svc.Query(&dynamodb.QueryInput{
+			TableName:              aws.String(dynamo_configuration.TABLE_DEDUPE_FINGERPRINTS),
+			KeyConditionExpression: aws.String("FingerprintBand = :band"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":band": {
+					S: aws.String(fingerprintBandKey(source, band)),
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("got error querying fingerprint entries: %s", err)
+			return result, err
+		}
+
+		entries := []FingerprintEntry{}
+		err = dynamodbattribute.UnmarshalListOfMaps(output.Items, &entries)
+		if err != nil {
+			log.Printf("error unmarshalling fingerprint entries: %s", err)
+			return result, err
+		}
+		for _, e := range entries {
+			if !seen[e.LedgerID] {
+				seen[e.LedgerID] = true
+				result = append(result, e)
+			}
+		}
+	}
+	return result, nil
+}
@@ -1,13 +1,10 @@
 package dal
 
 import (
-	"encoding/json"
 	"fmt"
-	"math"
 	"strconv"
 
 	"log"
-	"reflect"
 	"time"
 
 	"bitbucket.org/creachadair/stringset"
@@ -15,19 +12,15 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	env "github.com/bezalel-media-core/v2/configuration"
 	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
 	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
 )
 
-func CreateLedger(item tables.Ledger) error {
-	item.MediaEventsVersion = start_version
-	item.PublishEventsVersion = start_version
-	item.LedgerStatus = tables.NEW_LEDGER
-	item.LedgerCreatedAtEpochMilli = time.Now().UnixMilli()
-	const twoWeeks = 1210000
-	item.TTL = time.Now().Unix() + twoWeeks
+const maxHeartbeat = 25
+const ledgerTTLSeconds = 1210000 // two weeks
 
+func CreateLedger(item tables.Ledger) error {
+	item = newLedgerRecord(item)
 	av, err := dynamodbattribute.MarshalMap(item)
 	if err != nil {
 		log.Printf("got error marshalling ledger item: %s", err)
@@ -48,6 +41,15 @@ func CreateLedger(item tables.Ledger) error {
 	return err
 }
 
+func newLedgerRecord(item tables.Ledger) tables.Ledger {
+	item.MediaEventsVersion = start_version
+	item.PublishEventsVersion = start_version
+	item.LedgerStatus = tables.NEW_LEDGER
+	item.LedgerCreatedAtEpochMilli = time.Now().UnixMilli()
+	item.TTL = time.Now().Unix() + ledgerTTLSeconds
+	return item
+}
+
 func GetLedger(ledgerId string) (tables.Ledger, error) {
 	result, err := svc.GetItem(&dynamodb.GetItemInput{
 		TableName: aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
@@ -69,12 +71,23 @@ func GetLedger(ledgerId string) (tables.Ledger, error) {
 		log.Printf("error unmarshalling ledger item: %s", err)
 		return resultItem, err
 	}
+	if len(result.Item) == 0 {
+		return resultItem, err
+	}
 
-	return resultItem, err
+	entries, err := getLedgerEventEntries(ledgerId)
+	if err != nil {
+		return resultItem, err
+	}
+	return hydrateLedgerEvents(resultItem, entries)
 }
 
 func DeleteLedger(ledgerId string) error {
-	_, err := svc.DeleteItem(&dynamodb.DeleteItemInput{
+	err := deleteLedgerEvents(ledgerId)
+	if err != nil {
+		return err
+	}
+	_, err = svc.DeleteItem(&dynamodb.DeleteItemInput{
 		TableName: aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
 		Key: map[string]*dynamodb.AttributeValue{
 			"LedgerID": {
@@ -86,102 +99,30 @@ func DeleteLedger(ledgerId string) error {
 	return err
 }
 
-func AppendLedgerMediaEvents(ledgerId string, mediaEvents []tables.MediaEvent) error {
-	var err error
-	retryCount := 0
-	maxRetries := env.GetEnvConfigs().AppendLedgerMaxRetries
-	minSeconds := env.GetEnvConfigs().AppendLedgerRetryDelaySec
-	success := false
-	canRetry := true
-	for retryCount < maxRetries && !success && canRetry {
-		err = appendLedgerMediaEvents(ledgerId, mediaEvents)
-		retryCount++
-		if err != nil && hasVersionConflict(err) {
-			time.Sleep(time.Duration(powInt(minSeconds, retryCount)) * time.Second)
-		} else if err != nil {
-			log.Printf("error appending media event to ledger: %s", err)
-			canRetry = false
-		} else {
-			success = true
-		}
-	}
-
-	return err
-}
-
-func appendLedgerMediaEvents(ledgerId string, mediaEvents []tables.MediaEvent) error {
-	ledgerItem, err := GetLedger(ledgerId)
-	if err != nil {
-		log.Printf("error fetching ledger: %s", err)
-		return err
-	}
-
+// Set-joins the events into the ledger's decoded media events; returns the events that were added.
+func withJoinedMediaEvents(ledgerItem tables.Ledger, mediaEvents []tables.MediaEvent) (tables.Ledger, []tables.MediaEvent, error) {
 	anyExistingMediaEvents, err := ledgerItem.GetExistingMediaEvents()
 	if err != nil {
 		log.Printf("error fetching existing media events: %s", err)
-		return err
+		return ledgerItem, nil, err
 	}
 
 	setEvents := joinMediaEventSet(anyExistingMediaEvents, mediaEvents)
-	joinedEventsJson, err := json.Marshal(setEvents)
-	if err != nil {
-		log.Printf("error marshalling joined mediaEvents: %s", err)
-		return err
-	}
-	ledgerItem.MediaEvents = string(joinedEventsJson)
-	const fieldKeyScript = "MediaEvents"
-	const versionKeyScript = "MediaEventsVersion"
-	err = updateLedgerEvents(ledgerItem, fieldKeyScript, versionKeyScript)
-	return err
+	ledgerItem.SetMediaEvents(setEvents)
+	return ledgerItem, setEvents[len(anyExistingMediaEvents):], nil
 }
 
-func AppendLedgerPublishEvents(ledgerId string, publishEvents []tables.PublishEvent) error {
-	var err error
-	retryCount := 0
-	maxRetries := env.GetEnvConfigs().AppendLedgerMaxRetries
-	minSeconds := env.GetEnvConfigs().AppendLedgerRetryDelaySec
-	success := false
-	canRetry := true
-	for retryCount < maxRetries && !success && canRetry {
-		err = appendLedgerPublishEvents(ledgerId, publishEvents)
-		retryCount++
-		if err != nil && hasVersionConflict(err) {
-			time.Sleep(time.Duration(powInt(minSeconds, retryCount)) * time.Second)
-		} else if err != nil {
-			log.Printf("error appending publish event to ledger: %s", err)
-			canRetry = false
-		} else {
-			success = true
-		}
-	}
-
-	return err
-}
-
-func appendLedgerPublishEvents(ledgerId string, publishEvents []tables.PublishEvent) error {
-	ledgerItem, err := GetLedger(ledgerId)
-	if err != nil {
-		log.Printf("error fetching ledger: %s", err)
-		return err
-	}
-
+// Set-joins the events into the ledger's decoded publish events; returns the events that were added.
+func withJoinedPublishEvents(ledgerItem tables.Ledger, publishEvents []tables.PublishEvent) (tables.Ledger, []tables.PublishEvent, error) {
 	anyExistingPublishEvents, err := ledgerItem.GetExistingPublishEvents()
 	if err != nil {
 		log.Printf("error fetching existing publish events: %s", err)
-		return err
+		return ledgerItem, nil, err
 	}
 
 	setEvents := joinPublishEventSet(anyExistingPublishEvents, publishEvents)
-	joinedEventsJson, err := json.Marshal(setEvents)
-	if err != nil {
-		log.Printf("error marshalling joined publishEvents: %s", err)
-		return err
-	}
-	ledgerItem.PublishEvents = string(joinedEventsJson)
-	const fieldKeyScript = "PublishEvents"
-	const versionKeyScript = "PublishEventsVersion"
-	err = updateLedgerEvents(ledgerItem, fieldKeyScript, versionKeyScript)
-	return err
+	ledgerItem.SetPublishEvents(setEvents)
+	return ledgerItem, setEvents[len(anyExistingPublishEvents):], nil
 }
 
 func hasVersionConflict(err error) bool {
@@ -194,8 +135,9 @@ func hasVersionConflict(err error) bool {
 	return false
 }
 
-func powInt(x, y int) int {
-	return int(math.Pow(float64(x), float64(y)))
+// The reason a transaction item was canceled for is its condition.
+func isConditionalCheckFailed(reason *dynamodb.CancellationReason) bool {
+	return aws.StringValue(reason.Code) == "ConditionalCheckFailed"
 }
 
 func SetLedgerStatus(ledgerEntry tables.Ledger, status tables.LedgerStatus) error {
@@ -222,45 +164,41 @@ func SetLedgerStatus(ledgerEntry tables.Ledger, status tables.LedgerStatus) erro
 	return err
 }
 
-func updateLedgerEvents(ledgerEntry tables.Ledger, fieldKey string, versionKey string) error {
-	updatedValue := getField(&ledgerEntry, fieldKey)
-	// Check to see that no one updated before us.
-	oldVersionNumber := getField(&ledgerEntry, versionKey).Int()
-	newVersionNumber := oldVersionNumber + 1
-	input := &dynamodb.UpdateItemInput{
+// Sets a New ledger Quarantined with the policy reason and workflow errors; returns false when the ledger isn't New,
+// e.g. another process already quarantined or finished it.
+func QuarantineLedger(ledgerId string, reason string, workflowErrors []tables.WorkflowError) (bool, error) {
+	errorsAv, err := dynamodbattribute.Marshal(workflowErrors)
+	if err != nil {
+		log.Printf("correlationID: %s error marshalling workflow errors: %s", ledgerId, err)
+		return false, err
+	}
+	_, err = svc.UpdateItem(&dynamodb.UpdateItemInput{
 		Key: map[string]*dynamodb.AttributeValue{
 			"LedgerID": {
-				S: aws.String(ledgerEntry.LedgerID),
+				S: aws.String(ledgerId),
 			},
 		},
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":r": {
-				S: aws.String(updatedValue.String()),
-			},
-			":v": {
-				N: aws.String(strconv.FormatInt(newVersionNumber, 10)),
-			},
-			":ov": {
-				N: aws.String(strconv.FormatInt(oldVersionNumber, 10)),
-			},
+			":status": {S: aws.String(string(tables.QUARANTINED_LEDGER))},
+			":new":    {S: aws.String(string(tables.NEW_LEDGER))},
+			":reason": {S: aws.String(reason)},
+			":at":     {N: aws.String(strconv.FormatInt(time.Now().UnixMilli(), 10))},
+			":errors": errorsAv,
 		},
-		TableName:           aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
-		ReturnValues:        aws.String("NONE"),
-		UpdateExpression:    aws.String(fmt.Sprintf("SET %s = :r, %s = :v", fieldKey, versionKey)),
-		ConditionExpression: aws.String(fmt.Sprintf("%s = :ov", versionKey)),
+		TableName:    aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+		ReturnValues: aws.String("NONE"),
+		UpdateExpression: aws.String("SET LedgerStatus = :status, QuarantineReason = :reason, " +
+			"QuarantinedAtEpochMilli = :at, LastWorkflowErrors = :errors"),
+		ConditionExpression: aws.String("LedgerStatus = :new"),
+	})
+	if hasVersionConflict(err) {
+		return false, nil
 	}
-
-	_, err := svc.UpdateItem(input)
 	if err != nil {
-		log.Printf("error calling updateLedgerEvents: %s", err)
+		log.Printf("correlationID: %s error quarantining ledger: %s", ledgerId, err)
+		return false, err
 	}
-	return err
-}
-
-func getField(v *tables.Ledger, field string) reflect.Value {
-	r := reflect.ValueOf(v)
-	f := reflect.Indirect(r).FieldByName(field)
-	return f
+	return true, nil
 }
 
 func joinMediaEventSet(s1 []tables.MediaEvent, s2 []tables.MediaEvent) []tables.MediaEvent {
@@ -273,6 +211,7 @@ func joinMediaEventSet(s1 []tables.MediaEvent, s2 []tables.MediaEvent) []tables.
 
 	for _, e := range s2 {
 		if !existing.Contains(e.EventID) {
+			existing.Add(e.EventID)
 			result = append(result, e)
 		}
 	}
@@ -290,6 +229,7 @@ func joinPublishEventSet(s1 []tables.PublishEvent, s2 []tables.PublishEvent) []t
 
 	for _, e := range s2 {
 		if !existing.Contains(e.GetEventID()) {
+			existing.Add(e.GetEventID())
 			result = append(result, e)
 		}
 	}
@@ -297,7 +237,6 @@ func joinPublishEventSet(s1 []tables.PublishEvent, s2 []tables.PublishEvent) []t
 }
 
 func IncrementHeartbeat(ledgerId string, curHeartbeatCount int64) error {
-	const maxHeartbeat = 25
 	if curHeartbeatCount >= maxHeartbeat {
 		log.Printf("correlationID: %s max heartbeat exceeded retuning nil noop", ledgerId)
 		return nil
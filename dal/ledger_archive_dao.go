@@ -0,0 +1,146 @@
+package dal
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+var ErrLedgerExists = errors.New("ledger already exists")
+
+// Ledgers that run no more workflows unless redriven.
+var archivableStatuses = []tables.LedgerStatus{tables.FINISHED_LEDGER, tables.QUARANTINED_LEDGER}
+
+// IDs of at most limit Finished or Quarantined ledgers whose TTL is before ttlBeforeEpochSec, and which weren't
+// archived since their latest append.
+func ListArchivableLedgers(ttlBeforeEpochSec int64, limit int) ([]string, error) {
+	ledgerIds := []string{}
+	for _, status := range archivableStatuses {
+		var startKey map[string]*dynamodb.AttributeValue
+		for {
+			result, err := svc.Query(newArchivableLedgersQuery(status, ttlBeforeEpochSec, startKey))
+			if err != nil {
+				log.Printf("error querying archivable %s ledgers: %s", status, err)
+				return ledgerIds, err
+			}
+			for _, item := range result.Items {
+				ledgerIds = append(ledgerIds, aws.StringValue(item["LedgerID"].S))
+				if len(ledgerIds) == limit {
+					return ledgerIds, nil
+				}
+			}
+			if len(result.LastEvaluatedKey) == 0 {
+				break
+			}
+			startKey = result.LastEvaluatedKey
+		}
+	}
+	return ledgerIds, nil
+}
+
+func newArchivableLedgersQuery(status tables.LedgerStatus, ttlBeforeEpochSec int64, startKey map[string]*dynamodb.AttributeValue) *dynamodb.QueryInput {
+	return &dynamodb.QueryInput{
+		TableName: aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+		IndexName: aws.String(dynamo_configuration.EVENT_LEDGER_STATE_GSI_NAME),
+		// A ledger's TTL is at least ledgerTTLSeconds after its creation, so one expiring before ttlBeforeEpochSec
+		// was created before ttlBeforeEpochSec - ledgerTTLSeconds.
+		KeyConditionExpression: aws.String("LedgerStatus = :status AND LedgerCreatedAtEpochMilli < :createdMilli"),
+		FilterExpression: aws.String("#ttl < :ttl AND " +
+			"(attribute_not_exists(ArchiveObjectKey) OR ArchivedHistoryVersion < HistoryVersion)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#ttl": aws.String("TTL"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":status":       {S: aws.String(string(status))},
+			":createdMilli": {N: aws.String(strconv.FormatInt((ttlBeforeEpochSec-ledgerTTLSeconds)*1000, 10))},
+			":ttl":          {N: aws.String(strconv.FormatInt(ttlBeforeEpochSec, 10))},
+		},
+		ProjectionExpression: aws.String("LedgerID"),
+		ExclusiveStartKey:    startKey,
+	}
+}
+
+// Records the archive holding the ledger as of historyVersion; a no-op for a missing ledger.
+func MarkLedgerArchived(ledgerId string, historyVersion int64, objectKey string) error {
+	_, err := svc.UpdateItem(&dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"LedgerID": {
+				S: aws.String(ledgerId),
+			},
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":key":     {S: aws.String(objectKey)},
+			":version": {N: aws.String(strconv.FormatInt(historyVersion, 10))},
+		},
+		TableName:           aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+		ReturnValues:        aws.String("NONE"),
+		UpdateExpression:    aws.String("SET ArchiveObjectKey = :key, ArchivedHistoryVersion = :version"),
+		ConditionExpression: aws.String("attribute_exists(LedgerID)"),
+	})
+	if hasVersionConflict(err) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("correlationID: %s error marking ledger archived: %s", ledgerId, err)
+	}
+	return err
+}
+
+// Writes an archived ledger back with a fresh TTL: its events as LedgerEvents items, then its history entries
+// that have since expired, then the ledger item. Returns ErrLedgerExists, writing nothing, while the ledger is live.
+func RestoreArchivedLedger(ledgerItem tables.Ledger, mediaEvents []tables.MediaEvent, publishEvents []tables.PublishEvent,
+	history []LedgerHistoryEntry) error {
+	existing, err := GetLedger(ledgerItem.LedgerID)
+	if err != nil {
+		return err
+	}
+	if existing.LedgerID != "" {
+		return ErrLedgerExists
+	}
+
+	_, err = putLedgerMediaEvents(ledgerItem.LedgerID, mediaEvents)
+	if err != nil {
+		return err
+	}
+	events := []ledgerEvent{}
+	for _, p := range publishEvents {
+		events = append(events, ledgerEvent{eventId: p.GetEventID(), event: p})
+	}
+	_, err = putLedgerEvents(ledgerItem.LedgerID, LEDGER_EVENT_PUBLISH, events)
+	if err != nil {
+		return err
+	}
+	for _, h := range history {
+		err = createLedgerHistoryEntry(h)
+		if err != nil && !hasVersionConflict(err) {
+			return err
+		}
+	}
+
+	ledgerItem.ClearEvents()
+	ledgerItem.TTL = time.Now().Unix() + ledgerTTLSeconds
+	av, err := dynamodbattribute.MarshalMap(ledgerItem)
+	if err != nil {
+		log.Printf("correlationID: %s got error marshalling restored ledger: %s", ledgerItem.LedgerID, err)
+		return err
+	}
+	_, err = svc.PutItem(&dynamodb.PutItemInput{
+		Item:                av,
+		TableName:           aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+		ConditionExpression: aws.String("attribute_not_exists(LedgerID)"),
+	})
+	if hasVersionConflict(err) {
+		return ErrLedgerExists
+	}
+	if err != nil {
+		log.Printf("correlationID: %s got error calling PutItem restored ledger: %s", ledgerItem.LedgerID, err)
+	}
+	return err
+}
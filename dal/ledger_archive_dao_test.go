@@ -0,0 +1,17 @@
+package dal
+
+import (
+	"strconv"
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewArchivableLedgersQuery(t *testing.T) {
+	ttlBefore := int64(1800000000)
+	input := newArchivableLedgersQuery(tables.FINISHED_LEDGER, ttlBefore, nil)
+	assert.Equal(t, "LedgerStatus = :status AND LedgerCreatedAtEpochMilli < :createdMilli", *input.KeyConditionExpression)
+	assert.Equal(t, strconv.FormatInt((ttlBefore-ledgerTTLSeconds)*1000, 10), *input.ExpressionAttributeValues[":createdMilli"].N)
+	assert.Equal(t, strconv.FormatInt(ttlBefore, 10), *input.ExpressionAttributeValues[":ttl"].N)
+}
@@ -0,0 +1,480 @@
+package dal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+const (
+	LEDGER_EVENT_MEDIA   = "Media"
+	LEDGER_EVENT_PUBLISH = "Publish"
+	LEDGER_EVENT_PROMPT  = "Prompt" // Prompt text shared by the ledger's media events, keyed by tables.PromptRef.
+	// Invalidated#<Sequence>#<EventKey> keeps an event a redrive removed; legacy blob events are hidden by it.
+	LEDGER_EVENT_INVALIDATED = "Invalidated"
+)
+
+var ErrLedgerNotFound = errors.New("ledger not found")
+var ErrLedgerAppendConflict = errors.New("ledger append conflicted with concurrent writes")
+
+const (
+	maxTransactItems     = 100 // DynamoDB limit per TransactWriteItems.
+	maxAppendConflictTry = 5
+)
+
+// One media or publish event of a ledger, stored under the LedgerID partition.
+type LedgerEventEntry struct {
+	LedgerID  string
+	EventKey  string           // <Media|Publish>#<EventID>, Prompt#<PromptRef> or Invalidated#...; a conditional put keeps appends idempotent.
+	Sequence  int64            // epoch nanos of the append; events are read back in append order.
+	EventJson tables.EventBlob // tables.MediaEvent, tables.PublishEvent or prompt text; an event blob, or plain JSON for older items.
+	TTL       int64            // epoch seconds
+}
+
+func ledgerEventKey(eventType string, eventId string) string {
+	return fmt.Sprintf("%s#%s", eventType, eventId)
+}
+
+type ledgerEvent struct {
+	eventId string
+	event   interface{}
+}
+
+// Long prompt text is written once per ledger as a Prompt item and referenced by the media events.
+func AppendLedgerMediaEvents(audit LedgerAudit, ledgerId string, mediaEvents []tables.MediaEvent) error {
+	events, err := putLedgerPrompts(ledgerId, mediaEvents)
+	if err != nil {
+		return err
+	}
+	return appendLedgerEvents(ledgerId, LEDGER_EVENT_MEDIA, events, "MediaEventsVersion", func(added []int) (LedgerHistoryEntry, error) {
+		addedEvents := []tables.MediaEvent{}
+		for _, i := range added {
+			addedEvents = append(addedEvents, mediaEvents[i])
+		}
+		addedBlob, err := tables.EncodeMediaEvents(addedEvents)
+		return newLedgerHistoryEntry(ledgerId, LEDGER_EVENT_MEDIA, audit, addedBlob), err
+	})
+}
+
+func AppendLedgerPublishEvents(audit LedgerAudit, ledgerId string, publishEvents []tables.PublishEvent) error {
+	events := []ledgerEvent{}
+	for _, p := range publishEvents {
+		events = append(events, ledgerEvent{eventId: p.GetEventID(), event: p})
+	}
+	return appendLedgerEvents(ledgerId, LEDGER_EVENT_PUBLISH, events, "PublishEventsVersion", func(added []int) (LedgerHistoryEntry, error) {
+		addedEvents := []tables.PublishEvent{}
+		for _, i := range added {
+			addedEvents = append(addedEvents, publishEvents[i])
+		}
+		addedBlob, err := tables.EncodePublishEvents(addedEvents)
+		return newLedgerHistoryEntry(ledgerId, LEDGER_EVENT_PUBLISH, audit, addedBlob), err
+	})
+}
+
+// Puts the prompts, then each media event not already in the ledger; returns the indices of the events written.
+func putLedgerMediaEvents(ledgerId string, mediaEvents []tables.MediaEvent) ([]int, error) {
+	events, err := putLedgerPrompts(ledgerId, mediaEvents)
+	if err != nil {
+		return nil, err
+	}
+	return putLedgerEvents(ledgerId, LEDGER_EVENT_MEDIA, events)
+}
+
+// Puts the prompt items the media events reference; returns the events to put, with their prompts deduped.
+// An orphaned prompt item of a failed append is harmless.
+func putLedgerPrompts(ledgerId string, mediaEvents []tables.MediaEvent) ([]ledgerEvent, error) {
+	prompts := map[string]string{}
+	events := []ledgerEvent{}
+	for _, m := range tables.DedupePrompts(mediaEvents, prompts) {
+		events = append(events, ledgerEvent{eventId: m.EventID, event: m})
+	}
+	promptEvents := []ledgerEvent{}
+	for ref, text := range prompts {
+		promptEvents = append(promptEvents, ledgerEvent{eventId: ref, event: text})
+	}
+	_, err := putLedgerEvents(ledgerId, LEDGER_EVENT_PROMPT, promptEvents)
+	if err != nil {
+		log.Printf("correlationID: %s error appending prompts to ledger: %s", ledgerId, err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// HistoryVersion and the events version of a ledger.
+type ledgerVersions struct {
+	versionKey     string // MediaEventsVersion, PublishEventsVersion or RedriveVersion.
+	historyVersion int64
+	eventsVersion  int64
+}
+
+func (v ledgerVersions) bumped() ledgerVersions {
+	return ledgerVersions{versionKey: v.versionKey, historyVersion: v.historyVersion + 1, eventsVersion: v.eventsVersion + 1}
+}
+
+func (v ledgerVersions) withHistory(history LedgerHistoryEntry) LedgerHistoryEntry {
+	history.HistoryVersion = v.historyVersion
+	history.EventsVersion = v.eventsVersion
+	return history
+}
+
+func getLedgerVersions(ledgerId string, versionKey string) (ledgerVersions, error) {
+	versions := ledgerVersions{versionKey: versionKey}
+	result, err := svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+		Key: map[string]*dynamodb.AttributeValue{
+			"LedgerID": {
+				S: aws.String(ledgerId),
+			},
+		},
+		ProjectionExpression: aws.String("LedgerID, HistoryVersion, " + versionKey),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		log.Printf("correlationID: %s error getting ledger versions: %s", ledgerId, err)
+		return versions, err
+	}
+	if len(result.Item) == 0 {
+		return versions, ErrLedgerNotFound
+	}
+	versions.historyVersion, err = parseVersionAttribute(result.Item, "HistoryVersion")
+	if err != nil {
+		return versions, err
+	}
+	versions.eventsVersion, err = parseVersionAttribute(result.Item, versionKey)
+	return versions, err
+}
+
+// Puts the events not already in the ledger, bumps versionKey and HistoryVersion, and writes the history record
+// newHistory builds of the events written, all in one transaction. So every append adding events fires the ledger
+// stream and is recorded, also when retried after a failure. Events beyond one transaction are put ahead of it.
+func appendLedgerEvents(ledgerId string, eventType string, events []ledgerEvent, versionKey string,
+	newHistory func(added []int) (LedgerHistoryEntry, error)) error {
+	sequence := time.Now().UnixNano()
+	overflow := max(len(events)-(maxTransactItems-2), 0)
+	written, err := putLedgerEvents(ledgerId, eventType, events[:overflow])
+	if err != nil {
+		return err
+	}
+
+	pending := []int{}
+	puts := map[int]*dynamodb.TransactWriteItem{}
+	for i := overflow; i < len(events); i++ {
+		put, err := newLedgerEventPut(ledgerId, eventType, events[i], sequence+int64(i))
+		if err != nil {
+			return err
+		}
+		puts[i] = put
+		pending = append(pending, i)
+	}
+	for i := 0; i < maxAppendConflictTry; i++ {
+		if len(pending) == 0 && len(written) == 0 {
+			return nil
+		}
+		versions, err := getLedgerVersions(ledgerId, versionKey)
+		if err != nil {
+			return err
+		}
+		history, err := newHistory(append(append([]int{}, written...), pending...))
+		if err != nil {
+			return err
+		}
+		historyPut, err := newLedgerHistoryPut(versions.bumped().withHistory(history))
+		if err != nil {
+			return err
+		}
+		items := []*dynamodb.TransactWriteItem{}
+		for _, p := range pending {
+			items = append(items, puts[p])
+		}
+		items = append(items, newLedgerVersionBump(ledgerId, versions, "", nil), historyPut)
+		_, err = svc.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			return nil
+		}
+		var canceled *dynamodb.TransactionCanceledException
+		if !errors.As(err, &canceled) || len(canceled.CancellationReasons) != len(items) {
+			log.Printf("correlationID: %s error appending %s events to ledger: %s", ledgerId, eventType, err)
+			return err
+		}
+		// Events already in the ledger, e.g. of a retried append, are dropped; a concurrent bump is retried.
+		stillNew := []int{}
+		for j, p := range pending {
+			if !isConditionalCheckFailed(canceled.CancellationReasons[j]) {
+				stillNew = append(stillNew, p)
+			}
+		}
+		pending = stillNew
+	}
+	log.Printf("correlationID: %s error appending %s events to ledger: %s", ledgerId, eventType, ErrLedgerAppendConflict)
+	return ErrLedgerAppendConflict
+}
+
+// Puts each event not already in the ledger; returns the indices of the events written.
+func putLedgerEvents(ledgerId string, eventType string, events []ledgerEvent) ([]int, error) {
+	sequence := time.Now().UnixNano()
+	written := []int{}
+	for i, e := range events {
+		isNew, err := putLedgerEvent(ledgerId, eventType, e, sequence+int64(i))
+		if err != nil {
+			log.Printf("correlationID: %s error appending %s event to ledger: %s", ledgerId, eventType, err)
+			return written, err
+		}
+		if isNew {
+			written = append(written, i)
+		}
+	}
+	return written, nil
+}
+
+func putLedgerEvent(ledgerId string, eventType string, event ledgerEvent, sequence int64) (bool, error) {
+	put, err := newLedgerEventPut(ledgerId, eventType, event, sequence)
+	if err != nil {
+		return false, err
+	}
+	_, err = svc.PutItem(&dynamodb.PutItemInput{
+		Item:                put.Put.Item,
+		TableName:           put.Put.TableName,
+		ConditionExpression: put.Put.ConditionExpression,
+	})
+	if hasVersionConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Conditional put of the event item; a no-op for events already in the ledger.
+func newLedgerEventPut(ledgerId string, eventType string, event ledgerEvent, sequence int64) (*dynamodb.TransactWriteItem, error) {
+	eventBlob, err := tables.EncodeEventBlob(event.event)
+	if err != nil {
+		return nil, err
+	}
+	entry := LedgerEventEntry{
+		LedgerID:  ledgerId,
+		EventKey:  ledgerEventKey(eventType, event.eventId),
+		Sequence:  sequence,
+		EventJson: eventBlob,
+		TTL:       time.Now().Unix() + ledgerTTLSeconds,
+	}
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			Item:                av,
+			TableName:           aws.String(dynamo_configuration.TABLE_LEDGER_EVENTS),
+			ConditionExpression: aws.String("attribute_not_exists(EventKey)"),
+		},
+	}, nil
+}
+
+// Sets the bumped versions, which fires the ledger stream, and the SET clause setExpression with its values;
+// fails unless the ledger is still at the given versions.
+func newLedgerVersionBump(ledgerId string, versions ledgerVersions, setExpression string, values map[string]*dynamodb.AttributeValue) *dynamodb.TransactWriteItem {
+	bumped := versions.bumped()
+	updateValues := map[string]*dynamodb.AttributeValue{
+		":hv": {
+			N: aws.String(strconv.FormatInt(bumped.historyVersion, 10)),
+		},
+		":ev": {
+			N: aws.String(strconv.FormatInt(bumped.eventsVersion, 10)),
+		},
+		":ohv": {
+			N: aws.String(strconv.FormatInt(versions.historyVersion, 10)),
+		},
+	}
+	for k, v := range values {
+		updateValues[k] = v
+	}
+	updateExpression := fmt.Sprintf("SET HistoryVersion = :hv, %s = :ev", versions.versionKey)
+	if setExpression != "" {
+		updateExpression += ", " + setExpression
+	}
+	condition := "attribute_exists(LedgerID) AND HistoryVersion = :ohv"
+	if versions.historyVersion == 0 {
+		condition = "attribute_exists(LedgerID) AND (attribute_not_exists(HistoryVersion) OR HistoryVersion = :ohv)"
+	}
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			Key: map[string]*dynamodb.AttributeValue{
+				"LedgerID": {
+					S: aws.String(ledgerId),
+				},
+			},
+			ExpressionAttributeValues: updateValues,
+			TableName:                 aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+			UpdateExpression:          aws.String(updateExpression),
+			ConditionExpression:       aws.String(condition),
+		},
+	}
+}
+
+// Bumps versionKey and HistoryVersion, applying the SET clause setExpression with its values, which fires the
+// ledger stream; the change's history record and the given items are written in the same transaction.
+func recordLedgerChange(history LedgerHistoryEntry, versionKey string, setExpression string, values map[string]*dynamodb.AttributeValue,
+	items []*dynamodb.TransactWriteItem) error {
+	for i := 0; i < maxAppendConflictTry; i++ {
+		versions, err := getLedgerVersions(history.LedgerID, versionKey)
+		if err != nil {
+			return err
+		}
+		historyPut, err := newLedgerHistoryPut(versions.bumped().withHistory(history))
+		if err != nil {
+			return err
+		}
+		transactItems := append(append([]*dynamodb.TransactWriteItem{}, items...),
+			newLedgerVersionBump(history.LedgerID, versions, setExpression, values), historyPut)
+		_, err = svc.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: transactItems})
+		var canceled *dynamodb.TransactionCanceledException
+		if errors.As(err, &canceled) && !hasFailedCondition(canceled, len(items)) {
+			continue // Bumped concurrently.
+		}
+		if canceled != nil {
+			err = ErrLedgerAppendConflict // One of the items changed since it was read.
+		}
+		if err != nil {
+			log.Printf("correlationID: %s error bumping %s: %s", history.LedgerID, versionKey, err)
+		}
+		return err
+	}
+	log.Printf("correlationID: %s error bumping %s: %s", history.LedgerID, versionKey, ErrLedgerAppendConflict)
+	return ErrLedgerAppendConflict
+}
+
+// Whether one of the first n items of the canceled transaction failed its condition.
+func hasFailedCondition(canceled *dynamodb.TransactionCanceledException, n int) bool {
+	for i, reason := range canceled.CancellationReasons {
+		if i < n && isConditionalCheckFailed(reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// All event items of the ledger in append order.
+func getLedgerEventEntries(ledgerId string) ([]LedgerEventEntry, error) {
+	entries := []LedgerEventEntry{}
+	var startKey map[string]*dynamodb.AttributeValue
+	for {
+		result, err := svc.Query(&dynamodb.QueryInput{
+			TableName:              aws.String(dynamo_configuration.TABLE_LEDGER_EVENTS),
+			KeyConditionExpression: aws.String("LedgerID = :id"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":id": {
+					S: aws.String(ledgerId),
+				},
+			},
+			ConsistentRead:    aws.Bool(true),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("correlationID: %s error querying ledger events: %s", ledgerId, err)
+			return entries, err
+		}
+		page := []LedgerEventEntry{}
+		err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &page)
+		if err != nil {
+			log.Printf("correlationID: %s error unmarshalling ledger events: %s", ledgerId, err)
+			return entries, err
+		}
+		entries = append(entries, page...)
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = result.LastEvaluatedKey
+	}
+	sortLedgerEventEntries(entries)
+	return entries, nil
+}
+
+func sortLedgerEventEntries(entries []LedgerEventEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Sequence != entries[j].Sequence {
+			return entries[i].Sequence < entries[j].Sequence
+		}
+		return entries[i].EventKey < entries[j].EventKey
+	})
+}
+
+// Sets the decoded media and publish events from the event items, after any events of the legacy JSON blobs.
+func hydrateLedgerEvents(ledgerItem tables.Ledger, entries []LedgerEventEntry) (tables.Ledger, error) {
+	prompts := map[string]string{}
+	mediaEvents := []tables.MediaEvent{}
+	publishEvents := []tables.PublishEvent{}
+	invalidatedKeys := map[string]bool{}
+	for _, entry := range entries {
+		var err error
+		if eventKey, ok := invalidatedEventKey(entry.EventKey); ok {
+			invalidatedKeys[eventKey] = true
+		} else if strings.HasPrefix(entry.EventKey, LEDGER_EVENT_MEDIA+"#") {
+			var m tables.MediaEvent
+			err = tables.DecodeEventBlob(entry.EventJson, &m)
+			mediaEvents = append(mediaEvents, m)
+		} else if strings.HasPrefix(entry.EventKey, LEDGER_EVENT_PUBLISH+"#") {
+			var p tables.PublishEvent
+			err = tables.DecodeEventBlob(entry.EventJson, &p)
+			publishEvents = append(publishEvents, p)
+		} else if ref, ok := strings.CutPrefix(entry.EventKey, LEDGER_EVENT_PROMPT+"#"); ok {
+			var text string
+			err = tables.DecodeEventBlob(entry.EventJson, &text)
+			prompts[ref] = text
+		}
+		if err != nil {
+			log.Printf("correlationID: %s error unmarshalling ledger event %s: %s", ledgerItem.LedgerID, entry.EventKey, err)
+			return ledgerItem, err
+		}
+	}
+	mediaEvents, err := tables.ResolvePrompts(mediaEvents, prompts)
+	if err != nil {
+		log.Printf("correlationID: %s error resolving media event prompts: %s", ledgerItem.LedgerID, err)
+		return ledgerItem, err
+	}
+	if len(invalidatedKeys) > 0 {
+		ledgerItem, err = withoutInvalidatedEvents(ledgerItem, invalidatedKeys)
+		if err != nil {
+			return ledgerItem, err
+		}
+	}
+
+	ledgerItem, _, err = withJoinedMediaEvents(ledgerItem, mediaEvents)
+	if err != nil {
+		return ledgerItem, err
+	}
+	ledgerItem, _, err = withJoinedPublishEvents(ledgerItem, publishEvents)
+	return ledgerItem, err
+}
+
+func deleteLedgerEvents(ledgerId string) error {
+	entries, err := getLedgerEventEntries(ledgerId)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		_, err = svc.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(dynamo_configuration.TABLE_LEDGER_EVENTS),
+			Key: map[string]*dynamodb.AttributeValue{
+				"LedgerID": {
+					S: aws.String(ledgerId),
+				},
+				"EventKey": {
+					S: aws.String(entry.EventKey),
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("correlationID: %s error deleting ledger event %s: %s", ledgerId, entry.EventKey, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package dal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func ledgerEventEntry(t *testing.T, eventType string, eventId string, sequence int64, event interface{}) LedgerEventEntry {
+	eventJson, err := json.Marshal(event)
+	assert.NoError(t, err)
+	return LedgerEventEntry{LedgerID: "l1", EventKey: ledgerEventKey(eventType, eventId), Sequence: sequence, EventJson: eventJson}
+}
+
+func TestHydrateLedgerEvents(t *testing.T) {
+	legacy, _ := json.Marshal([]tables.MediaEvent{{LedgerID: "l1", EventID: "legacy"}})
+	ledger := tables.Ledger{LedgerID: "l1", MediaEvents: legacy}
+	completed := tables.PublishEvent{LedgerID: "l1", DistributionChannel: "Medium", AccountID: "a", PublisherProfileID: "p", PublishStatus: tables.COMPLETE}
+	entries := []LedgerEventEntry{
+		ledgerEventEntry(t, LEDGER_EVENT_MEDIA, "second", 20, tables.MediaEvent{LedgerID: "l1", EventID: "second"}),
+		ledgerEventEntry(t, LEDGER_EVENT_PUBLISH, completed.GetEventID(), 15, completed),
+		ledgerEventEntry(t, LEDGER_EVENT_MEDIA, "first", 10, tables.MediaEvent{LedgerID: "l1", EventID: "first"}),
+		ledgerEventEntry(t, LEDGER_EVENT_MEDIA, "legacy", 5, tables.MediaEvent{LedgerID: "l1", EventID: "legacy"}),
+	}
+	sortLedgerEventEntries(entries)
+
+	hydrated, err := hydrateLedgerEvents(ledger, entries)
+	assert.NoError(t, err)
+	mediaEvents, _ := hydrated.GetExistingMediaEvents()
+	ids := []string{}
+	for _, m := range mediaEvents {
+		ids = append(ids, m.EventID)
+	}
+	assert.Equal(t, []string{"legacy", "first", "second"}, ids)
+	publishEvents, _ := hydrated.GetExistingPublishEvents()
+	assert.Len(t, publishEvents, 1)
+	assert.Equal(t, tables.COMPLETE, publishEvents[0].PublishStatus)
+	assert.Empty(t, hydrated.MediaEvents, "hydrated events are kept decoded rather than re-encoded")
+	assert.Empty(t, hydrated.PublishEvents)
+}
+
+func TestHydrateLedgerEventsResolvesPrompts(t *testing.T) {
+	script := strings.Repeat("Abridge the forum post. ", 10)
+	prompts := map[string]string{}
+	deduped := tables.DedupePrompts([]tables.MediaEvent{{LedgerID: "l1", EventID: "child", PromptInstruction: script}}, prompts)
+	promptBlob, _ := tables.EncodeEventBlob(script)
+	eventBlob, _ := tables.EncodeEventBlob(deduped[0])
+	entries := []LedgerEventEntry{
+		{LedgerID: "l1", EventKey: ledgerEventKey(LEDGER_EVENT_PROMPT, tables.PromptRef(script)), Sequence: 1, EventJson: promptBlob},
+		{LedgerID: "l1", EventKey: ledgerEventKey(LEDGER_EVENT_MEDIA, "child"), Sequence: 2, EventJson: eventBlob},
+	}
+
+	hydrated, err := hydrateLedgerEvents(tables.Ledger{LedgerID: "l1"}, entries)
+	assert.NoError(t, err)
+	mediaEvents, _ := hydrated.GetExistingMediaEvents()
+	assert.Len(t, mediaEvents, 1)
+	assert.Equal(t, script, mediaEvents[0].PromptInstruction)
+
+	_, err = hydrateLedgerEvents(tables.Ledger{LedgerID: "l1"}, entries[1:])
+	assert.Error(t, err, "a media event can't be read without its prompt")
+}
+
+func TestHydrateLedgerEventsSkipsInvalidatedLegacyEvents(t *testing.T) {
+	legacy, _ := json.Marshal([]tables.MediaEvent{{LedgerID: "l1", EventID: "kept"}, {LedgerID: "l1", EventID: "redriven"}})
+	ledger := tables.Ledger{LedgerID: "l1", MediaEvents: legacy}
+	entries := []LedgerEventEntry{
+		{LedgerID: "l1", EventKey: invalidatedEventEntryKey(5, ledgerEventKey(LEDGER_EVENT_MEDIA, "redriven")), Sequence: 5},
+		ledgerEventEntry(t, LEDGER_EVENT_MEDIA, "regenerated", 10, tables.MediaEvent{LedgerID: "l1", EventID: "regenerated"}),
+	}
+
+	hydrated, err := hydrateLedgerEvents(ledger, entries)
+	assert.NoError(t, err)
+	mediaEvents, _ := hydrated.GetExistingMediaEvents()
+	assert.Len(t, mediaEvents, 2)
+	assert.Equal(t, "kept", mediaEvents[0].EventID)
+	assert.Equal(t, "regenerated", mediaEvents[1].EventID)
+}
+
+func TestInvalidatedEventKey(t *testing.T) {
+	eventKey := ledgerEventKey(LEDGER_EVENT_PUBLISH, "Medium.acc.pub.Assigned")
+	parsed, ok := invalidatedEventKey(invalidatedEventEntryKey(123, eventKey))
+	assert.True(t, ok)
+	assert.Equal(t, eventKey, parsed)
+	_, ok = invalidatedEventKey(eventKey)
+	assert.False(t, ok)
+}
+
+func TestNewLedgerVersionBump(t *testing.T) {
+	versions := ledgerVersions{versionKey: "PublishEventsVersion", historyVersion: 4, eventsVersion: 2}
+	update := newLedgerVersionBump("l1", versions, "", nil).Update
+	assert.Equal(t, "SET HistoryVersion = :hv, PublishEventsVersion = :ev", *update.UpdateExpression)
+	assert.Equal(t, "attribute_exists(LedgerID) AND HistoryVersion = :ohv", *update.ConditionExpression)
+	assert.Equal(t, "5", *update.ExpressionAttributeValues[":hv"].N)
+	assert.Equal(t, "3", *update.ExpressionAttributeValues[":ev"].N)
+
+	first := newLedgerVersionBump("l1", ledgerVersions{versionKey: "MediaEventsVersion"}, "", nil).Update
+	assert.Contains(t, *first.ConditionExpression, "attribute_not_exists(HistoryVersion)", "expected ledgers without history to be bumped")
+
+	history := versions.bumped().withHistory(LedgerHistoryEntry{LedgerID: "l1"})
+	assert.Equal(t, int64(5), history.HistoryVersion)
+	assert.Equal(t, int64(3), history.EventsVersion)
+}
@@ -0,0 +1,147 @@
+package dal
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	env "github.com/bezalel-media-core/v2/configuration"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Who appended to a ledger; recorded with each append in the ledger history.
+type LedgerAudit struct {
+	WorkflowName string
+	ProcessID    string // processId of RunWorkflows.
+}
+
+// Immutable record of one append that added events to a ledger.
+type LedgerHistoryEntry struct {
+	LedgerID             string
+	HistoryVersion       int64  // Ledger HistoryVersion after the append; 1 for the first append.
+	EventType            string // LEDGER_EVENT_MEDIA, LEDGER_EVENT_PUBLISH or LEDGER_EVENT_INVALIDATED
+	EventsVersion        int64  // MediaEventsVersion, PublishEventsVersion or RedriveVersion after the append.
+	WorkflowName         string
+	ProcessID            string
+	AddedEvents          tables.EventBlob // Event blob of the events the append added, or of the LedgerInvalidation.
+	RecordedAtEpochMilli int64
+	TTL                  int64 // epoch seconds
+}
+
+func (e *LedgerHistoryEntry) GetAddedMediaEvents() ([]tables.MediaEvent, error) {
+	if e.EventType != LEDGER_EVENT_MEDIA {
+		return []tables.MediaEvent{}, nil
+	}
+	return tables.DecodeMediaEvents(e.AddedEvents)
+}
+
+// For an invalidation, its CompensatingEvents.
+func (e *LedgerHistoryEntry) GetAddedPublishEvents() ([]tables.PublishEvent, error) {
+	if e.EventType == LEDGER_EVENT_INVALIDATED {
+		invalidation, _, err := e.GetInvalidation()
+		return append([]tables.PublishEvent{}, invalidation.CompensatingEvents...), err
+	}
+	if e.EventType != LEDGER_EVENT_PUBLISH {
+		return []tables.PublishEvent{}, nil
+	}
+	return tables.DecodePublishEvents(e.AddedEvents)
+}
+
+func (e *LedgerHistoryEntry) GetInvalidation() (LedgerInvalidation, bool, error) {
+	invalidation := LedgerInvalidation{}
+	if e.EventType != LEDGER_EVENT_INVALIDATED {
+		return invalidation, false, nil
+	}
+	err := tables.DecodeEventBlob(e.AddedEvents, &invalidation)
+	return invalidation, true, err
+}
+
+func newLedgerHistoryEntry(ledgerId string, eventType string, audit LedgerAudit, addedEvents tables.EventBlob) LedgerHistoryEntry {
+	return LedgerHistoryEntry{
+		LedgerID:             ledgerId,
+		EventType:            eventType,
+		WorkflowName:         audit.WorkflowName,
+		ProcessID:            audit.ProcessID,
+		AddedEvents:          addedEvents,
+		RecordedAtEpochMilli: time.Now().UnixMilli(),
+	}
+}
+
+func createLedgerHistoryEntry(entry LedgerHistoryEntry) error {
+	put, err := newLedgerHistoryPut(entry)
+	if err != nil {
+		return err
+	}
+	_, err = svc.PutItem(&dynamodb.PutItemInput{
+		Item:                put.Put.Item,
+		TableName:           put.Put.TableName,
+		ConditionExpression: put.Put.ConditionExpression,
+	})
+	if err != nil {
+		log.Printf("correlationID: %s got error calling PutItem ledger history version %d: %s", entry.LedgerID, entry.HistoryVersion, err)
+	}
+	return err
+}
+
+// Put of the history record; fails when the version is already recorded.
+func newLedgerHistoryPut(entry LedgerHistoryEntry) (*dynamodb.TransactWriteItem, error) {
+	entry.TTL = time.Now().Unix() + env.GetEnvConfigs().LedgerHistoryTTLHours*60*60
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		log.Printf("correlationID: %s got error marshalling ledger history: %s", entry.LedgerID, err)
+		return nil, err
+	}
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			Item:                av,
+			TableName:           aws.String(dynamo_configuration.TABLE_LEDGER_HISTORY),
+			ConditionExpression: aws.String("attribute_not_exists(HistoryVersion)"),
+		},
+	}, nil
+}
+
+// All appends of the ledger, oldest first.
+func GetLedgerHistory(ledgerId string) ([]LedgerHistoryEntry, error) {
+	entries := []LedgerHistoryEntry{}
+	var startKey map[string]*dynamodb.AttributeValue
+	for {
+		result, err := svc.Query(&dynamodb.QueryInput{
+			TableName:              aws.String(dynamo_configuration.TABLE_LEDGER_HISTORY),
+			KeyConditionExpression: aws.String("LedgerID = :id"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":id": {
+					S: aws.String(ledgerId),
+				},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("correlationID: %s error querying ledger history: %s", ledgerId, err)
+			return entries, err
+		}
+		page := []LedgerHistoryEntry{}
+		err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &page)
+		if err != nil {
+			log.Printf("correlationID: %s error unmarshalling ledger history: %s", ledgerId, err)
+			return entries, err
+		}
+		entries = append(entries, page...)
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = result.LastEvaluatedKey
+	}
+	return entries, nil
+}
+
+func parseVersionAttribute(attributes map[string]*dynamodb.AttributeValue, key string) (int64, error) {
+	value, ok := attributes[key]
+	if !ok || value.N == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(*value.N, 10, 64)
+}
@@ -0,0 +1,218 @@
+package dal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Events a redrive removes from a ledger so the workflows append them again.
+type LedgerInvalidation struct {
+	LedgerID        string
+	Stage           string
+	Channel         string // Set when the redrive is scoped to one distribution channel.
+	MediaEventIDs   []string
+	PublishEventIDs []string
+	// Renewed events written in place of the invalidated events of the same EventID, e.g. ASSIGNED events
+	// that keep their assignment.
+	CompensatingEvents []tables.PublishEvent `json:",omitempty"`
+}
+
+func invalidatedEventEntryKey(sequence int64, eventKey string) string {
+	return fmt.Sprintf("%s#%d#%s", LEDGER_EVENT_INVALIDATED, sequence, eventKey)
+}
+
+// The <Media|Publish>#<EventID> key of an Invalidated entry.
+func invalidatedEventKey(entryKey string) (string, bool) {
+	rest, ok := strings.CutPrefix(entryKey, LEDGER_EVENT_INVALIDATED+"#")
+	if !ok {
+		return "", false
+	}
+	_, eventKey, ok := strings.Cut(rest, "#")
+	return eventKey, ok
+}
+
+// Moves each event item to an Invalidated entry and writes the CompensatingEvents in place of the invalidated
+// events of the same EventID, conditioned on the event items read. The last transaction reopens the ledger:
+// LedgerStatus New, HeartbeatCount 0, RedrivenAtEpochMilli now, and a bumped RedriveVersion, which fires the
+// ledger stream. So the workflows only run once every event is moved; moves beyond one transaction are written
+// ahead of it.
+func InvalidateLedgerEvents(audit LedgerAudit, invalidation LedgerInvalidation) error {
+	ledgerId := invalidation.LedgerID
+	entries, err := getLedgerEventEntries(ledgerId)
+	if err != nil {
+		return err
+	}
+	read := map[string]LedgerEventEntry{}
+	for _, e := range entries {
+		read[e.EventKey] = e
+	}
+	eventKeys := []string{}
+	for _, id := range invalidation.MediaEventIDs {
+		eventKeys = append(eventKeys, ledgerEventKey(LEDGER_EVENT_MEDIA, id))
+	}
+	for _, id := range invalidation.PublishEventIDs {
+		eventKeys = append(eventKeys, ledgerEventKey(LEDGER_EVENT_PUBLISH, id))
+	}
+	sequence := time.Now().UnixNano()
+	replacements := map[string]*dynamodb.TransactWriteItem{}
+	for i, p := range invalidation.CompensatingEvents {
+		put, err := newLedgerEventPut(ledgerId, LEDGER_EVENT_PUBLISH, ledgerEvent{eventId: p.GetEventID(), event: p},
+			sequence+int64(len(eventKeys)+i))
+		if err != nil {
+			return err
+		}
+		replacements[ledgerEventKey(LEDGER_EVENT_PUBLISH, p.GetEventID())] = put
+	}
+
+	chunks := [][]*dynamodb.TransactWriteItem{{}}
+	addToChunk := func(items ...*dynamodb.TransactWriteItem) {
+		if len(chunks[len(chunks)-1])+len(items) > maxTransactItems-2 {
+			chunks = append(chunks, []*dynamodb.TransactWriteItem{})
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], items...)
+	}
+	for i, eventKey := range eventKeys {
+		existing, ok := read[eventKey]
+		move, err := newLedgerEventMove(ledgerId, eventKey, existing, ok, replacements[eventKey], sequence+int64(i))
+		if err != nil {
+			return err
+		}
+		delete(replacements, eventKey)
+		addToChunk(move...)
+	}
+	for _, p := range invalidation.CompensatingEvents {
+		if put, ok := replacements[ledgerEventKey(LEDGER_EVENT_PUBLISH, p.GetEventID())]; ok {
+			addToChunk(put) // Renews no invalidated event; a plain conditional put.
+		}
+	}
+	for _, chunk := range chunks[:len(chunks)-1] {
+		_, err = svc.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: chunk})
+		var canceled *dynamodb.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			err = ErrLedgerAppendConflict
+		}
+		if err != nil {
+			log.Printf("correlationID: %s error invalidating ledger events: %s", ledgerId, err)
+			return err
+		}
+	}
+
+	invalidationBlob, err := tables.EncodeEventBlob(invalidation)
+	if err != nil {
+		return err
+	}
+	values := map[string]*dynamodb.AttributeValue{
+		":zero": {
+			N: aws.String("0"),
+		},
+		":status": {
+			S: aws.String(string(tables.NEW_LEDGER)),
+		},
+		":at": {
+			N: aws.String(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+		},
+	}
+	history := newLedgerHistoryEntry(ledgerId, LEDGER_EVENT_INVALIDATED, audit, invalidationBlob)
+	return recordLedgerChange(history, "RedriveVersion", "HeartbeatCount = :zero, LedgerStatus = :status, RedrivenAtEpochMilli = :at",
+		values, chunks[len(chunks)-1])
+}
+
+// Put of the Invalidated entry, and a delete of the event item or the put of its replacement, conditioned on the
+// item still being the one read. Events of the legacy blobs have no item; their Invalidated entry hides them.
+func newLedgerEventMove(ledgerId string, eventKey string, existing LedgerEventEntry, hasItem bool,
+	replacement *dynamodb.TransactWriteItem, sequence int64) ([]*dynamodb.TransactWriteItem, error) {
+	entry := LedgerEventEntry{
+		LedgerID:  ledgerId,
+		EventKey:  invalidatedEventEntryKey(sequence, eventKey),
+		Sequence:  sequence,
+		EventJson: existing.EventJson, // Empty for events of the legacy blobs.
+		TTL:       time.Now().Unix() + ledgerTTLSeconds,
+	}
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		return nil, err
+	}
+	move := []*dynamodb.TransactWriteItem{{
+		Put: &dynamodb.Put{
+			Item:                av,
+			TableName:           aws.String(dynamo_configuration.TABLE_LEDGER_EVENTS),
+			ConditionExpression: aws.String("attribute_not_exists(EventKey)"),
+		},
+	}}
+	if !hasItem {
+		if replacement != nil {
+			move = append(move, replacement) // Conditioned on no item.
+		}
+		return move, nil
+	}
+
+	names := map[string]*string{
+		"#sequence": aws.String("Sequence"),
+	}
+	values := map[string]*dynamodb.AttributeValue{
+		":sequence": {
+			N: aws.String(strconv.FormatInt(existing.Sequence, 10)),
+		},
+	}
+	condition := aws.String("#sequence = :sequence")
+	if replacement != nil {
+		replacement.Put.ConditionExpression = condition
+		replacement.Put.ExpressionAttributeNames = names
+		replacement.Put.ExpressionAttributeValues = values
+		return append(move, replacement), nil
+	}
+	return append(move, &dynamodb.TransactWriteItem{
+		Delete: &dynamodb.Delete{
+			TableName: aws.String(dynamo_configuration.TABLE_LEDGER_EVENTS),
+			Key: map[string]*dynamodb.AttributeValue{
+				"LedgerID": {
+					S: aws.String(ledgerId),
+				},
+				"EventKey": {
+					S: aws.String(eventKey),
+				},
+			},
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		},
+	}), nil
+}
+
+// Drops invalidated events from the ledger's MediaEvents and PublishEvents blobs.
+func withoutInvalidatedEvents(ledgerItem tables.Ledger, invalidatedKeys map[string]bool) (tables.Ledger, error) {
+	mediaEvents, err := ledgerItem.GetExistingMediaEvents()
+	if err != nil {
+		return ledgerItem, err
+	}
+	keptMedia := []tables.MediaEvent{}
+	for _, m := range mediaEvents {
+		if !invalidatedKeys[ledgerEventKey(LEDGER_EVENT_MEDIA, m.EventID)] {
+			keptMedia = append(keptMedia, m)
+		}
+	}
+	publishEvents, err := ledgerItem.GetExistingPublishEvents()
+	if err != nil {
+		return ledgerItem, err
+	}
+	keptPublish := []tables.PublishEvent{}
+	for _, p := range publishEvents {
+		if !invalidatedKeys[ledgerEventKey(LEDGER_EVENT_PUBLISH, p.GetEventID())] {
+			keptPublish = append(keptPublish, p)
+		}
+	}
+
+	ledgerItem.SetMediaEvents(keptMedia)
+	ledgerItem.SetPublishEvents(keptPublish)
+	return ledgerItem, nil
+}
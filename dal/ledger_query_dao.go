@@ -0,0 +1,253 @@
+package dal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Bounds the index pages read by one ListLedgers call when filters discard most items.
+const maxListLedgerQueries = 10
+
+var ErrInvalidPageToken = errors.New("invalid ledger page token")
+
+// Filters of ListLedgers; zero values are unbounded.
+type LedgerFilter struct {
+	Status                  tables.LedgerStatus // Empty lists every status, one LedgerStatusIndex partition after another.
+	CreatedAfterEpochMilli  int64               // Inclusive.
+	CreatedBeforeEpochMilli int64               // Exclusive.
+	Source                  string
+	Language                string
+	Search                  string // Case-insensitive text of the trigger payload or a generated title.
+	NewestFirst             bool
+}
+
+type LedgerPage struct {
+	Ledgers       []tables.Ledger // Without MediaEvents and PublishEvents.
+	NextPageToken string          // Empty on the last page.
+}
+
+// Position after the last ledger of a page.
+type ledgerPageToken struct {
+	Status    tables.LedgerStatus `json:"s"`
+	LedgerID  string              `json:"id,omitempty"` // Empty to start at the beginning of Status.
+	CreatedAt int64               `json:"c"`
+}
+
+func encodeLedgerPageToken(token ledgerPageToken) string {
+	raw, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeLedgerPageToken(encoded string) (ledgerPageToken, error) {
+	token := ledgerPageToken{}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return token, ErrInvalidPageToken
+	}
+	err = json.Unmarshal(raw, &token)
+	if err != nil {
+		return token, ErrInvalidPageToken
+	}
+	if _, err = tables.ParseLedgerStatus(string(token.Status)); err != nil {
+		return token, ErrInvalidPageToken
+	}
+	return token, nil
+}
+
+func (t ledgerPageToken) startKey() map[string]*dynamodb.AttributeValue {
+	if t.LedgerID == "" {
+		return nil
+	}
+	return map[string]*dynamodb.AttributeValue{
+		"LedgerID":                  {S: aws.String(t.LedgerID)},
+		"LedgerStatus":              {S: aws.String(string(t.Status))},
+		"LedgerCreatedAtEpochMilli": {N: aws.String(strconv.FormatInt(t.CreatedAt, 10))},
+	}
+}
+
+// Statuses to query in order, resuming at the page token's status.
+func listLedgerStatuses(filter LedgerFilter, token *ledgerPageToken) ([]tables.LedgerStatus, error) {
+	statuses := tables.LEDGER_STATUSES
+	if filter.Status != "" {
+		statuses = []tables.LedgerStatus{filter.Status}
+	}
+	if token == nil {
+		return statuses, nil
+	}
+	for i, s := range statuses {
+		if s == token.Status {
+			return statuses[i:], nil
+		}
+	}
+	return nil, ErrInvalidPageToken
+}
+
+// Queries the LedgerStatusIndex, oldest first unless NewestFirst; at most limit ledgers per page.
+// A page may hold fewer than limit ledgers, and still have a NextPageToken, when filters discard most items.
+func ListLedgers(filter LedgerFilter, limit int, pageToken string) (LedgerPage, error) {
+	page := LedgerPage{Ledgers: []tables.Ledger{}}
+	var token *ledgerPageToken
+	if pageToken != "" {
+		decoded, err := decodeLedgerPageToken(pageToken)
+		if err != nil {
+			return page, err
+		}
+		token = &decoded
+	}
+	statuses, err := listLedgerStatuses(filter, token)
+	if err != nil {
+		return page, err
+	}
+
+	queries := 0
+	for _, status := range statuses {
+		var startKey map[string]*dynamodb.AttributeValue
+		if token != nil && token.Status == status {
+			startKey = token.startKey()
+		}
+		for {
+			if queries == maxListLedgerQueries {
+				page.NextPageToken = encodeLedgerPageToken(pageTokenOf(status, startKey))
+				return page, nil
+			}
+			queries++
+			result, err := svc.Query(newListLedgersQuery(filter, status, limit, startKey))
+			if err != nil {
+				log.Printf("error querying ledgers by status %s: %s", status, err)
+				return page, err
+			}
+			items := []tables.Ledger{}
+			err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &items)
+			if err != nil {
+				log.Printf("error unmarshalling listed ledgers: %s", err)
+				return page, err
+			}
+			for i, item := range items {
+				if !MatchesLedgerSearch(item, filter.Search) {
+					continue
+				}
+				page.Ledgers = append(page.Ledgers, item)
+				isLastItem := i == len(items)-1 && len(result.LastEvaluatedKey) == 0
+				if len(page.Ledgers) == limit && !(isLastItem && status == statuses[len(statuses)-1]) {
+					page.NextPageToken = encodeLedgerPageToken(ledgerPageToken{Status: status, LedgerID: item.LedgerID, CreatedAt: item.LedgerCreatedAtEpochMilli})
+					return page, nil
+				}
+			}
+			if len(result.LastEvaluatedKey) == 0 {
+				break
+			}
+			startKey = result.LastEvaluatedKey
+		}
+	}
+	return page, nil
+}
+
+// Token resuming at the index key; a nil key resumes at the beginning of status.
+func pageTokenOf(status tables.LedgerStatus, key map[string]*dynamodb.AttributeValue) ledgerPageToken {
+	token := ledgerPageToken{Status: status}
+	if key == nil {
+		return token
+	}
+	token.LedgerID = aws.StringValue(key["LedgerID"].S)
+	token.CreatedAt, _ = strconv.ParseInt(aws.StringValue(key["LedgerCreatedAtEpochMilli"].N), 10, 64)
+	return token
+}
+
+func newListLedgersQuery(filter LedgerFilter, status tables.LedgerStatus, limit int, startKey map[string]*dynamodb.AttributeValue) *dynamodb.QueryInput {
+	createdBefore := int64(math.MaxInt64)
+	if filter.CreatedBeforeEpochMilli > 0 {
+		createdBefore = filter.CreatedBeforeEpochMilli - 1
+	}
+	values := map[string]*dynamodb.AttributeValue{
+		":status": {S: aws.String(string(status))},
+		":from":   {N: aws.String(strconv.FormatInt(filter.CreatedAfterEpochMilli, 10))},
+		":to":     {N: aws.String(strconv.FormatInt(createdBefore, 10))},
+	}
+	filters := []string{}
+	if filter.Source != "" {
+		values[":source"] = &dynamodb.AttributeValue{S: aws.String(filter.Source)}
+		filters = append(filters, "TriggerEventSource = :source")
+	}
+	if filter.Language != "" {
+		keys := []string{}
+		for i, l := range tables.LanguageCodeVariants(filter.Language) {
+			key := fmt.Sprintf(":lang%d", i)
+			values[key] = &dynamodb.AttributeValue{S: aws.String(l)}
+			keys = append(keys, key)
+		}
+		filters = append(filters, fmt.Sprintf("TriggerEventTargetLanguage IN (%s)", strings.Join(keys, ", ")))
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+		IndexName:                 aws.String(dynamo_configuration.EVENT_LEDGER_STATE_GSI_NAME),
+		KeyConditionExpression:    aws.String("LedgerStatus = :status AND LedgerCreatedAtEpochMilli BETWEEN :from AND :to"),
+		ExpressionAttributeValues: values,
+		ProjectionExpression: aws.String("LedgerID, LedgerStatus, LedgerCreatedAtEpochMilli, TriggerEventPayload, TriggerEventSource, " +
+			"TriggerEventTargetLanguage, GeneratedTitles, HeartbeatCount, HistoryVersion, QuarantineReason, QuarantinedAtEpochMilli, " +
+			"LastWorkflowErrors"),
+		ScanIndexForward:  aws.Bool(!filter.NewestFirst),
+		Limit:             aws.Int64(int64(limit)),
+		ExclusiveStartKey: startKey,
+	}
+	if len(filters) > 0 {
+		input.FilterExpression = aws.String(strings.Join(filters, " AND "))
+	}
+	return input
+}
+
+// Empty search matches every ledger.
+func MatchesLedgerSearch(ledgerItem tables.Ledger, search string) bool {
+	search = strings.ToLower(strings.TrimSpace(search))
+	if search == "" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(ledgerItem.TriggerEventPayload), search) {
+		return true
+	}
+	for _, title := range ledgerItem.GeneratedTitles {
+		if strings.Contains(strings.ToLower(title), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// Appends the title once; a no-op for a missing ledger.
+func AddGeneratedTitle(ledgerId string, title string) error {
+	_, err := svc.UpdateItem(&dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"LedgerID": {
+				S: aws.String(ledgerId),
+			},
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":title":  {S: aws.String(title)},
+			":titles": {L: []*dynamodb.AttributeValue{{S: aws.String(title)}}},
+			":empty":  {L: []*dynamodb.AttributeValue{}},
+		},
+		TableName:           aws.String(dynamo_configuration.TABLE_EVENT_LEDGER),
+		ReturnValues:        aws.String("NONE"),
+		UpdateExpression:    aws.String("SET GeneratedTitles = list_append(if_not_exists(GeneratedTitles, :empty), :titles)"),
+		ConditionExpression: aws.String("attribute_exists(LedgerID) AND NOT contains(GeneratedTitles, :title)"),
+	})
+	if hasVersionConflict(err) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("correlationID: %s error adding generated title: %s", ledgerId, err)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package dal
+
+import (
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerPageTokenRoundTrip(t *testing.T) {
+	token := ledgerPageToken{Status: tables.NEW_LEDGER, LedgerID: "l1", CreatedAt: 1700000000000}
+	decoded, err := decodeLedgerPageToken(encodeLedgerPageToken(token))
+	assert.NoError(t, err)
+	assert.Equal(t, token, decoded)
+	assert.Equal(t, "l1", *decoded.startKey()["LedgerID"].S)
+	assert.Equal(t, "1700000000000", *decoded.startKey()["LedgerCreatedAtEpochMilli"].N)
+
+	_, err = decodeLedgerPageToken("not a token!")
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+	_, err = decodeLedgerPageToken(encodeLedgerPageToken(ledgerPageToken{Status: "Bogus", LedgerID: "l1"}))
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+	assert.Nil(t, ledgerPageToken{Status: tables.NEW_LEDGER}.startKey())
+}
+
+func TestListLedgerStatuses(t *testing.T) {
+	statuses, err := listLedgerStatuses(LedgerFilter{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tables.LEDGER_STATUSES, statuses)
+
+	statuses, err = listLedgerStatuses(LedgerFilter{}, &ledgerPageToken{Status: tables.FINISHED_LEDGER})
+	assert.NoError(t, err)
+	assert.Equal(t, []tables.LedgerStatus{tables.FINISHED_LEDGER, tables.QUARANTINED_LEDGER}, statuses)
+
+	_, err = listLedgerStatuses(LedgerFilter{Status: tables.NEW_LEDGER}, &ledgerPageToken{Status: tables.FINISHED_LEDGER})
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestNewListLedgersQuery(t *testing.T) {
+	filter := LedgerFilter{CreatedBeforeEpochMilli: 1000, Source: "v1/source/forum", Language: "en", NewestFirst: true}
+	input := newListLedgersQuery(filter, tables.NEW_LEDGER, 25, nil)
+	assert.Equal(t, "0", *input.ExpressionAttributeValues[":from"].N)
+	assert.Equal(t, "999", *input.ExpressionAttributeValues[":to"].N)
+	assert.Equal(t, "TriggerEventSource = :source AND TriggerEventTargetLanguage IN (:lang0, :lang1)", *input.FilterExpression)
+	assert.Equal(t, "EN", *input.ExpressionAttributeValues[":lang1"].S)
+	assert.False(t, *input.ScanIndexForward)
+	assert.Equal(t, int64(25), *input.Limit)
+
+	input = newListLedgersQuery(LedgerFilter{}, tables.NEW_LEDGER, 25, nil)
+	assert.Nil(t, input.FilterExpression)
+}
+
+func TestMatchesLedgerSearch(t *testing.T) {
+	ledger := tables.Ledger{TriggerEventPayload: "My landlord kept the deposit", GeneratedTitles: []string{"Tenant Wins In Court #shorts"}}
+	assert.True(t, MatchesLedgerSearch(ledger, ""))
+	assert.True(t, MatchesLedgerSearch(ledger, "LANDLORD"))
+	assert.True(t, MatchesLedgerSearch(ledger, " wins in court "))
+	assert.False(t, MatchesLedgerSearch(ledger, "eviction"))
+}
@@ -0,0 +1,253 @@
+package dal
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Ledger persistence used by the orchestration workflows.
+// Appends are idempotent per EventID and bump MediaEventsVersion/PublishEventsVersion when an event was added.
+// A missing ledger is returned empty; appending to one fails with ErrLedgerNotFound.
+type LedgerStore interface {
+	CreateLedger(item tables.Ledger) error
+	GetLedger(ledgerId string) (tables.Ledger, error)
+	DeleteLedger(ledgerId string) error
+	AppendLedgerMediaEvents(audit LedgerAudit, ledgerId string, mediaEvents []tables.MediaEvent) error
+	AppendLedgerPublishEvents(audit LedgerAudit, ledgerId string, publishEvents []tables.PublishEvent) error
+	SetLedgerStatus(ledgerEntry tables.Ledger, status tables.LedgerStatus) error
+	IncrementHeartbeat(ledgerId string, curHeartbeatCount int64) error
+	GetLedgerHistory(ledgerId string) ([]LedgerHistoryEntry, error)
+	AddGeneratedTitle(ledgerId string, title string) error
+	InvalidateLedgerEvents(audit LedgerAudit, invalidation LedgerInvalidation) error
+	QuarantineLedger(ledgerId string, reason string, workflowErrors []tables.WorkflowError) (bool, error)
+}
+
+type DynamoLedgerStore struct{}
+
+func NewDynamoLedgerStore() LedgerStore {
+	return DynamoLedgerStore{}
+}
+
+func (s DynamoLedgerStore) CreateLedger(item tables.Ledger) error {
+	return CreateLedger(item)
+}
+
+func (s DynamoLedgerStore) GetLedger(ledgerId string) (tables.Ledger, error) {
+	return GetLedger(ledgerId)
+}
+
+func (s DynamoLedgerStore) DeleteLedger(ledgerId string) error {
+	return DeleteLedger(ledgerId)
+}
+
+func (s DynamoLedgerStore) AppendLedgerMediaEvents(audit LedgerAudit, ledgerId string, mediaEvents []tables.MediaEvent) error {
+	return AppendLedgerMediaEvents(audit, ledgerId, mediaEvents)
+}
+
+func (s DynamoLedgerStore) AppendLedgerPublishEvents(audit LedgerAudit, ledgerId string, publishEvents []tables.PublishEvent) error {
+	return AppendLedgerPublishEvents(audit, ledgerId, publishEvents)
+}
+
+func (s DynamoLedgerStore) SetLedgerStatus(ledgerEntry tables.Ledger, status tables.LedgerStatus) error {
+	return SetLedgerStatus(ledgerEntry, status)
+}
+
+func (s DynamoLedgerStore) IncrementHeartbeat(ledgerId string, curHeartbeatCount int64) error {
+	return IncrementHeartbeat(ledgerId, curHeartbeatCount)
+}
+
+func (s DynamoLedgerStore) GetLedgerHistory(ledgerId string) ([]LedgerHistoryEntry, error) {
+	return GetLedgerHistory(ledgerId)
+}
+
+func (s DynamoLedgerStore) AddGeneratedTitle(ledgerId string, title string) error {
+	return AddGeneratedTitle(ledgerId, title)
+}
+
+func (s DynamoLedgerStore) InvalidateLedgerEvents(audit LedgerAudit, invalidation LedgerInvalidation) error {
+	return InvalidateLedgerEvents(audit, invalidation)
+}
+
+func (s DynamoLedgerStore) QuarantineLedger(ledgerId string, reason string, workflowErrors []tables.WorkflowError) (bool, error) {
+	return QuarantineLedger(ledgerId, reason, workflowErrors)
+}
+
+// Thread-safe LedgerStore for offline tests; nothing is persisted.
+type MemoryLedgerStore struct {
+	mu      sync.Mutex
+	ledgers map[string]tables.Ledger
+	history map[string][]LedgerHistoryEntry
+}
+
+func NewMemoryLedgerStore() *MemoryLedgerStore {
+	return &MemoryLedgerStore{ledgers: map[string]tables.Ledger{}, history: map[string][]LedgerHistoryEntry{}}
+}
+
+func (s *MemoryLedgerStore) CreateLedger(item tables.Ledger) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ledgers[item.LedgerID] = newLedgerRecord(item)
+	return nil
+}
+
+func (s *MemoryLedgerStore) GetLedger(ledgerId string) (tables.Ledger, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ledgers[ledgerId], nil
+}
+
+func (s *MemoryLedgerStore) DeleteLedger(ledgerId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ledgers, ledgerId)
+	return nil
+}
+
+func (s *MemoryLedgerStore) GetLedgerHistory(ledgerId string) ([]LedgerHistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]LedgerHistoryEntry{}, s.history[ledgerId]...), nil
+}
+
+func (s *MemoryLedgerStore) AppendLedgerMediaEvents(audit LedgerAudit, ledgerId string, mediaEvents []tables.MediaEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.ledgers[ledgerId]
+	if !ok {
+		return ErrLedgerNotFound
+	}
+	stored, added, err := withJoinedMediaEvents(stored, mediaEvents)
+	if err != nil || len(added) == 0 {
+		return err
+	}
+	addedBlob, err := tables.EncodeMediaEvents(added)
+	if err != nil {
+		return err
+	}
+	stored.MediaEventsVersion++
+	s.recordAppend(&stored, newLedgerHistoryEntry(ledgerId, LEDGER_EVENT_MEDIA, audit, addedBlob), stored.MediaEventsVersion)
+	return nil
+}
+
+func (s *MemoryLedgerStore) AppendLedgerPublishEvents(audit LedgerAudit, ledgerId string, publishEvents []tables.PublishEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.ledgers[ledgerId]
+	if !ok {
+		return ErrLedgerNotFound
+	}
+	stored, added, err := withJoinedPublishEvents(stored, publishEvents)
+	if err != nil || len(added) == 0 {
+		return err
+	}
+	addedBlob, err := tables.EncodePublishEvents(added)
+	if err != nil {
+		return err
+	}
+	stored.PublishEventsVersion++
+	s.recordAppend(&stored, newLedgerHistoryEntry(ledgerId, LEDGER_EVENT_PUBLISH, audit, addedBlob), stored.PublishEventsVersion)
+	return nil
+}
+
+func (s *MemoryLedgerStore) InvalidateLedgerEvents(audit LedgerAudit, invalidation LedgerInvalidation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.ledgers[invalidation.LedgerID]
+	if !ok {
+		return ErrLedgerNotFound
+	}
+	invalidatedKeys := map[string]bool{}
+	for _, id := range invalidation.MediaEventIDs {
+		invalidatedKeys[ledgerEventKey(LEDGER_EVENT_MEDIA, id)] = true
+	}
+	for _, id := range invalidation.PublishEventIDs {
+		invalidatedKeys[ledgerEventKey(LEDGER_EVENT_PUBLISH, id)] = true
+	}
+	stored, err := withoutInvalidatedEvents(stored, invalidatedKeys)
+	if err != nil {
+		return err
+	}
+	// Like the conditional puts of DynamoLedgerStore, a compensating event still in the ledger fails the invalidation.
+	stored, added, err := withJoinedPublishEvents(stored, invalidation.CompensatingEvents)
+	if err != nil {
+		return err
+	}
+	if len(added) != len(invalidation.CompensatingEvents) {
+		return ErrLedgerAppendConflict
+	}
+	invalidationBlob, err := tables.EncodeEventBlob(invalidation)
+	if err != nil {
+		return err
+	}
+	stored.LedgerStatus = tables.NEW_LEDGER
+	stored.HeartbeatCount = 0
+	stored.RedrivenAtEpochMilli = time.Now().UnixMilli()
+	stored.RedriveVersion++
+	s.recordAppend(&stored, newLedgerHistoryEntry(invalidation.LedgerID, LEDGER_EVENT_INVALIDATED, audit, invalidationBlob), stored.RedriveVersion)
+	return nil
+}
+
+// Callers hold the lock.
+func (s *MemoryLedgerStore) recordAppend(stored *tables.Ledger, history LedgerHistoryEntry, eventsVersion int64) {
+	stored.HistoryVersion++
+	history.HistoryVersion = stored.HistoryVersion
+	history.EventsVersion = eventsVersion
+	s.ledgers[stored.LedgerID] = *stored
+	s.history[stored.LedgerID] = append(s.history[stored.LedgerID], history)
+}
+
+func (s *MemoryLedgerStore) SetLedgerStatus(ledgerEntry tables.Ledger, status tables.LedgerStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := s.ledgers[ledgerEntry.LedgerID]
+	// Like an UpdateItem, a missing ledger is created with only the key and status.
+	stored.LedgerID = ledgerEntry.LedgerID
+	stored.LedgerStatus = status
+	s.ledgers[ledgerEntry.LedgerID] = stored
+	return nil
+}
+
+func (s *MemoryLedgerStore) IncrementHeartbeat(ledgerId string, curHeartbeatCount int64) error {
+	if curHeartbeatCount >= maxHeartbeat {
+		log.Printf("correlationID: %s max heartbeat exceeded retuning nil noop", ledgerId)
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := s.ledgers[ledgerId]
+	stored.LedgerID = ledgerId
+	stored.HeartbeatCount++
+	s.ledgers[ledgerId] = stored
+	return nil
+}
+
+func (s *MemoryLedgerStore) AddGeneratedTitle(ledgerId string, title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.ledgers[ledgerId]
+	if !ok || slices.Contains(stored.GeneratedTitles, title) {
+		return nil
+	}
+	stored.GeneratedTitles = append(append([]string{}, stored.GeneratedTitles...), title)
+	s.ledgers[ledgerId] = stored
+	return nil
+}
+
+func (s *MemoryLedgerStore) QuarantineLedger(ledgerId string, reason string, workflowErrors []tables.WorkflowError) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.ledgers[ledgerId]
+	if !ok || stored.LedgerStatus != tables.NEW_LEDGER {
+		return false, nil
+	}
+	stored.LedgerStatus = tables.QUARANTINED_LEDGER
+	stored.QuarantineReason = reason
+	stored.QuarantinedAtEpochMilli = time.Now().UnixMilli()
+	stored.LastWorkflowErrors = append([]tables.WorkflowError{}, workflowErrors...)
+	s.ledgers[ledgerId] = stored
+	return true, nil
+}
@@ -0,0 +1,218 @@
+package dal
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAudit = LedgerAudit{WorkflowName: "TestWorkflow", ProcessID: "process-1"}
+
+func TestMemoryLedgerStoreCreateAndStatus(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	assert.NoError(t, store.CreateLedger(tables.Ledger{LedgerID: "l1", LedgerStatus: tables.FINISHED_LEDGER}))
+
+	ledger, err := store.GetLedger("l1")
+	assert.NoError(t, err)
+	assert.Equal(t, tables.NEW_LEDGER, ledger.LedgerStatus)
+	assert.NotZero(t, ledger.LedgerCreatedAtEpochMilli)
+
+	assert.NoError(t, store.SetLedgerStatus(ledger, tables.FINISHED_LEDGER))
+	assert.NoError(t, store.IncrementHeartbeat("l1", ledger.HeartbeatCount))
+	ledger, _ = store.GetLedger("l1")
+	assert.Equal(t, tables.FINISHED_LEDGER, ledger.LedgerStatus)
+	assert.Equal(t, int64(1), ledger.HeartbeatCount)
+
+	assert.NoError(t, store.IncrementHeartbeat("l1", maxHeartbeat))
+	ledger, _ = store.GetLedger("l1")
+	assert.Equal(t, int64(1), ledger.HeartbeatCount)
+
+	assert.NoError(t, store.DeleteLedger("l1"))
+	ledger, err = store.GetLedger("l1")
+	assert.NoError(t, err)
+	assert.Empty(t, ledger.LedgerID)
+}
+
+func TestMemoryLedgerStoreConcurrentAppends(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	store.CreateLedger(tables.Ledger{LedgerID: "l1"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			event := tables.MediaEvent{EventID: fmt.Sprintf("e%d", i)}
+			assert.NoError(t, store.AppendLedgerMediaEvents(testAudit, "l1", []tables.MediaEvent{event}))
+			assert.NoError(t, store.AppendLedgerMediaEvents(testAudit, "l1", []tables.MediaEvent{event}))
+		}(i)
+	}
+	wg.Wait()
+
+	ledger, _ := store.GetLedger("l1")
+	mediaEvents, err := ledger.GetExistingMediaEvents()
+	assert.NoError(t, err)
+	assert.Len(t, mediaEvents, 5)
+	assert.Equal(t, int64(5), ledger.MediaEventsVersion, "duplicate appends don't bump the version")
+
+	history, _ := store.GetLedgerHistory("l1")
+	assert.Len(t, history, 5)
+	for i, h := range history {
+		assert.Equal(t, int64(i+1), h.HistoryVersion)
+		added, err := h.GetAddedMediaEvents()
+		assert.NoError(t, err)
+		assert.Len(t, added, 1)
+	}
+}
+
+func TestMemoryLedgerStoreMissingLedger(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	err := store.AppendLedgerPublishEvents(testAudit, "missing", []tables.PublishEvent{{LedgerID: "missing"}})
+	assert.ErrorIs(t, err, ErrLedgerNotFound)
+}
+
+func TestMemoryLedgerStoreHistory(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	store.CreateLedger(tables.Ledger{LedgerID: "l1"})
+	assigned := tables.PublishEvent{LedgerID: "l1", DistributionChannel: "Medium", PublishStatus: tables.ASSIGNED}
+	store.AppendLedgerMediaEvents(testAudit, "l1", []tables.MediaEvent{{LedgerID: "l1", EventID: "m1"}})
+	store.AppendLedgerPublishEvents(LedgerAudit{WorkflowName: "AssignmentWorkflow", ProcessID: "process-2"}, "l1", []tables.PublishEvent{assigned})
+	store.AppendLedgerPublishEvents(testAudit, "l1", []tables.PublishEvent{assigned})
+
+	history, err := store.GetLedgerHistory("l1")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2, "appends that add nothing aren't recorded")
+	assert.Equal(t, LEDGER_EVENT_PUBLISH, history[1].EventType)
+	assert.Equal(t, int64(2), history[1].HistoryVersion)
+	assert.Equal(t, int64(1), history[1].EventsVersion)
+	assert.Equal(t, "AssignmentWorkflow", history[1].WorkflowName)
+	assert.Equal(t, "process-2", history[1].ProcessID)
+	added, _ := history[1].GetAddedPublishEvents()
+	assert.Equal(t, []tables.PublishEvent{assigned}, added)
+	media, _ := history[1].GetAddedMediaEvents()
+	assert.Empty(t, media)
+
+	ledger, _ := store.GetLedger("l1")
+	assert.Equal(t, int64(2), ledger.HistoryVersion)
+}
+
+func TestMemoryLedgerStoreGeneratedTitles(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	assert.NoError(t, store.AddGeneratedTitle("missing", "Title"))
+	store.CreateLedger(tables.Ledger{LedgerID: "l1"})
+	store.AddGeneratedTitle("l1", "First")
+	store.AddGeneratedTitle("l1", "Second")
+	store.AddGeneratedTitle("l1", "First")
+
+	ledger, _ := store.GetLedger("l1")
+	assert.Equal(t, []string{"First", "Second"}, ledger.GeneratedTitles)
+	missing, _ := store.GetLedger("missing")
+	assert.Empty(t, missing.LedgerID)
+}
+
+func TestMemoryLedgerStoreInvalidateLedgerEvents(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	assert.ErrorIs(t, store.InvalidateLedgerEvents(testAudit, LedgerInvalidation{LedgerID: "missing"}), ErrLedgerNotFound)
+
+	store.CreateLedger(tables.Ledger{LedgerID: "l1"})
+	assigned := tables.PublishEvent{LedgerID: "l1", DistributionChannel: "Medium", AccountID: "a", PublisherProfileID: "p", PublishStatus: tables.ASSIGNED}
+	store.AppendLedgerMediaEvents(testAudit, "l1", []tables.MediaEvent{{LedgerID: "l1", EventID: "root"}, {LedgerID: "l1", EventID: "child"}})
+	store.AppendLedgerPublishEvents(testAudit, "l1", []tables.PublishEvent{assigned})
+	store.SetLedgerStatus(tables.Ledger{LedgerID: "l1"}, tables.FINISHED_LEDGER)
+	store.IncrementHeartbeat("l1", 0)
+
+	invalidation := LedgerInvalidation{LedgerID: "l1", Stage: "Enrichment", MediaEventIDs: []string{"child"}, PublishEventIDs: []string{assigned.GetEventID()}}
+	assert.NoError(t, store.InvalidateLedgerEvents(LedgerAudit{WorkflowName: "Redrive", ProcessID: "redrive-1"}, invalidation))
+
+	ledger, _ := store.GetLedger("l1")
+	assert.Equal(t, tables.NEW_LEDGER, ledger.LedgerStatus)
+	assert.Equal(t, int64(0), ledger.HeartbeatCount)
+	assert.Equal(t, int64(1), ledger.RedriveVersion)
+	mediaEvents, _ := ledger.GetExistingMediaEvents()
+	assert.Equal(t, []tables.MediaEvent{{LedgerID: "l1", EventID: "root"}}, mediaEvents)
+	publishEvents, _ := ledger.GetExistingPublishEvents()
+	assert.Empty(t, publishEvents)
+
+	history, _ := store.GetLedgerHistory("l1")
+	assert.Len(t, history, 3)
+	recorded, ok, err := history[2].GetInvalidation()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, invalidation, recorded)
+	assert.Equal(t, "Redrive", history[2].WorkflowName)
+
+	// Invalidated events may be appended again.
+	store.AppendLedgerPublishEvents(testAudit, "l1", []tables.PublishEvent{assigned})
+	ledger, _ = store.GetLedger("l1")
+	publishEvents, _ = ledger.GetExistingPublishEvents()
+	assert.Equal(t, []tables.PublishEvent{assigned}, publishEvents)
+}
+
+func TestMemoryLedgerStoreInvalidateWithCompensatingEvents(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	store.CreateLedger(tables.Ledger{LedgerID: "l1"})
+	assigned := tables.PublishEvent{LedgerID: "l1", DistributionChannel: "Medium", AccountID: "a", PublisherProfileID: "p",
+		PublishStatus: tables.ASSIGNED, ProcessOwner: "process-1"}
+	rendering := assigned
+	rendering.PublishStatus = tables.RENDERING
+	store.AppendLedgerPublishEvents(testAudit, "l1", []tables.PublishEvent{assigned, rendering})
+	store.SetLedgerStatus(tables.Ledger{LedgerID: "l1"}, tables.FINISHED_LEDGER)
+	renewed := assigned
+	renewed.ProcessOwner = "redrive-1"
+
+	// A compensating event still in the ledger fails the append, and with it the reopen.
+	conflicting := LedgerInvalidation{LedgerID: "l1", Stage: "Publish", PublishEventIDs: []string{rendering.GetEventID()},
+		CompensatingEvents: []tables.PublishEvent{renewed}}
+	assert.ErrorIs(t, store.InvalidateLedgerEvents(testAudit, conflicting), ErrLedgerAppendConflict)
+	ledger, _ := store.GetLedger("l1")
+	assert.Equal(t, tables.FINISHED_LEDGER, ledger.LedgerStatus)
+	assert.Equal(t, int64(0), ledger.RedriveVersion)
+	publishEvents, _ := ledger.GetExistingPublishEvents()
+	assert.Equal(t, []tables.PublishEvent{assigned, rendering}, publishEvents)
+	history, _ := store.GetLedgerHistory("l1")
+	assert.Len(t, history, 1)
+
+	invalidation := LedgerInvalidation{LedgerID: "l1", Stage: "FinalRender", PublishEventIDs: []string{assigned.GetEventID(), rendering.GetEventID()},
+		CompensatingEvents: []tables.PublishEvent{renewed}}
+	assert.NoError(t, store.InvalidateLedgerEvents(testAudit, invalidation))
+	ledger, _ = store.GetLedger("l1")
+	assert.Equal(t, tables.NEW_LEDGER, ledger.LedgerStatus)
+	publishEvents, _ = ledger.GetExistingPublishEvents()
+	assert.Equal(t, []tables.PublishEvent{renewed}, publishEvents)
+	history, _ = store.GetLedgerHistory("l1")
+	assert.Len(t, history, 2)
+	added, err := history[1].GetAddedPublishEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []tables.PublishEvent{renewed}, added)
+}
+
+func TestMemoryLedgerStoreQuarantineLedger(t *testing.T) {
+	store := NewMemoryLedgerStore()
+	quarantined, err := store.QuarantineLedger("missing", "reason", nil)
+	assert.NoError(t, err)
+	assert.False(t, quarantined)
+
+	store.CreateLedger(tables.Ledger{LedgerID: "l1"})
+	workflowErrors := []tables.WorkflowError{{WorkflowName: "ScriptWorkflow", Error: "bad script"}}
+	quarantined, err = store.QuarantineLedger("l1", "heartbeat count 24 reached max 24", workflowErrors)
+	assert.NoError(t, err)
+	assert.True(t, quarantined)
+	ledger, _ := store.GetLedger("l1")
+	assert.Equal(t, tables.QUARANTINED_LEDGER, ledger.LedgerStatus)
+	assert.Equal(t, "heartbeat count 24 reached max 24", ledger.QuarantineReason)
+	assert.NotZero(t, ledger.QuarantinedAtEpochMilli)
+	assert.Equal(t, workflowErrors, ledger.LastWorkflowErrors)
+
+	// Only a New ledger is quarantined, once.
+	quarantined, _ = store.QuarantineLedger("l1", "again", nil)
+	assert.False(t, quarantined)
+
+	// A redrive reopens it and restarts its quarantine age.
+	assert.NoError(t, store.InvalidateLedgerEvents(testAudit, LedgerInvalidation{LedgerID: "l1", Stage: "Script"}))
+	ledger, _ = store.GetLedger("l1")
+	assert.Equal(t, tables.NEW_LEDGER, ledger.LedgerStatus)
+	assert.NotZero(t, ledger.RedrivenAtEpochMilli)
+}
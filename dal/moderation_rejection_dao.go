@@ -0,0 +1,53 @@
+package dal
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+)
+
+// Ingestion event rejected by pre-LLM moderation; never saved as a ledger.
+type ModerationRejectionEntry struct {
+	Source               string
+	RejectionKey         string // <rejectedAtEpochMilli>#<contentHash>
+	ContentHash          string
+	Scope                string // Rule set that rejected the event.
+	Reason               string
+	Detail               string
+	RejectedAtEpochMilli int64
+	TTL                  int64 // epoch seconds
+}
+
+func CreateModerationRejection(source string, contentHash string, scope string, reason string, detail string) error {
+	const thirtyDays = 2592000
+	now := time.Now()
+	entry := ModerationRejectionEntry{
+		Source:               source,
+		RejectionKey:         fmt.Sprintf("%013d#%s", now.UnixMilli(), contentHash),
+		ContentHash:          contentHash,
+		Scope:                scope,
+		Reason:               reason,
+		Detail:               detail,
+		RejectedAtEpochMilli: now.UnixMilli(),
+		TTL:                  now.Unix() + thirtyDays,
+	}
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		log.Printf("got error marshalling moderation rejection: %s", err)
+		return err
+	}
+	_, err = svc.PutItem(&dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(dynamo_configuration.TABLE_MODERATION_REJECTIONS),
+	})
+	if err != nil {
+		log.Printf("got error calling PutItem moderation rejection: %s", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package dal
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Ledger that was not admitted on ingestion, waiting to be retried in its admission lane.
+type ParkedEventEntry struct {
+	Lane      string
+	ParkedKey string // <parkedAtEpochMilli>#<ledgerId>; retried oldest first.
+	Source    string
+	Reason    string // Admission reason the event was last turned away for.
+	Attempts  int64
+	Ledger    tables.Ledger
+	TTL       int64 // epoch seconds
+}
+
+func CreateParkedEvent(ledger tables.Ledger, lane string, reason string, ttlSeconds int64) error {
+	entry := ParkedEventEntry{
+		Lane:      lane,
+		ParkedKey: fmt.Sprintf("%013d#%s", time.Now().UnixMilli(), ledger.LedgerID),
+		Source:    ledger.TriggerEventSource,
+		Reason:    reason,
+		Ledger:    ledger,
+		TTL:       time.Now().Unix() + ttlSeconds,
+	}
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		log.Printf("correlationID: %s got error marshalling parked event: %s", ledger.LedgerID, err)
+		return err
+	}
+	_, err = svc.PutItem(&dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(dynamo_configuration.TABLE_PARKED_EVENTS),
+	})
+	if err != nil {
+		log.Printf("correlationID: %s got error calling PutItem parked event: %s", ledger.LedgerID, err)
+		return err
+	}
+	return nil
+}
+
+// Oldest parked events of the lane first.
+func GetParkedEvents(lane string, limit int64) ([]ParkedEventEntry, error) {
+	result, err := svc.Query(&dynamodb.QueryInput{
+		TableName:              aws.String(dynamo_configuration.TABLE_PARKED_EVENTS),
+		KeyConditionExpression: aws.String("Lane = :lane"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":lane": {
+				S: aws.String(lane),
+			},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int64(limit),
+	})
+	entries := []ParkedEventEntry{}
+	if err != nil {
+		log.Printf("got error querying parked events of lane %s: %s", lane, err)
+		return entries, err
+	}
+	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &entries)
+	if err != nil {
+		log.Printf("error unmarshalling parked events: %s", err)
+	}
+	return entries, err
+}
+
+func RecordParkedEventAttempt(entry ParkedEventEntry, reason string) error {
+	_, err := svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_PARKED_EVENTS),
+		Key:       parkedEventKey(entry),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":one": {
+				N: aws.String(strconv.FormatInt(1, 10)),
+			},
+			":reason": {
+				S: aws.String(reason),
+			},
+		},
+		UpdateExpression: aws.String("ADD Attempts :one SET Reason = :reason"),
+	})
+	if err != nil {
+		log.Printf("correlationID: %s got error recording parked event attempt: %s", entry.Ledger.LedgerID, err)
+	}
+	return err
+}
+
+func DeleteParkedEvent(entry ParkedEventEntry) error {
+	_, err := svc.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_PARKED_EVENTS),
+		Key:       parkedEventKey(entry),
+	})
+	if err != nil {
+		log.Printf("correlationID: %s got error deleting parked event: %s", entry.Ledger.LedgerID, err)
+	}
+	return err
+}
+
+func parkedEventKey(entry ParkedEventEntry) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"Lane": {
+			S: aws.String(entry.Lane),
+		},
+		"ParkedKey": {
+			S: aws.String(entry.ParkedKey),
+		},
+	}
+}
@@ -0,0 +1,160 @@
+package dal
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+
+	"log"
+)
+
+// Shared buffer of reaction content urls waiting to be batched into a single ledger.
+// Any instance may append; the instance that wins the versioned claim flushes the batch.
+type ReactionBufferEntry struct {
+	Source                    string
+	TargetLanguage            string // Language of the first buffered request; applies to the whole batch.
+	ContentUrls               []string
+	BufferVersion             int64 // Incremented on every append and claim.
+	PayloadBytes              int64 // Total size of the buffered request payloads.
+	FirstBufferedAtEpochMilli int64
+	TTL                       int64 // epoch seconds
+}
+
+func AppendReactionBuffer(source string, targetLanguage string, contentUrl string, payloadBytes int64) (ReactionBufferEntry, error) {
+	const twoWeeksTTL = 1210000
+	ttl := time.Now().Unix() + twoWeeksTTL
+	input := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"Source": {
+				S: aws.String(source),
+			},
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":u": {
+				L: []*dynamodb.AttributeValue{{S: aws.String(contentUrl)}},
+			},
+			":url": {
+				S: aws.String(contentUrl),
+			},
+			":empty": {
+				L: []*dynamodb.AttributeValue{},
+			},
+			":l": {
+				S: aws.String(targetLanguage),
+			},
+			":n": {
+				N: aws.String(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+			},
+			":t": {
+				N: aws.String(strconv.FormatInt(ttl, 10)),
+			},
+			":one": {
+				N: aws.String(strconv.FormatInt(1, 10)),
+			},
+			":size": {
+				N: aws.String(strconv.FormatInt(payloadBytes, 10)),
+			},
+		},
+		ExpressionAttributeNames: map[string]*string{
+			"#ttlName": aws.String("TTL"),
+		},
+		TableName:    aws.String(dynamo_configuration.TABLE_REACTION_BUFFERS),
+		ReturnValues: aws.String("ALL_NEW"),
+		UpdateExpression: aws.String(fmt.Sprintf(`SET %s = list_append(if_not_exists(%s, :empty), :u), %s = if_not_exists(%s, :l),
+		%s = if_not_exists(%s, :n), #ttlName = :t ADD %s :one, %s :size`,
+			"ContentUrls", "ContentUrls", "TargetLanguage", "TargetLanguage",
+			"FirstBufferedAtEpochMilli", "FirstBufferedAtEpochMilli", "BufferVersion", "PayloadBytes")),
+		// Same url resubmitted while still buffered is a no-op.
+		ConditionExpression: aws.String("attribute_not_exists(ContentUrls) OR NOT contains(ContentUrls, :url)"),
+	}
+
+	response, err := svc.UpdateItem(input)
+	if err != nil && hasVersionConflict(err) {
+		log.Printf("content url already buffered for source %s: %s", source, contentUrl)
+		return GetReactionBuffer(source)
+	}
+	if err != nil {
+		log.Printf("error calling UpdateItem to append reaction buffer: %s", err)
+		return ReactionBufferEntry{}, err
+	}
+
+	resultItem := ReactionBufferEntry{}
+	err = dynamodbattribute.UnmarshalMap(response.Attributes, &resultItem)
+	if err != nil {
+		log.Printf("error unmarshalling reaction buffer entry: %s", err)
+		return resultItem, err
+	}
+	return resultItem, err
+}
+
+func GetReactionBuffer(source string) (ReactionBufferEntry, error) {
+	result, err := svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_REACTION_BUFFERS),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Source": {
+				S: aws.String(source),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	resultItem := ReactionBufferEntry{}
+	if err != nil {
+		log.Printf("got error calling GetItem reaction buffer entry: %s", err)
+		return resultItem, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &resultItem)
+	if err != nil {
+		log.Printf("error unmarshalling reaction buffer entry: %s", err)
+		return resultItem, err
+	}
+	return resultItem, err
+}
+
+// Empties the buffer if nobody appended or claimed since expectedVersion was observed.
+// Returns the claimed batch and true only for the single winning caller.
+func ClaimReactionBuffer(source string, expectedVersion int64) (ReactionBufferEntry, bool, error) {
+	input := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"Source": {
+				S: aws.String(source),
+			},
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":ov": {
+				N: aws.String(strconv.FormatInt(expectedVersion, 10)),
+			},
+			":one": {
+				N: aws.String(strconv.FormatInt(1, 10)),
+			},
+		},
+		TableName:    aws.String(dynamo_configuration.TABLE_REACTION_BUFFERS),
+		ReturnValues: aws.String("ALL_OLD"),
+		UpdateExpression: aws.String(fmt.Sprintf("REMOVE %s, %s, %s, %s ADD %s :one",
+			"ContentUrls", "TargetLanguage", "FirstBufferedAtEpochMilli", "PayloadBytes", "BufferVersion")),
+		ConditionExpression: aws.String(fmt.Sprintf("%s = :ov", "BufferVersion")),
+	}
+
+	response, err := svc.UpdateItem(input)
+	if err != nil && hasVersionConflict(err) {
+		return ReactionBufferEntry{}, false, nil
+	}
+	if err != nil {
+		log.Printf("error calling UpdateItem to claim reaction buffer: %s", err)
+		return ReactionBufferEntry{}, false, err
+	}
+
+	resultItem := ReactionBufferEntry{}
+	err = dynamodbattribute.UnmarshalMap(response.Attributes, &resultItem)
+	if err != nil {
+		log.Printf("error unmarshalling claimed reaction buffer entry: %s", err)
+		return resultItem, false, err
+	}
+	return resultItem, true, err
+}
@@ -0,0 +1,149 @@
+package dal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
+	"github.com/google/uuid"
+
+	"log"
+)
+
+const (
+	CREDENTIAL_ACTIVE  = "Active"
+	CREDENTIAL_REVOKED = "Revoked"
+)
+
+// Shared secret a third-party producer uses to sign requests for a single source.
+// A source may hold several active keys so producers can rotate without downtime.
+type SourceCredentialEntry struct {
+	Source              string
+	KeyID               string
+	Secret              string // hex; HMAC-SHA256 key.
+	Status              string
+	CreatedAtEpochMilli int64
+	RevokedAtEpochMilli int64
+}
+
+func CreateSourceCredential(source string) (SourceCredentialEntry, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		log.Printf("error generating source credential secret: %s", err)
+		return SourceCredentialEntry{}, err
+	}
+	entry := SourceCredentialEntry{
+		Source:              source,
+		KeyID:               uuid.New().String(),
+		Secret:              hex.EncodeToString(secret),
+		Status:              CREDENTIAL_ACTIVE,
+		CreatedAtEpochMilli: time.Now().UnixMilli(),
+	}
+
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		log.Printf("got error marshalling source credential entry: %s", err)
+		return SourceCredentialEntry{}, err
+	}
+	_, err = svc.PutItem(&dynamodb.PutItemInput{
+		Item:                av,
+		TableName:           aws.String(dynamo_configuration.TABLE_SOURCE_CREDENTIALS),
+		ConditionExpression: aws.String("attribute_not_exists(KeyID)"),
+	})
+	if err != nil {
+		log.Printf("got error calling PutItem source credential entry: %s", err)
+		return SourceCredentialEntry{}, err
+	}
+	return entry, nil
+}
+
+func GetSourceCredential(source string, keyID string) (SourceCredentialEntry, error) {
+	result, err := svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_SOURCE_CREDENTIALS),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Source": {
+				S: aws.String(source),
+			},
+			"KeyID": {
+				S: aws.String(keyID),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	resultItem := SourceCredentialEntry{}
+	if err != nil {
+		log.Printf("got error calling GetItem source credential entry: %s", err)
+		return resultItem, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &resultItem)
+	if err != nil {
+		log.Printf("error unmarshalling source credential entry: %s", err)
+		return resultItem, err
+	}
+	return resultItem, err
+}
+
+// Revocation is immediate; the entry is kept for auditing.
+func RevokeSourceCredential(source string, keyID string) error {
+	_, err := svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_SOURCE_CREDENTIALS),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Source": {
+				S: aws.String(source),
+			},
+			"KeyID": {
+				S: aws.String(keyID),
+			},
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":s": {
+				S: aws.String(CREDENTIAL_REVOKED),
+			},
+			":t": {
+				N: aws.String(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+			},
+		},
+		UpdateExpression: aws.String("SET #status = :s, RevokedAtEpochMilli = :t"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ConditionExpression: aws.String("attribute_exists(KeyID)"),
+	})
+	if err != nil {
+		log.Printf("got error calling UpdateItem to revoke source credential %s: %s", keyID, err)
+		return err
+	}
+	return nil
+}
+
+// Records a request signature; false if it was already seen within its TTL, i.e. a replay.
+func RecordSignatureNonce(signature string, ttlSeconds int64) (bool, error) {
+	_, err := svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(dynamo_configuration.TABLE_SIGNATURE_NONCES),
+		Item: map[string]*dynamodb.AttributeValue{
+			"Signature": {
+				S: aws.String(signature),
+			},
+			"TTL": {
+				N: aws.String(strconv.FormatInt(time.Now().Unix()+ttlSeconds, 10)),
+			},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(Signature)"),
+	})
+	if err != nil && hasVersionConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("got error calling PutItem signature nonce: %s", err)
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,181 @@
+package v1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Event blobs are JSON, gzipped once at least minCompressBytes long, and stored as binary. Older items hold a
+// string: gzipped JSON base64 encoded behind this marker, or legacy plain JSON.
+const EVENT_BLOB_MARKER = "gz1:"
+const minCompressBytes = 1024
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+type EventBlob []byte
+
+func (b EventBlob) MarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	if len(b) == 0 {
+		av.NULL = aws.Bool(true)
+		return nil
+	}
+	av.B = b
+	return nil
+}
+
+// Reads the binary blob, or the string of an older item.
+func (b *EventBlob) UnmarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	switch {
+	case av.B != nil:
+		*b = av.B
+	case av.S != nil:
+		*b = EventBlob(*av.S)
+	default:
+		*b = nil
+	}
+	return nil
+}
+
+// Prompt text of at least this length is stored once per blob and referenced by hash.
+const PROMPT_REF_PREFIX = "prompt-ref:sha256:"
+const minPromptRefChars = 128
+
+type mediaEventsBlob struct {
+	Prompts map[string]string `json:"prompts,omitempty"` // hash reference -> prompt text
+	Events  []MediaEvent      `json:"events"`
+}
+
+func EncodeMediaEvents(mediaEvents []MediaEvent) (EventBlob, error) {
+	prompts := map[string]string{}
+	blob := mediaEventsBlob{Events: DedupePrompts(mediaEvents, prompts), Prompts: prompts}
+	return EncodeEventBlob(blob)
+}
+
+func DecodeMediaEvents(encoded EventBlob) ([]MediaEvent, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(encoded), []byte("[")) {
+		var legacy []MediaEvent
+		err := json.Unmarshal(encoded, &legacy)
+		return legacy, err
+	}
+	var blob mediaEventsBlob
+	err := DecodeEventBlob(encoded, &blob)
+	if err != nil {
+		return blob.Events, err
+	}
+	return ResolvePrompts(blob.Events, blob.Prompts)
+}
+
+func EncodePublishEvents(publishEvents []PublishEvent) (EventBlob, error) {
+	return EncodeEventBlob(publishEvents)
+}
+
+func DecodePublishEvents(encoded EventBlob) ([]PublishEvent, error) {
+	var publishEvents []PublishEvent
+	err := DecodeEventBlob(encoded, &publishEvents)
+	return publishEvents, err
+}
+
+// Replaces long prompt text with a hash reference, collecting the text into prompts.
+func DedupePrompts(mediaEvents []MediaEvent, prompts map[string]string) []MediaEvent {
+	result := []MediaEvent{}
+	for _, m := range mediaEvents {
+		m.PromptInstruction = promptToRef(m.PromptInstruction, prompts)
+		m.SystemPromptInstruction = promptToRef(m.SystemPromptInstruction, prompts)
+		result = append(result, m)
+	}
+	return result
+}
+
+func ResolvePrompts(mediaEvents []MediaEvent, prompts map[string]string) ([]MediaEvent, error) {
+	result := []MediaEvent{}
+	for _, m := range mediaEvents {
+		var err error
+		m.PromptInstruction, err = refToPrompt(m.PromptInstruction, prompts)
+		if err != nil {
+			return result, err
+		}
+		m.SystemPromptInstruction, err = refToPrompt(m.SystemPromptInstruction, prompts)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func PromptRef(text string) string {
+	hash := sha256.Sum256([]byte(text))
+	return PROMPT_REF_PREFIX + hex.EncodeToString(hash[:])
+}
+
+func promptToRef(text string, prompts map[string]string) string {
+	if len(text) < minPromptRefChars {
+		return text
+	}
+	ref := PromptRef(text)
+	prompts[ref] = text
+	return ref
+}
+
+func refToPrompt(text string, prompts map[string]string) (string, error) {
+	if !strings.HasPrefix(text, PROMPT_REF_PREFIX) {
+		return text, nil
+	}
+	prompt, ok := prompts[text]
+	if !ok {
+		return text, fmt.Errorf("missing prompt text for reference: %s", text)
+	}
+	return prompt, nil
+}
+
+func EncodeEventBlob(v interface{}) (EventBlob, error) {
+	raw, err := json.Marshal(v)
+	if err != nil || len(raw) < minCompressBytes {
+		return raw, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(raw)
+	if err != nil {
+		return nil, err
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decodes a blob, gzipped or not, or an older item's marked or plain JSON string, into v.
+func DecodeEventBlob(encoded EventBlob, v interface{}) error {
+	compressed := []byte(encoded)
+	if bytes.HasPrefix(encoded, []byte(EVENT_BLOB_MARKER)) {
+		var err error
+		compressed, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(string(encoded), EVENT_BLOB_MARKER))
+		if err != nil {
+			return err
+		}
+	} else if !bytes.HasPrefix(encoded, gzipMagic) {
+		return json.Unmarshal(encoded, v)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
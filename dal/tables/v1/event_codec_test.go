@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMediaEvents() []MediaEvent {
+	script := strings.Repeat("Narrate the forum post about the wedding. ", 20)
+	parent := MediaEvent{LedgerID: "l1", PromptInstruction: script, SystemPromptInstruction: script, MediaType: MEDIA_TEXT, EventID: "parent"}
+	child := parent.ToChildMediaEntry(script, "short system prompt", MEDIA_VOCAL)
+	return []MediaEvent{parent, child}
+}
+
+func TestEncodeMediaEventsRoundTrip(t *testing.T) {
+	mediaEvents := testMediaEvents()
+	encoded, err := EncodeMediaEvents(mediaEvents)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(encoded, gzipMagic))
+
+	legacy, _ := json.Marshal(mediaEvents)
+	assert.Less(t, len(encoded), len(legacy)/2)
+
+	decoded, err := DecodeMediaEvents(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, mediaEvents, decoded)
+}
+
+func TestDecodeLegacyLedgerEvents(t *testing.T) {
+	mediaEvents := testMediaEvents()
+	legacy, _ := json.Marshal(mediaEvents)
+	ledger := Ledger{LedgerID: "l1", MediaEvents: legacy, PublishEvents: EventBlob(`[{"LedgerID":"l1","PublishStatus":"Complete"}]`)}
+
+	decoded, err := ledger.GetExistingMediaEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, mediaEvents, decoded)
+	publishEvents, err := ledger.GetExistingPublishEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, COMPLETE, publishEvents[0].PublishStatus)
+}
+
+func TestEncodeEventBlob(t *testing.T) {
+	small, err := EncodeEventBlob(PublishEvent{LedgerID: "l1", PublishStatus: COMPLETE})
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(small), "small blobs are stored uncompressed")
+
+	var av dynamodb.AttributeValue
+	assert.NoError(t, small.MarshalDynamoDBAttributeValue(&av))
+	assert.Equal(t, []byte(small), av.B)
+
+	older, err := json.Marshal(PublishEvent{LedgerID: "l1", PublishStatus: COMPLETE})
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(older)
+	zw.Close()
+	var blob EventBlob
+	assert.NoError(t, blob.UnmarshalDynamoDBAttributeValue(&dynamodb.AttributeValue{
+		S: aws.String(EVENT_BLOB_MARKER + base64.StdEncoding.EncodeToString(buf.Bytes())),
+	}))
+	var decoded PublishEvent
+	assert.NoError(t, DecodeEventBlob(blob, &decoded))
+	assert.Equal(t, COMPLETE, decoded.PublishStatus)
+}
+
+func TestDedupePrompts(t *testing.T) {
+	prompts := map[string]string{}
+	deduped := DedupePrompts(testMediaEvents(), prompts)
+	assert.Len(t, prompts, 1)
+	assert.True(t, strings.HasPrefix(deduped[1].PromptInstruction, PROMPT_REF_PREFIX))
+	assert.Equal(t, "short system prompt", deduped[1].SystemPromptInstruction)
+
+	_, err := ResolvePrompts(deduped, map[string]string{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Canonical BCP-47 tag for a client or manifest provided language, e.g. "EN" -> "en", "pt_br" -> "pt-BR".
+func NormalizeLanguageCode(code string) (string, error) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), "_", "-")
+	if code == "" {
+		return "", errors.New("empty language code")
+	}
+	tag, err := language.Parse(code)
+	if err != nil {
+		return "", err
+	}
+	if tag == language.Und {
+		return "", errors.New("undetermined language code: " + code)
+	}
+	return tag.String(), nil
+}
+
+// Spellings a stored language may use; publisher profiles created before normalization use uppercase ISO 639 codes.
+func LanguageCodeVariants(code string) []string {
+	result := []string{code}
+	canonical, err := NormalizeLanguageCode(code)
+	if err != nil {
+		return result
+	}
+	seen := map[string]bool{code: true}
+	for _, v := range []string{canonical, strings.ToUpper(canonical)} {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLanguageCode(t *testing.T) {
+	cases := map[string]string{
+		"EN":      "en",
+		" en ":    "en",
+		"pt_br":   "pt-BR",
+		"zh-hant": "zh-Hant",
+		"ES":      "es",
+	}
+	for input, expected := range cases {
+		actual, err := NormalizeLanguageCode(input)
+		assert.Nil(t, err, input)
+		assert.Equal(t, expected, actual, input)
+	}
+
+	for _, invalid := range []string{"", "und", "not a language"} {
+		_, err := NormalizeLanguageCode(invalid)
+		assert.NotNil(t, err, "expected error for: "+invalid)
+	}
+}
+
+func TestLanguageCodeVariants(t *testing.T) {
+	assert.Equal(t, []string{"en", "EN"}, LanguageCodeVariants("en"))
+	assert.Equal(t, []string{"EN", "en"}, LanguageCodeVariants("EN"))
+	assert.Equal(t, []string{"pt-BR", "PT-BR"}, LanguageCodeVariants("pt-BR"))
+}
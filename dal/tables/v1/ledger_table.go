@@ -16,8 +16,22 @@ type LedgerStatus string
 const (
 	NEW_LEDGER      LedgerStatus = "New"
 	FINISHED_LEDGER LedgerStatus = "Finished" // Terminal for all cases: expired or success.
+	// Never converged within the quarantine policy; workflows stop until a redrive reopens the ledger.
+	QUARANTINED_LEDGER LedgerStatus = "Quarantined"
 )
 
+var LEDGER_STATUSES = []LedgerStatus{NEW_LEDGER, FINISHED_LEDGER, QUARANTINED_LEDGER}
+
+// Case-insensitive match of a known LedgerStatus.
+func ParseLedgerStatus(status string) (LedgerStatus, error) {
+	for _, s := range LEDGER_STATUSES {
+		if strings.EqualFold(string(s), strings.TrimSpace(status)) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ledger status: %s", status)
+}
+
 type Ledger struct {
 	// Required
 	LedgerID                  string       // Also system correlation ID.
@@ -27,29 +41,72 @@ type Ledger struct {
 	// Optional
 	TriggerEventPayload        string // article text, ...
 	TriggerEventSource         string
-	TriggerEventMediaUrls      string // CSV Images, videos, ... [url1, url2,...]
-	TriggerEventWebsiteUrls    string // CSV product pages, news article sources crawled. [url1, url2,...]
-	TriggerEventTargetLanguage string // EN, CN, ... Specifies the overall downstream language as set by Drivers.
-	TriggerEventContentHash    string // for deduping raw events.
-	MediaEvents                string // Media generation: audio, video, ...
-	PublishEvents              string // Publish to distribution channel: YouTube, Instagram, ...
-	MediaEventsVersion         int64
+	TriggerEventMediaUrls      string    // CSV Images, videos, ... [url1, url2,...]
+	TriggerEventWebsiteUrls    string    // CSV product pages, news article sources crawled. [url1, url2,...]
+	TriggerEventTargetLanguage string    // EN, CN, ... Specifies the overall downstream language as set by Drivers.
+	TriggerEventContentHash    string    // for deduping raw events.
+	TriggerEventRedactions     string    // JSON redaction report of personal information stripped from the payload.
+	MediaEvents                EventBlob // Media generation: audio, video, ...; encoded blob (see event_codec.go) of older ledger items; read with GetExistingMediaEvents.
+	PublishEvents              EventBlob // Publish to distribution channel: YouTube, Instagram, ...; read with GetExistingPublishEvents.
+	MediaEventsVersion         int64     // Bumped per append so the ledger stream fires.
 	PublishEventsVersion       int64
+	HistoryVersion             int64 // Bumped with either events version; the latest LedgerHistory record.
+	RedriveVersion             int64 // Bumped per redrive, which invalidates events so the workflows re-execute them.
+	RedrivenAtEpochMilli       int64 `dynamodbav:",omitempty"` // Latest redrive; restarts the quarantine age.
 	HeartbeatCount             int64
-	TTL                        int64 // epoch seconds
+	AdmissionReserved          bool            `dynamodbav:",omitempty"` // Took in-flight slots on admission; see dal.ReleaseLedgerAdmission.
+	AdmissionReleased          bool            `dynamodbav:",omitempty"` // Freed them on completion or quarantine; cleared by dal.ReadmitLedger.
+	GeneratedTitles            []string        `dynamodbav:",omitempty"` // Script titles set on enrichment; searchable with the trigger payload.
+	QuarantineReason           string          `dynamodbav:",omitempty"` // Policy limit of the latest quarantine.
+	QuarantinedAtEpochMilli    int64           `dynamodbav:",omitempty"`
+	LastWorkflowErrors         []WorkflowError `dynamodbav:",omitempty"` // Failed workflows of the run that quarantined the ledger.
+	ArchiveObjectKey           string          `dynamodbav:",omitempty"` // S3 key of the latest archive holding the ledger.
+	ArchivedHistoryVersion     int64           // HistoryVersion of that archive; a later append, e.g. a redrive, is archived again.
+	TTL                        int64           // epoch seconds
+
+	// Decoded events, set when hydrated so reads skip the blobs; nil when unset. Not stored.
+	decodedMediaEvents   []MediaEvent
+	decodedPublishEvents []PublishEvent
+}
+
+type WorkflowError struct {
+	WorkflowName string
+	Error        string
 }
 
 type Event interface {
 	GetEventID() string
 }
 
+// Replaces the events with decoded ones, clearing the blob; a ledger item written afterwards holds no events.
+func (ledgerItem *Ledger) SetMediaEvents(mediaEvents []MediaEvent) {
+	ledgerItem.MediaEvents = nil
+	ledgerItem.decodedMediaEvents = append([]MediaEvent{}, mediaEvents...)
+}
+
+func (ledgerItem *Ledger) SetPublishEvents(publishEvents []PublishEvent) {
+	ledgerItem.PublishEvents = nil
+	ledgerItem.decodedPublishEvents = append([]PublishEvent{}, publishEvents...)
+}
+
+// Drops the events, blobs and decoded, e.g. before storing a ledger whose events are kept elsewhere.
+func (ledgerItem *Ledger) ClearEvents() {
+	ledgerItem.MediaEvents = nil
+	ledgerItem.PublishEvents = nil
+	ledgerItem.decodedMediaEvents = nil
+	ledgerItem.decodedPublishEvents = nil
+}
+
 func (ledgerItem *Ledger) GetExistingMediaEvents() ([]MediaEvent, error) {
+	if ledgerItem.decodedMediaEvents != nil {
+		return append([]MediaEvent{}, ledgerItem.decodedMediaEvents...), nil
+	}
 	var existingMediaEvents []MediaEvent
-	if ledgerItem.MediaEvents == "" {
+	if len(ledgerItem.MediaEvents) == 0 {
 		return existingMediaEvents, nil
 	}
 
-	err := json.Unmarshal([]byte(ledgerItem.MediaEvents), &existingMediaEvents)
+	existingMediaEvents, err := DecodeMediaEvents(ledgerItem.MediaEvents)
 	if err != nil {
 		log.Printf("error unmarshalling mediaEvents: %s", err)
 		return existingMediaEvents, err
@@ -58,12 +115,15 @@ func (ledgerItem *Ledger) GetExistingMediaEvents() ([]MediaEvent, error) {
 }
 
 func (ledgerItem *Ledger) GetExistingPublishEvents() ([]PublishEvent, error) {
+	if ledgerItem.decodedPublishEvents != nil {
+		return append([]PublishEvent{}, ledgerItem.decodedPublishEvents...), nil
+	}
 	var existingPublishEvents []PublishEvent
-	if ledgerItem.PublishEvents == "" {
+	if len(ledgerItem.PublishEvents) == 0 {
 		return existingPublishEvents, nil
 	}
 
-	err := json.Unmarshal([]byte(ledgerItem.PublishEvents), &existingPublishEvents)
+	existingPublishEvents, err := DecodePublishEvents(ledgerItem.PublishEvents)
 	if err != nil {
 		log.Printf("error unmarshalling publishEvents: %s", err)
 		return existingPublishEvents, err
@@ -220,6 +280,9 @@ func (m *MediaEvent) SetEventID() {
 	m.EventID = fmt.Sprintf("%s.%s.%s.%s", m.Language, m.MediaType, m.Niche, m.PromptHash)
 }
 
+// Prefix of uploads not yet saved to a ledger; media bucket notifications of it are ignored.
+const UPLOAD_STAGING_PREFIX = "upload-staging/"
+
 func (m *MediaEvent) SetContentLookupKey() {
 	// Use guid because promptHash for static-scripts will collide.
 	// <media_Type>.<ledgerId>.<guid>.<media_file_extention>
@@ -15,6 +15,12 @@ func HandlerHealthCheck(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Ok")
 }
 
+func writeJson(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
+
 func isAuthorized(r *http.Request) bool {
 	return r.Header.Get("Authorization") == "password" // TODO, obviously replace this.
 }
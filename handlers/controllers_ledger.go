@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	ledgers "github.com/bezalel-media-core/v2/service/ledgers"
+	requestModels "github.com/bezalel-media-core/v2/service/models"
+	orchestration "github.com/bezalel-media-core/v2/service/orchestration"
+)
+
+func HandlerGetLedger(w http.ResponseWriter, r *http.Request) {
+	if !isAuthorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "Unauthorized.")
+		return
+	}
+
+	ledgerID := r.PathValue("id")
+	view, found, err := ledgers.GetLedgerTrackingView(ledgerID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Ledger not found: %s", ledgerID)
+		return
+	}
+	writeJson(w, http.StatusOK, view)
+}
+
+func HandlerGetLedgerHistory(w http.ResponseWriter, r *http.Request) {
+	if !isAuthorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "Unauthorized.")
+		return
+	}
+
+	ledgerID := r.PathValue("id")
+	view, found, err := ledgers.GetLedgerHistoryView(ledgerID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Ledger not found: %s", ledgerID)
+		return
+	}
+	writeJson(w, http.StatusOK, view)
+}
+
+// Query params from (default 0) and to (default latest) are ledger history versions.
+func HandlerGetLedgerDiff(w http.ResponseWriter, r *http.Request) {
+	if !isAuthorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "Unauthorized.")
+		return
+	}
+
+	fromVersion, errFrom := parseVersionParam(r, "from", 0)
+	toVersion, errTo := parseVersionParam(r, "to", -1)
+	if errFrom != nil || errTo != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "from and to must be history versions")
+		return
+	}
+
+	ledgerID := r.PathValue("id")
+	view, found, err := ledgers.GetLedgerDiffView(ledgerID, fromVersion, toVersion)
+	if errors.Is(err, ledgers.ErrInvalidHistoryRange) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Ledger not found: %s", ledgerID)
+		return
+	}
+	writeJson(w, http.StatusOK, view)
+}
+
+func parseVersionParam(r *http.Request, name string, defaultVersion int64) (int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultVersion, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// See ledgers.ParseLedgerListQuery for the query params.
+func HandlerListLedgers(w http.ResponseWriter, r *http.Request) {
+	listLedgers(w, r, false, "")
+}
+
+// Like HandlerListLedgers, with the q search text required.
+func HandlerSearchLedgers(w http.ResponseWriter, r *http.Request) {
+	listLedgers(w, r, true, "")
+}
+
+// Like HandlerListLedgers, for Quarantined ledgers with their quarantine reason and last workflow errors.
+func HandlerListQuarantinedLedgers(w http.ResponseWriter, r *http.Request) {
+	listLedgers(w, r, false, tables.QUARANTINED_LEDGER)
+}
+
+// A non-empty status is required of the listed ledgers.
+func listLedgers(w http.ResponseWriter, r *http.Request, requireSearch bool, status tables.LedgerStatus) {
+	if !isAuthorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "Unauthorized.")
+		return
+	}
+
+	query, err := ledgers.ParseLedgerListQuery(r.URL.Query(), time.Now())
+	if err == nil && requireSearch && query.Filter.Search == "" {
+		err = fmt.Errorf("%w: q is required", ledgers.ErrInvalidListQuery)
+	}
+	if err == nil && status != "" {
+		if query.Filter.Status != "" && query.Filter.Status != status {
+			err = fmt.Errorf("%w: status must be %s", ledgers.ErrInvalidListQuery, status)
+		}
+		query.Filter.Status = status
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	view, err := ledgers.ListLedgerViews(query)
+	if errors.Is(err, ledgers.ErrInvalidListQuery) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	writeJson(w, http.StatusOK, view)
+}
+
+// Body: requestModels.LedgerRedriveRequest. A Finished ledger returns 409 unless forced.
+func HandlerRedriveLedger(w http.ResponseWriter, r *http.Request) {
+	if !isAuthorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "Unauthorized.")
+		return
+	}
+
+	var payload requestModels.LedgerRedriveRequest
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	stage, err := orchestration.ParseRedriveStage(payload.Stage)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+
+	ledgerID := r.PathValue("id")
+	result, found, err := orchestration.RedriveLedger(orchestration.RedriveRequest{
+		LedgerID: ledgerID,
+		Stage:    stage,
+		Channel:  payload.Channel,
+		Force:    payload.Force,
+	})
+	if errors.Is(err, orchestration.ErrInvalidRedrive) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if errors.Is(err, orchestration.ErrRedriveRequiresForce) || errors.Is(err, orchestration.ErrAssignmentTaken) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Ledger not found: %s", ledgerID)
+		return
+	}
+	writeJson(w, http.StatusOK, result)
+}
@@ -1,32 +1,170 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 
+	env "github.com/bezalel-media-core/v2/configuration"
+	"github.com/bezalel-media-core/v2/service/authorization"
 	ingestion_service "github.com/bezalel-media-core/v2/service/ingestion"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
 )
 
-func HandlerCustomPrompt(w http.ResponseWriter, r *http.Request) {
-	if !isAuthorized(r) {
+// Handler for a registered ingestion source; see manifest source_to_script_categories.
+func HandlerSource(source string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleSourceEvent(source, w, r)
+	}
+}
+
+// Handler for a registered upload source; accepts multipart/form-data media files.
+func HandlerSourceUpload(source string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Route must be called with POST, given %s", r.Method)
+			return
+		}
+		verifyBody, ok := isSourceUploadAuthorized(source, r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "Unauthorized.")
+			return
+		}
+
+		result, err := ingestion_service.SaveSourceUploadToLedger(source, w, r, verifyBody)
+		if errors.Is(err, ingestion_service.ErrUnverifiedUpload) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "Unauthorized.")
+			return
+		}
+		writeJson(w, ingestionStatusCode(result), result)
+	}
+}
+
+func handleSourceEvent(source string, w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Route must be called with POST, given %s", r.Method)
+		return
+	}
+	if !isSourceRequestAuthorized(source, r, authorization.MaxSignedBodyBytes) {
 		w.WriteHeader(http.StatusUnauthorized)
 		fmt.Fprintf(w, "Unauthorized.")
 		return
 	}
 
+	result, _ := ingestion_service.SaveSourceEventToLedger(source, r)
+	writeJson(w, ingestionStatusCode(result), result)
+}
+
+// Source requests must be HMAC-signed with a credential of that source; see authorization.VerifySourceRequest.
+func isSourceRequestAuthorized(source string, r *http.Request, maxBodyBytes int64) bool {
+	body, err := authorization.VerifySourceRequest(source, r, maxBodyBytes)
+	if errors.Is(err, authorization.ErrMissingSignature) && env.GetEnvConfigs().AllowUnsignedSourceRequests {
+		return isAuthorized(r)
+	}
+	if err != nil {
+		log.Printf("rejected source request for %s: %s", source, err)
+		return false
+	}
+	authorization.WithVerifiedBody(r, body)
+	return true
+}
+
+// Like isSourceRequestAuthorized, without buffering the upload; the body is verified by the returned func.
+func isSourceUploadAuthorized(source string, r *http.Request) (func() error, bool) {
+	verifyBody, err := authorization.VerifySourceRequestStream(source, r, ingestion_service.MaxUploadRequestBytes())
+	if errors.Is(err, authorization.ErrMissingSignature) && env.GetEnvConfigs().AllowUnsignedSourceRequests {
+		return func() error { return nil }, isAuthorized(r)
+	}
+	if err != nil {
+		log.Printf("rejected source upload for %s: %s", source, err)
+		return nil, false
+	}
+	return verifyBody, true
+}
+
+func ingestionStatusCode(result models_v1.IngestionResult) int {
+	switch result.Outcome {
+	case models_v1.OUTCOME_INVALID:
+		return http.StatusBadRequest
+	case models_v1.OUTCOME_REJECTED:
+		return http.StatusUnprocessableEntity
+	case models_v1.OUTCOME_FAILED:
+		return http.StatusInternalServerError
+	case models_v1.OUTCOME_SHED:
+		return http.StatusTooManyRequests
+	case models_v1.OUTCOME_PARKED:
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// Streams one NDJSON result per JSONL request line of ?source=<sourceName>, signed like that source's requests;
+// see ingestion.IngestSourceJsonLines. The -bulk-ingest CLI ingests lines of any source.
+func HandlerBulkIngestion(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		w.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(w, "Route must be called with POST, given %s", r.Method)
 		return
 	}
-	source := r.URL.Path[1:]
-	err := ingestion_service.SaveSourceEventToLedger(source, r)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, err.Error())
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Missing source query parameter.")
+		return
+	}
+	if !isSourceRequestAuthorized(source, r, authorization.MaxSignedBodyBytes) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "Unauthorized.")
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/x-ndjson")
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Ok")
+	encoder := json.NewEncoder(w)
+	flusher, canFlush := w.(http.Flusher)
+	err := ingestion_service.IngestSourceJsonLines(source, r.Body, func(result models_v1.IngestionResult) error {
+		err := encoder.Encode(result)
+		if err == nil && canFlush {
+			flusher.Flush()
+		}
+		return err
+	})
+	if err != nil {
+		// Status is already sent; report the aborted stream as a final result line.
+		encoder.Encode(models_v1.IngestionResult{Outcome: models_v1.OUTCOME_FAILED, Error: err.Error()})
+	}
+}
+
+// Dry run of a source request given as ?source=<sourceName>; see ingestion.PreviewSourcePayload.
+func HandlerPreviewIngestion(w http.ResponseWriter, r *http.Request) {
+	if !isAuthorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "Unauthorized.")
+		return
+	}
+
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Route must be called with POST, given %s", r.Method)
+		return
+	}
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Missing source query parameter.")
+		return
+	}
+
+	result, err := ingestion_service.PreviewSourcePayload(source, r.Body)
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, result)
+		return
+	}
+	writeJson(w, http.StatusOK, result)
 }
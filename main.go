@@ -8,41 +8,79 @@ import (
 	dynamo_configuration "github.com/bezalel-media-core/v2/configuration/dynamo"
 	handlers "github.com/bezalel-media-core/v2/handlers"
 	manifest "github.com/bezalel-media-core/v2/manifest"
+	ingestion "github.com/bezalel-media-core/v2/service/ingestion"
 
 	pubsub "github.com/bezalel-media-core/v2/service/orchestration"
+	archiveDaemon "github.com/bezalel-media-core/v2/service/system/archival"
+	batchDaemon "github.com/bezalel-media-core/v2/service/system/batching"
+	cronDaemon "github.com/bezalel-media-core/v2/service/system/cron"
+	feedDaemon "github.com/bezalel-media-core/v2/service/system/feeds"
 	heartbeatDaemon "github.com/bezalel-media-core/v2/service/system/heartbeat"
+	parkingDaemon "github.com/bezalel-media-core/v2/service/system/parking"
 )
 
 const route_health = "/health"
+const route_bulk_ingestion = "/v1/ingestion/bulk"
+const route_preview_ingestion = "/v1/ingestion/preview"
+const route_get_ledger = "GET /v1/ledger/{id}"
+const route_get_ledger_history = "GET /v1/ledger/{id}/history"
+const route_get_ledger_diff = "GET /v1/ledger/{id}/diff"
+const route_redrive_ledger = "POST /v1/ledger/{id}/redrive"
+const route_list_ledgers = "GET /v1/ledgers"
+const route_search_ledgers = "GET /v1/ledgers/search"
+const route_list_quarantined_ledgers = "GET /v1/ledgers/quarantined"
 
 // Oauth2 Flows
 const route_youtube_oauth_start = "/v1/authcode/youtube" // start endpoint for enabling oauth code flow.
 const route_youtube_oauth_callback = "/v1/authcode/youtube/callback"
 
-// Custom ingestion sources
-const route_source_prompt = "/v1/source/prompt"
-const route_source_blog = "/v1/source/blog"
-const route_source_forum = "/v1/source/forum"
-const route_source_reactions_short_image = "/v1/source/reaction/short/image"
-const route_source_reactions_short_video = "/v1/source/reaction/short/video"
-const route_source_reactions_long_image = "/v1/source/reaction/long/image"
-const route_source_reactions_long_video = "/v1/source/reaction/long/video"
-
 func main() {
 	// Register Oauth callbacks
 	http.HandleFunc(route_youtube_oauth_start, handlers.HandlerOauthCodeFlowStart)
 	http.HandleFunc(route_youtube_oauth_callback, handlers.HandlerOauthCodeCallback)
-	// Register ingestion handlers
 	http.HandleFunc(route_health, handlers.HandlerHealthCheck)
-	http.HandleFunc(route_source_prompt, handlers.HandlerCustomPrompt)
-	http.HandleFunc(route_source_blog, handlers.HandlerCustomPrompt)
-	http.HandleFunc(route_source_forum, handlers.HandlerCustomPrompt)
+	http.HandleFunc(route_bulk_ingestion, handlers.HandlerBulkIngestion)
+	http.HandleFunc(route_preview_ingestion, handlers.HandlerPreviewIngestion)
+	http.HandleFunc(route_get_ledger, handlers.HandlerGetLedger)
+	http.HandleFunc(route_get_ledger_history, handlers.HandlerGetLedgerHistory)
+	http.HandleFunc(route_get_ledger_diff, handlers.HandlerGetLedgerDiff)
+	http.HandleFunc(route_redrive_ledger, handlers.HandlerRedriveLedger)
+	http.HandleFunc(route_list_ledgers, handlers.HandlerListLedgers)
+	http.HandleFunc(route_search_ledgers, handlers.HandlerSearchLedgers)
+	http.HandleFunc(route_list_quarantined_ledgers, handlers.HandlerListQuarantinedLedgers)
 
 	config.GetEnvConfigs()
-	dynamo_configuration.Init()
 	manifest.GetManifestLoader()
+	registerSourceHandlers()
+	dynamo_configuration.Init()
+	if runCommandLineMode() {
+		return
+	}
 	go pubsub.PollForLedgerUpdates()
 	go heartbeatDaemon.StartHeartbeatWatch()
+	go feedDaemon.StartFeedWatch()
+	go batchDaemon.StartBatchFlushWatch()
+	go cronDaemon.StartCronWatch()
+	go parkingDaemon.StartParkedEventWatch()
+	go archiveDaemon.StartLedgerArchiveWatch()
 	//go scaler.StartWatching() TODO Set this when ECS provisioned.
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
+
+// Ingestion routes and drivers are declared by the manifest source registry.
+func registerSourceHandlers() {
+	err := ingestion.InitSourceRegistry()
+	if err != nil {
+		log.Fatalf("inconsistent manifest source registry: %s", err)
+	}
+	for _, s := range manifest.GetManifestLoader().SourceToScriptCategoryCollection.Sources {
+		if s.Route == "" {
+			continue
+		}
+		if s.DriverType == manifest.DRIVER_TYPE_UPLOAD {
+			http.HandleFunc(s.Route, handlers.HandlerSourceUpload(s.SourceName))
+		} else {
+			http.HandleFunc(s.Route, handlers.HandlerSource(s.SourceName))
+		}
+	}
+}
@@ -13,6 +13,11 @@ type ManifestLoader struct {
 	ScriptPrompts                    ScriptPromptCollection
 	SourceToScriptCategoryCollection SourceCollection
 	DistributionFormatToChannel      DistributionFormatCollection
+	FeedSources                      FeedSourceCollection
+	BatchPolicies                    BatchPolicyCollection
+	CronSchedules                    CronScheduleCollection
+	AdmissionPolicies                AdmissionPolicyCollection
+	ModerationRules                  ModerationRuleCollection
 }
 
 var manifestInstance *ManifestLoader
@@ -21,6 +26,7 @@ var once sync.Once
 const (
 	PROMPT_SCRIPT_VAR_RAW_TEXT           = "$RAW_TEXT"
 	PROMPT_SCRIPT_VAR_LANGUAGE           = "$LANGUAGE"
+	PROMPT_SCRIPT_VAR_SOURCE_URLS        = "$SOURCE_URLS" // CSV of the ledger's website urls; only in citationText.
 	PROMPT_SCRIPT_VAR_BLOG_FORMAT        = "$BLOG_JSON_FORMAT"
 	PROMPT_SCRIPT_VAR_TINY_BLOG_FORMAT   = "$TINY_BLOG_JSON_FORMAT"
 	PROMPT_SCRIPT_VAR_SHORT_VIDEO_FORMAT = "$SHORT_VIDEO_JSON_FORMAT"
@@ -39,6 +45,8 @@ type Prompt struct {
 	PromptCategoryKey string `yaml:"promptCategoryKey"` // Language.MediaType.Niche
 	SystemPromptText  string `yaml:"systemPromptText"`
 	PromptText        string `yaml:"promptText"`
+	// Appended to the prompt only for ledgers with website urls, e.g. articles to cite; may use $SOURCE_URLS.
+	CitationText string `yaml:"citationText"`
 }
 
 func (p *Prompt) GetDistributionFormat() string {
@@ -53,13 +61,35 @@ type ScriptPromptCollection struct {
 	ScriptPrompts []Prompt `yaml:"scriptPrompts"`
 }
 
+const (
+	DRIVER_TYPE_PROMPT   = "prompt"
+	DRIVER_TYPE_BLOG     = "blog"
+	DRIVER_TYPE_FORUM    = "forum"
+	DRIVER_TYPE_FEED     = "feed"
+	DRIVER_TYPE_REACTION = "reaction"
+	DRIVER_TYPE_UPLOAD   = "upload" // Multipart media uploads; not available to bulk ingestion.
+	DRIVER_TYPE_ARTICLE  = "article"
+)
+
+// Source registry; see source_to_script_categories.yml.
 type SourceCollection struct {
-	Sources []struct {
-		SourceName       string `yaml:"sourceName"`
-		ScriptCategories []struct {
-			CategoryKey string `yaml:"categoryKey"`
-		} `yaml:"scriptCategories"`
-	} `yaml:"sources"`
+	Sources []Source `yaml:"sources"`
+}
+
+type Source struct {
+	SourceName    string `yaml:"sourceName"`
+	Route         string `yaml:"route"` // Empty when the source has no HTTP entrypoint.
+	DriverType    string `yaml:"driverType"`
+	RequestSchema string `yaml:"requestSchema"`
+	// Reject payloads at least this similar to a recent ledger of the same source, (0, 1]; 0 disables.
+	SimilarityThreshold float64 `yaml:"similarityThreshold"`
+	// Replace emails, phones, addresses, handles, and profile urls in the payload before prompting.
+	RedactPersonalInfo bool             `yaml:"redactPersonalInfo"`
+	ScriptCategories   []ScriptCategory `yaml:"scriptCategories"`
+}
+
+type ScriptCategory struct {
+	CategoryKey string `yaml:"categoryKey"`
 }
 
 type DistributionFormatCollection struct {
@@ -71,6 +101,85 @@ type DistributionFormatCollection struct {
 	} `yaml:"distributionFormats"`
 }
 
+type FeedSourceCollection struct {
+	Feeds []FeedSource `yaml:"feeds"`
+}
+
+type FeedSource struct {
+	SourceName     string `yaml:"sourceName"`
+	FeedUrl        string `yaml:"feedUrl"`
+	TargetLanguage string `yaml:"targetLanguage"`
+}
+
+type BatchPolicyCollection struct {
+	Policies []BatchPolicy `yaml:"batchPolicies"`
+}
+
+// Zero-valued limits are disabled.
+type BatchPolicy struct {
+	SourceName      string `yaml:"sourceName"`
+	MaxItems        int    `yaml:"maxItems"`
+	MaxAgeSec       int64  `yaml:"maxAgeSec"`
+	MaxPayloadBytes int64  `yaml:"maxPayloadBytes"`
+}
+
+const (
+	LANE_PRIORITY = "priority"
+	LANE_STANDARD = "standard"
+	LANE_BULK     = "bulk"
+)
+
+// See admission_policies.yml.
+type AdmissionPolicyCollection struct {
+	MaxInFlight   int64             `yaml:"maxInFlight"`
+	Lanes         []AdmissionLane   `yaml:"lanes"`
+	DefaultPolicy AdmissionPolicy   `yaml:"defaultPolicy"`
+	Policies      []AdmissionPolicy `yaml:"admissionPolicies"`
+}
+
+type AdmissionLane struct {
+	Lane                 string `yaml:"lane"`
+	Rank                 int    `yaml:"rank"`
+	GlobalCeilingPercent int64  `yaml:"globalCeilingPercent"`
+}
+
+type AdmissionPolicy struct {
+	SourceName    string  `yaml:"sourceName"`
+	Lane          string  `yaml:"lane"`
+	RatePerMinute float64 `yaml:"ratePerMinute"`
+	Burst         int64   `yaml:"burst"`
+	MaxInFlight   int64   `yaml:"maxInFlight"`
+}
+
+// See moderation_rules.yml.
+type ModerationRuleCollection struct {
+	DefaultRules ModerationRules   `yaml:"defaultRules"`
+	SourceRules  []ModerationRules `yaml:"sourceRules"`
+	NicheRules   []ModerationRules `yaml:"nicheRules"`
+}
+
+type ModerationRules struct {
+	SourceName        string   `yaml:"sourceName"` // Scope of sourceRules.
+	Niche             string   `yaml:"niche"`      // Scope of nicheRules.
+	BlockedKeywords   []string `yaml:"blockedKeywords"`
+	BlockedPatterns   []string `yaml:"blockedPatterns"`
+	MaxPayloadChars   int      `yaml:"maxPayloadChars"`
+	AllowedUrlDomains []string `yaml:"allowedUrlDomains"`
+	Classifiers       []string `yaml:"classifiers"`
+}
+
+type CronScheduleCollection struct {
+	Schedules []CronSchedule `yaml:"cronSchedules"`
+}
+
+type CronSchedule struct {
+	Name            string `yaml:"name"`
+	Cron            string `yaml:"cron"` // 5-field expression, UTC.
+	SourceName      string `yaml:"sourceName"`
+	CatchUp         string `yaml:"catchUp"`         // skip, latest, all; defaults to latest.
+	PayloadTemplate string `yaml:"payloadTemplate"` // text/template rendering the source's request JSON.
+}
+
 func GetManifestLoader() *ManifestLoader {
 	if manifestInstance != nil {
 		return manifestInstance
@@ -112,11 +221,55 @@ func (m *ManifestLoader) GetScriptPromptsFromSource(sourceName string) []Prompt
 	return resultPrompts
 }
 
+func (m *ManifestLoader) GetSource(sourceName string) (Source, bool) {
+	for _, s := range m.SourceToScriptCategoryCollection.Sources {
+		if s.SourceName == sourceName {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+func (m *ManifestLoader) GetBatchPolicy(sourceName string) (BatchPolicy, bool) {
+	for _, p := range m.BatchPolicies.Policies {
+		if p.SourceName == sourceName {
+			return p, true
+		}
+	}
+	return BatchPolicy{}, false
+}
+
+// Falls back to the default policy for sources without their own.
+func (m *ManifestLoader) GetAdmissionPolicy(sourceName string) AdmissionPolicy {
+	for _, p := range m.AdmissionPolicies.Policies {
+		if p.SourceName == sourceName {
+			return p
+		}
+	}
+	policy := m.AdmissionPolicies.DefaultPolicy
+	policy.SourceName = sourceName
+	return policy
+}
+
+func (m *ManifestLoader) GetAdmissionLane(lane string) (AdmissionLane, bool) {
+	for _, l := range m.AdmissionPolicies.Lanes {
+		if l.Lane == lane {
+			return l, true
+		}
+	}
+	return AdmissionLane{}, false
+}
+
 func initManifest() {
 	manifest := ManifestLoader{
 		ScriptPrompts:                    getScriptPromptCollection(),
 		SourceToScriptCategoryCollection: getSourceToScriptCategoryCollection(),
 		DistributionFormatToChannel:      getDistributionFormatToChannelCollection(),
+		FeedSources:                      getFeedSourceCollection(),
+		BatchPolicies:                    getBatchPolicyCollection(),
+		CronSchedules:                    getCronScheduleCollection(),
+		AdmissionPolicies:                getAdmissionPolicyCollection(),
+		ModerationRules:                  getModerationRuleCollection(),
 	}
 	manifestInstance = &manifest
 }
@@ -174,3 +327,73 @@ func getDistributionFormatToChannelCollection() DistributionFormatCollection {
 	}
 	return distFormats
 }
+
+func getFeedSourceCollection() FeedSourceCollection {
+	feedFile, err := os.ReadFile("./manifest/feed_sources.yml")
+	if err != nil {
+		log.Fatalf("failed to load file manifest feed sources: %s", err)
+	}
+
+	var feeds FeedSourceCollection
+	err = yaml.Unmarshal(feedFile, &feeds)
+	if err != nil {
+		log.Fatalf("failed to unmarshall manifest feed sources: %s", err)
+	}
+	return feeds
+}
+
+func getBatchPolicyCollection() BatchPolicyCollection {
+	policyFile, err := os.ReadFile("./manifest/batch_policies.yml")
+	if err != nil {
+		log.Fatalf("failed to load file manifest batch policies: %s", err)
+	}
+
+	var policies BatchPolicyCollection
+	err = yaml.Unmarshal(policyFile, &policies)
+	if err != nil {
+		log.Fatalf("failed to unmarshall manifest batch policies: %s", err)
+	}
+	return policies
+}
+
+func getAdmissionPolicyCollection() AdmissionPolicyCollection {
+	policyFile, err := os.ReadFile("./manifest/admission_policies.yml")
+	if err != nil {
+		log.Fatalf("failed to load file manifest admission policies: %s", err)
+	}
+
+	var policies AdmissionPolicyCollection
+	err = yaml.Unmarshal(policyFile, &policies)
+	if err != nil {
+		log.Fatalf("failed to unmarshall manifest admission policies: %s", err)
+	}
+	return policies
+}
+
+func getModerationRuleCollection() ModerationRuleCollection {
+	rulesFile, err := os.ReadFile("./manifest/moderation_rules.yml")
+	if err != nil {
+		log.Fatalf("failed to load file manifest moderation rules: %s", err)
+	}
+
+	var rules ModerationRuleCollection
+	err = yaml.Unmarshal(rulesFile, &rules)
+	if err != nil {
+		log.Fatalf("failed to unmarshall manifest moderation rules: %s", err)
+	}
+	return rules
+}
+
+func getCronScheduleCollection() CronScheduleCollection {
+	cronFile, err := os.ReadFile("./manifest/cron_schedules.yml")
+	if err != nil {
+		log.Fatalf("failed to load file manifest cron schedules: %s", err)
+	}
+
+	var schedules CronScheduleCollection
+	err = yaml.Unmarshal(cronFile, &schedules)
+	if err != nil {
+		log.Fatalf("failed to unmarshall manifest cron schedules: %s", err)
+	}
+	return schedules
+}
@@ -0,0 +1,237 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	configs "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/google/uuid"
+)
+
+var s3_svc = s3.New(configs.GetAwsSession())
+
+var ErrArchivedLedgerNotFound = errors.New("ledger not found in archive")
+
+// One line of an archive object: a ledger with its events decoded, and its history.
+type LedgerArchiveRecord struct {
+	Ledger               tables.Ledger            `json:"ledger"` // MediaEvents and PublishEvents are cleared; see below.
+	MediaEvents          []tables.MediaEvent      `json:"mediaEvents"`
+	PublishEvents        []tables.PublishEvent    `json:"publishEvents"`
+	History              []dal.LedgerHistoryEntry `json:"history"`
+	ArchivedAtEpochMilli int64                    `json:"archivedAtEpochMilli"`
+}
+
+type RestoreResult struct {
+	ArchiveObjectKey string   `json:"archiveObjectKey"`
+	Restored         []string `json:"restored"`
+	Skipped          []string `json:"skipped"` // Still live in the table.
+}
+
+// <prefix>/date=<YYYY-MM-DD of ledger creation, UTC>/source=<source>
+func PartitionPrefix(prefix string, createdAtEpochMilli int64, source string) string {
+	if source == "" {
+		source = "unknown"
+	}
+	partition := fmt.Sprintf("date=%s/source=%s", time.UnixMilli(createdAtEpochMilli).UTC().Format(time.DateOnly),
+		url.PathEscape(source))
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return partition
+	}
+	return prefix + "/" + partition
+}
+
+func NewLedgerArchiveRecord(ledgerItem tables.Ledger, history []dal.LedgerHistoryEntry, archivedAt time.Time) (LedgerArchiveRecord, error) {
+	record := LedgerArchiveRecord{History: history, ArchivedAtEpochMilli: archivedAt.UnixMilli()}
+	var err error
+	record.MediaEvents, err = ledgerItem.GetExistingMediaEvents()
+	if err != nil {
+		return record, err
+	}
+	record.PublishEvents, err = ledgerItem.GetExistingPublishEvents()
+	if err != nil {
+		return record, err
+	}
+	ledgerItem.ClearEvents()
+	record.Ledger = ledgerItem
+	return record, nil
+}
+
+// Gzipped JSON lines, one record per line.
+func EncodeArchive(records []LedgerArchiveRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for _, r := range records {
+		err := encoder.Encode(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := zw.Close()
+	return buf.Bytes(), err
+}
+
+func DecodeArchive(r io.Reader) ([]LedgerArchiveRecord, error) {
+	records := []LedgerArchiveRecord{}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return records, err
+	}
+	defer zr.Close()
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024) // Lines hold every event of a ledger.
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record LedgerArchiveRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Records grouped by partition prefix, in the order of the sorted prefixes.
+func GroupByPartition(prefix string, records []LedgerArchiveRecord) ([]string, map[string][]LedgerArchiveRecord) {
+	partitions := map[string][]LedgerArchiveRecord{}
+	for _, r := range records {
+		p := PartitionPrefix(prefix, r.Ledger.LedgerCreatedAtEpochMilli, r.Ledger.TriggerEventSource)
+		partitions[p] = append(partitions[p], r)
+	}
+	keys := []string{}
+	for p := range partitions {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+	return keys, partitions
+}
+
+// Exports Finished and Quarantined ledgers nearing TTL, one object per partition, then marks each ledger archived.
+func ArchiveExpiringLedgers() {
+	envConfigs := configs.GetEnvConfigs()
+	now := time.Now()
+	ttlBefore := now.Add(time.Duration(envConfigs.LedgerArchiveLeadHours) * time.Hour).Unix()
+	ledgerIds, err := dal.ListArchivableLedgers(ttlBefore, envConfigs.LedgerArchiveBatchSize)
+	if err != nil {
+		log.Printf("error listing ledgers to archive: %s", err)
+		return
+	}
+
+	records := []LedgerArchiveRecord{}
+	for _, id := range ledgerIds {
+		record, found, err := getLedgerArchiveRecord(id, now)
+		if err != nil {
+			log.Printf("correlationID: %s error reading ledger to archive: %s", id, err)
+			continue
+		}
+		if found {
+			records = append(records, record)
+		}
+	}
+
+	runId := fmt.Sprintf("%d-%s", now.UnixMilli(), uuid.New().String())
+	prefixes, partitions := GroupByPartition(envConfigs.LedgerArchivePrefix, records)
+	for _, p := range prefixes {
+		objectKey := fmt.Sprintf("%s/%s.jsonl.gz", p, runId)
+		err = putArchive(objectKey, partitions[p])
+		if err != nil {
+			log.Printf("error archiving %d ledgers to %s: %s", len(partitions[p]), objectKey, err)
+			continue
+		}
+		for _, r := range partitions[p] {
+			err = dal.MarkLedgerArchived(r.Ledger.LedgerID, r.Ledger.HistoryVersion, objectKey)
+			if err != nil {
+				log.Printf("correlationID: %s error marking ledger archived to %s: %s", r.Ledger.LedgerID, objectKey, err)
+			}
+		}
+		log.Printf("archived %d ledgers to %s", len(partitions[p]), objectKey)
+	}
+}
+
+// Returns false when the ledger expired since it was listed.
+func getLedgerArchiveRecord(ledgerId string, archivedAt time.Time) (LedgerArchiveRecord, bool, error) {
+	ledgerItem, err := dal.GetLedger(ledgerId)
+	if err != nil || ledgerItem.LedgerID == "" {
+		return LedgerArchiveRecord{}, false, err
+	}
+	history, err := dal.GetLedgerHistory(ledgerId)
+	if err != nil {
+		return LedgerArchiveRecord{}, false, err
+	}
+	record, err := NewLedgerArchiveRecord(ledgerItem, history, archivedAt)
+	return record, err == nil, err
+}
+
+func putArchive(objectKey string, records []LedgerArchiveRecord) error {
+	body, err := EncodeArchive(records)
+	if err != nil {
+		return err
+	}
+	_, err = s3_svc.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(configs.GetEnvConfigs().LedgerArchiveBucket),
+		Key:         aws.String(objectKey),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/gzip"),
+	})
+	return err
+}
+
+// Restores the ledger of the archive object, or every ledger of it when ledgerId is empty. Ledgers still in the
+// table are skipped.
+func RestoreLedgers(objectKey string, ledgerId string) (RestoreResult, error) {
+	result := RestoreResult{ArchiveObjectKey: objectKey, Restored: []string{}, Skipped: []string{}}
+	object, err := s3_svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(configs.GetEnvConfigs().LedgerArchiveBucket),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		log.Printf("error downloading ledger archive %s: %s", objectKey, err)
+		return result, err
+	}
+	defer object.Body.Close()
+	records, err := DecodeArchive(object.Body)
+	if err != nil {
+		log.Printf("error decoding ledger archive %s: %s", objectKey, err)
+		return result, err
+	}
+
+	for _, r := range records {
+		if ledgerId != "" && r.Ledger.LedgerID != ledgerId {
+			continue
+		}
+		r.Ledger.ArchiveObjectKey = objectKey // Archived again only once appended to, e.g. by a redrive.
+		r.Ledger.ArchivedHistoryVersion = r.Ledger.HistoryVersion
+		err = dal.RestoreArchivedLedger(r.Ledger, r.MediaEvents, r.PublishEvents, r.History)
+		if errors.Is(err, dal.ErrLedgerExists) {
+			result.Skipped = append(result.Skipped, r.Ledger.LedgerID)
+			continue
+		}
+		if err != nil {
+			log.Printf("correlationID: %s error restoring ledger from %s: %s", r.Ledger.LedgerID, objectKey, err)
+			return result, err
+		}
+		result.Restored = append(result.Restored, r.Ledger.LedgerID)
+	}
+	if ledgerId != "" && len(result.Restored)+len(result.Skipped) == 0 {
+		return result, fmt.Errorf("%w: %s in %s", ErrArchivedLedgerNotFound, ledgerId, objectKey)
+	}
+	return result, nil
+}
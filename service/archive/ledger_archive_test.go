@@ -0,0 +1,60 @@
+package archive
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionPrefix(t *testing.T) {
+	createdAt := time.Date(2026, 10, 18, 23, 30, 0, 0, time.UTC).UnixMilli()
+	assert.Equal(t, "ledger-archive/date=2026-10-18/source=Reddit", PartitionPrefix("/ledger-archive/", createdAt, "Reddit"))
+	assert.Equal(t, "date=2026-10-18/source=unknown", PartitionPrefix("", createdAt, ""))
+	assert.Equal(t, "a/date=2026-10-18/source=News%2FWire", PartitionPrefix("a", createdAt, "News/Wire"))
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	ledgerItem := tables.Ledger{LedgerID: "l1", LedgerStatus: tables.FINISHED_LEDGER, TriggerEventSource: "Reddit", HistoryVersion: 2}
+	mediaEvents := []tables.MediaEvent{{EventID: "m1", SystemPromptInstruction: "system", PromptInstruction: "prompt"}}
+	publishEvents := []tables.PublishEvent{{PublisherProfileID: "p1", RootMediaEventID: "m1", PublishStatus: tables.COMPLETE}}
+	var err error
+	ledgerItem.MediaEvents, err = tables.EncodeMediaEvents(mediaEvents)
+	assert.Nil(t, err)
+	ledgerItem.PublishEvents, err = tables.EncodePublishEvents(publishEvents)
+	assert.Nil(t, err)
+	history := []dal.LedgerHistoryEntry{{LedgerID: "l1", HistoryVersion: 1, EventType: dal.LEDGER_EVENT_MEDIA}}
+
+	record, err := NewLedgerArchiveRecord(ledgerItem, history, time.UnixMilli(1000))
+	assert.Nil(t, err)
+	assert.Empty(t, record.Ledger.MediaEvents)
+	assert.Empty(t, record.Ledger.PublishEvents)
+	assert.Equal(t, mediaEvents, record.MediaEvents)
+	assert.Equal(t, int64(1000), record.ArchivedAtEpochMilli)
+
+	encoded, err := EncodeArchive([]LedgerArchiveRecord{record, record})
+	assert.Nil(t, err)
+	decoded, err := DecodeArchive(bytes.NewReader(encoded))
+	assert.Nil(t, err)
+	assert.Equal(t, []LedgerArchiveRecord{record, record}, decoded)
+
+	_, err = DecodeArchive(bytes.NewReader([]byte("{\"ledger\": {}}")))
+	assert.NotNil(t, err)
+}
+
+func TestGroupByPartition(t *testing.T) {
+	day := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	records := []LedgerArchiveRecord{
+		{Ledger: tables.Ledger{LedgerID: "a", TriggerEventSource: "Reddit", LedgerCreatedAtEpochMilli: day.UnixMilli()}},
+		{Ledger: tables.Ledger{LedgerID: "b", TriggerEventSource: "Blog", LedgerCreatedAtEpochMilli: day.UnixMilli()}},
+		{Ledger: tables.Ledger{LedgerID: "c", TriggerEventSource: "Reddit", LedgerCreatedAtEpochMilli: day.Add(time.Hour).UnixMilli()}},
+		{Ledger: tables.Ledger{LedgerID: "d", TriggerEventSource: "Reddit", LedgerCreatedAtEpochMilli: day.AddDate(0, 0, -1).UnixMilli()}},
+	}
+	prefixes, partitions := GroupByPartition("p", records)
+	assert.Equal(t, []string{"p/date=2026-10-17/source=Reddit", "p/date=2026-10-18/source=Blog", "p/date=2026-10-18/source=Reddit"}, prefixes)
+	assert.Len(t, partitions["p/date=2026-10-18/source=Reddit"], 2)
+	assert.Equal(t, "d", partitions["p/date=2026-10-17/source=Reddit"][0].Ledger.LedgerID)
+}
@@ -0,0 +1,207 @@
+package authorization
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+)
+
+const (
+	HEADER_KEY_ID    = "X-Bezalel-Key-Id"
+	HEADER_TIMESTAMP = "X-Bezalel-Timestamp" // Unix seconds.
+	HEADER_SIGNATURE = "X-Bezalel-Signature" // "sha256=" + hex HMAC-SHA256(secret, timestamp + "." + body).
+
+	signaturePrefix = "sha256="
+	// Default body limit of signed requests; upload sources allow more.
+	MaxSignedBodyBytes = 10 * 1024 * 1024
+)
+
+var ErrMissingSignature = errors.New("request is not signed")
+
+// Signs a source request body; producers send the result in HEADER_SIGNATURE.
+func SignPayload(secret string, timestamp int64, body []byte) string {
+	mac := newSignatureMac(secret, timestamp)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HMAC of the signed timestamp; write the body to it.
+func newSignatureMac(secret string, timestamp int64) hash.Hash {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	return mac
+}
+
+// Verifies the request was signed by an active credential of the source and is not a replay.
+// Returns the verified body, which the caller must use in place of the consumed r.Body.
+func VerifySourceRequest(source string, r *http.Request, maxBodyBytes int64) ([]byte, error) {
+	keyID := r.Header.Get(HEADER_KEY_ID)
+	if keyID == "" && r.Header.Get(HEADER_SIGNATURE) == "" {
+		return nil, ErrMissingSignature
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	if err != nil {
+		log.Printf("error reading signed request body: %s", err)
+		return nil, err
+	}
+	if int64(len(body)) > maxBodyBytes {
+		return nil, fmt.Errorf("signed request body exceeds %d bytes", maxBodyBytes)
+	}
+
+	credential, err := getActiveCredential(source, keyID)
+	if err != nil {
+		return nil, err
+	}
+	err = verifySignature(credential.Secret, r.Header.Get(HEADER_TIMESTAMP), r.Header.Get(HEADER_SIGNATURE), body, time.Now(), replayWindow())
+	if err != nil {
+		return nil, err
+	}
+	err = recordSignature(r.Header.Get(HEADER_SIGNATURE))
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Like VerifySourceRequest, for bodies too large to buffer, e.g. uploads. The credential and timestamp are checked
+// up front and r.Body is replaced by a reader hashing the body as the caller streams it. The returned func reads
+// the rest of the body and verifies the signature; nothing read from the body may be kept until it returns nil.
+func VerifySourceRequestStream(source string, r *http.Request, maxBodyBytes int64) (func() error, error) {
+	keyID := r.Header.Get(HEADER_KEY_ID)
+	signatureHeader := r.Header.Get(HEADER_SIGNATURE)
+	if keyID == "" && signatureHeader == "" {
+		return nil, ErrMissingSignature
+	}
+	credential, err := getActiveCredential(source, keyID)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := verifySignatureHeaders(r.Header.Get(HEADER_TIMESTAMP), signatureHeader, time.Now(), replayWindow())
+	if err != nil {
+		return nil, err
+	}
+
+	body := newSignedBodyReader(credential.Secret, timestamp, r.Body, maxBodyBytes)
+	r.Body = body
+	return func() error {
+		err := body.verify(signatureHeader)
+		if err != nil {
+			return err
+		}
+		return recordSignature(signatureHeader)
+	}, nil
+}
+
+func getActiveCredential(source string, keyID string) (dal.SourceCredentialEntry, error) {
+	credential, err := dal.GetSourceCredential(source, keyID)
+	if err != nil {
+		return credential, err
+	}
+	if credential.KeyID == "" || credential.Status != dal.CREDENTIAL_ACTIVE {
+		return credential, fmt.Errorf("no active credential %s for source %s", keyID, source)
+	}
+	return credential, nil
+}
+
+func replayWindow() time.Duration {
+	return time.Duration(env.GetEnvConfigs().SignatureReplayWindowSec) * time.Second
+}
+
+// Signatures stay valid for the whole window, so remember them at least that long.
+func recordSignature(signatureHeader string) error {
+	fresh, err := dal.RecordSignatureNonce(signatureHeader, 2*int64(replayWindow().Seconds()))
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return errors.New("replayed request signature")
+	}
+	return nil
+}
+
+func verifySignature(secret string, timestampHeader string, signatureHeader string, body []byte, now time.Time, window time.Duration) error {
+	timestamp, err := verifySignatureHeaders(timestampHeader, signatureHeader, now, window)
+	if err != nil {
+		return err
+	}
+	expected := SignPayload(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signatureHeader)) {
+		return errors.New("request signature mismatch")
+	}
+	return nil
+}
+
+// Returns the signed timestamp.
+func verifySignatureHeaders(timestampHeader string, signatureHeader string, now time.Time, window time.Duration) (int64, error) {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed %s header: %s", HEADER_TIMESTAMP, timestampHeader)
+	}
+	skew := now.Sub(time.Unix(timestamp, 0))
+	if skew > window || skew < -window {
+		return 0, fmt.Errorf("request timestamp outside of replay window: %s", skew)
+	}
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) {
+		return 0, fmt.Errorf("malformed %s header", HEADER_SIGNATURE)
+	}
+	return timestamp, nil
+}
+
+// Hashes a request body as it is read, failing reads past maxBodyBytes.
+type signedBodyReader struct {
+	body      io.ReadCloser
+	mac       hash.Hash
+	remaining int64
+}
+
+func newSignedBodyReader(secret string, timestamp int64, body io.ReadCloser, maxBodyBytes int64) *signedBodyReader {
+	return &signedBodyReader{body: body, mac: newSignatureMac(secret, timestamp), remaining: maxBodyBytes}
+}
+
+func (s *signedBodyReader) Read(p []byte) (int, error) {
+	n, err := s.body.Read(p)
+	s.mac.Write(p[:n])
+	s.remaining -= int64(n)
+	if s.remaining < 0 {
+		return n, errors.New("signed request body exceeds its limit")
+	}
+	return n, err
+}
+
+func (s *signedBodyReader) Close() error {
+	return s.body.Close()
+}
+
+// Reads the rest of the body, e.g. a multipart epilogue, and compares the signature of all of it.
+func (s *signedBodyReader) verify(signatureHeader string) error {
+	_, err := io.Copy(io.Discard, s)
+	if err != nil {
+		log.Printf("error reading signed request body: %s", err)
+		return err
+	}
+	expected := signaturePrefix + hex.EncodeToString(s.mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signatureHeader)) {
+		return errors.New("request signature mismatch")
+	}
+	return nil
+}
+
+// Restores a body consumed by VerifySourceRequest.
+func WithVerifiedBody(r *http.Request, body []byte) {
+	r.Body = io.NopCloser(bytes.NewReader(body))
+}
@@ -0,0 +1,51 @@
+package authorization
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := "secret"
+	body := []byte(`{"promptText": "hello"}`)
+	now := time.Unix(1700000000, 0)
+	window := 5 * time.Minute
+	ts := strconv.FormatInt(now.Unix(), 10)
+	signature := SignPayload(secret, now.Unix(), body)
+
+	assert.Nil(t, verifySignature(secret, ts, signature, body, now, window))
+	assert.Nil(t, verifySignature(secret, ts, signature, body, now.Add(window), window), "expected edge of window to pass")
+
+	assert.NotNil(t, verifySignature("other", ts, signature, body, now, window), "expected wrong secret to fail")
+	assert.NotNil(t, verifySignature(secret, ts, signature, []byte(`{"promptText": "hellO"}`), now, window), "expected tampered body to fail")
+	assert.NotNil(t, verifySignature(secret, ts, signature, body, now.Add(window+time.Second), window), "expected stale timestamp to fail")
+	assert.NotNil(t, verifySignature(secret, ts, signature, body, now.Add(-window-time.Second), window), "expected future timestamp to fail")
+	assert.NotNil(t, verifySignature(secret, "abc", signature, body, now, window), "expected malformed timestamp to fail")
+	assert.NotNil(t, verifySignature(secret, ts, signature[len(signaturePrefix):], body, now, window), "expected missing prefix to fail")
+
+	resigned := SignPayload(secret, now.Unix()+1, body)
+	assert.NotNil(t, verifySignature(secret, ts, resigned, body, now, window), "expected timestamp to be bound to the signature")
+}
+
+func TestSignedBodyReader(t *testing.T) {
+	secret := "secret"
+	body := []byte("--boundary\r\nfile contents\r\n--boundary--\r\nepilogue")
+	signature := SignPayload(secret, 1700000000, body)
+
+	reader := newSignedBodyReader(secret, 1700000000, io.NopCloser(bytes.NewReader(body)), int64(len(body)))
+	head := make([]byte, 10)
+	_, err := io.ReadFull(reader, head)
+	assert.Nil(t, err)
+	assert.Nil(t, reader.verify(signature), "expected the unread rest of the body to be signed too")
+
+	tampered := newSignedBodyReader(secret, 1700000000, io.NopCloser(bytes.NewReader(append(body, 'x'))), 1024)
+	assert.NotNil(t, tampered.verify(signature), "expected tampered body to fail")
+
+	tooLarge := newSignedBodyReader(secret, 1700000000, io.NopCloser(bytes.NewReader(body)), int64(len(body)-1))
+	assert.NotNil(t, tooLarge.verify(signature), "expected body over the limit to fail")
+}
@@ -0,0 +1,87 @@
+package ingestion
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+)
+
+const (
+	ADMISSION_RATE_LIMITED     = "rate_limited"     // Source token bucket is empty.
+	ADMISSION_SOURCE_IN_FLIGHT = "source_in_flight" // Source reached its maxInFlight.
+	ADMISSION_LANE_AT_CAPACITY = "lane_at_capacity" // Global in-flight events reached the lane ceiling.
+
+	milliTokens             = 1000
+	maxAdmissionConflictTry = 5
+)
+
+// Takes a token and in-flight slot for one event of the source; released by dal.ReleaseLedgerAdmission
+// once its ledger completes. requestedLane may only lower the source's lane, e.g. for backfills.
+// Fails open on storage errors, as shedding every event would stall ingestion; reserved is false then,
+// so the ledger releases no slot it never took.
+func admitEvent(source string, requestedLane string) (admitted bool, reserved bool, reason string) {
+	m := manifest.GetManifestLoader()
+	policy := m.GetAdmissionPolicy(source)
+	lane := effectiveLane(m, policy.Lane, requestedLane)
+	laneConfig, _ := m.GetAdmissionLane(lane)
+	globalCeiling := m.AdmissionPolicies.MaxInFlight * laneConfig.GlobalCeilingPercent / 100
+
+	for i := 0; i < maxAdmissionConflictTry; i++ {
+		bucket, err := dal.GetSourceAdmissionBucket(source)
+		if err != nil {
+			log.Printf("WARN admitting event of source %s without admission control: %s", source, err)
+			return true, false, ""
+		}
+		now := time.Now().UnixMilli()
+		tokens := refillTokens(bucket, policy, now)
+		if tokens < milliTokens {
+			return false, false, ADMISSION_RATE_LIMITED
+		}
+		if bucket.InFlight >= policy.MaxInFlight {
+			return false, false, ADMISSION_SOURCE_IN_FLIGHT
+		}
+
+		err = dal.ReserveAdmission(source, bucket, tokens-milliTokens, now, globalCeiling)
+		if errors.Is(err, dal.ErrAdmissionConflict) {
+			continue
+		}
+		if errors.Is(err, dal.ErrLaneAtCapacity) {
+			return false, false, ADMISSION_LANE_AT_CAPACITY
+		}
+		if err != nil {
+			log.Printf("WARN admitting event of source %s without admission control: %s", source, err)
+			return true, false, ""
+		}
+		return true, true, ""
+	}
+	log.Printf("WARN admitting event of source %s after repeated admission conflicts", source)
+	return true, false, ""
+}
+
+// Tokens available at nowMilli, in thousandths; a new bucket starts full.
+func refillTokens(bucket dal.AdmissionBucketEntry, policy manifest.AdmissionPolicy, nowMilli int64) int64 {
+	capacity := policy.Burst * milliTokens
+	if bucket.LastRefillEpochMilli == 0 {
+		return capacity
+	}
+	elapsedMilli := max(nowMilli-bucket.LastRefillEpochMilli, 0)
+	// ratePerMinute tokens per 60000ms is ratePerMinute/60 thousandths of a token per ms.
+	refilled := bucket.TokensMilli + int64(float64(elapsedMilli)*policy.RatePerMinute/60)
+	return min(refilled, capacity)
+}
+
+// The lower-priority of the two lanes; unknown or empty requested lanes are ignored.
+func effectiveLane(m *manifest.ManifestLoader, sourceLane string, requestedLane string) string {
+	requested, ok := m.GetAdmissionLane(requestedLane)
+	if !ok {
+		return sourceLane
+	}
+	current, _ := m.GetAdmissionLane(sourceLane)
+	if requested.Rank > current.Rank {
+		return requested.Lane
+	}
+	return sourceLane
+}
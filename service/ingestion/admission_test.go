@@ -0,0 +1,37 @@
+package ingestion
+
+import (
+	"testing"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefillTokens(t *testing.T) {
+	policy := manifest.AdmissionPolicy{RatePerMinute: 60, Burst: 5}
+	const now = int64(1_700_000_000_000)
+
+	assert.Equal(t, int64(5000), refillTokens(dal.AdmissionBucketEntry{}, policy, now), "expected new bucket to start full")
+
+	drained := dal.AdmissionBucketEntry{TokensMilli: 0, LastRefillEpochMilli: now}
+	assert.Equal(t, int64(0), refillTokens(drained, policy, now))
+	assert.Equal(t, int64(1000), refillTokens(drained, policy, now+1000), "expected one token per second at 60/min")
+	assert.Equal(t, int64(500), refillTokens(drained, policy, now+500))
+	assert.Equal(t, int64(5000), refillTokens(drained, policy, now+60_000), "expected refill capped at burst")
+	assert.Equal(t, int64(0), refillTokens(drained, policy, now-1000), "expected clock skew not to drain tokens")
+}
+
+func TestEffectiveLane(t *testing.T) {
+	m := &manifest.ManifestLoader{AdmissionPolicies: manifest.AdmissionPolicyCollection{
+		Lanes: []manifest.AdmissionLane{
+			{Lane: manifest.LANE_PRIORITY, Rank: 0},
+			{Lane: manifest.LANE_STANDARD, Rank: 1},
+			{Lane: manifest.LANE_BULK, Rank: 2},
+		},
+	}}
+	assert.Equal(t, manifest.LANE_PRIORITY, effectiveLane(m, manifest.LANE_PRIORITY, ""))
+	assert.Equal(t, manifest.LANE_BULK, effectiveLane(m, manifest.LANE_PRIORITY, manifest.LANE_BULK), "expected backfills to lower the lane")
+	assert.Equal(t, manifest.LANE_BULK, effectiveLane(m, manifest.LANE_BULK, manifest.LANE_PRIORITY), "expected requests not to raise the lane")
+	assert.Equal(t, manifest.LANE_STANDARD, effectiveLane(m, manifest.LANE_STANDARD, "unknown"))
+}
@@ -0,0 +1,85 @@
+package ingestion
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+)
+
+const maxBulkLineBytes = 4 * 1024 * 1024
+
+type payloadSaver func(source string, payloadIO io.ReadCloser) (models_v1.IngestionResult, error)
+
+// Ingests a JSONL stream of {"source", "payload"} lines, emitting one result per non-blank line in order.
+// Per-line failures are reported through their result; only read and emit errors abort the stream.
+// Lines are admitted in the bulk lane so backfills yield to live sources.
+func IngestJsonLines(linesIO io.Reader, emit func(models_v1.IngestionResult) error) error {
+	return ingestJsonLines(linesIO, emit, saveBulkPayload)
+}
+
+// Like IngestJsonLines, with every line required to name the given source; other lines are invalid.
+// HTTP bulk requests are signed per source, so a credential of one source can't write ledgers of another.
+func IngestSourceJsonLines(source string, linesIO io.Reader, emit func(models_v1.IngestionResult) error) error {
+	return ingestJsonLines(linesIO, emit, onlySource(source, saveBulkPayload))
+}
+
+func saveBulkPayload(source string, payloadIO io.ReadCloser) (models_v1.IngestionResult, error) {
+	return savePayloadToLedger(source, payloadIO, manifest.LANE_BULK)
+}
+
+func onlySource(allowedSource string, save payloadSaver) payloadSaver {
+	return func(source string, payloadIO io.ReadCloser) (models_v1.IngestionResult, error) {
+		if source != allowedSource {
+			err := fmt.Errorf("line source %s differs from the signed source %s", source, allowedSource)
+			return invalidResult(source, err), err
+		}
+		return save(source, payloadIO)
+	}
+}
+
+func ingestJsonLines(linesIO io.Reader, emit func(models_v1.IngestionResult) error, save payloadSaver) error {
+	scanner := bufio.NewScanner(linesIO)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBulkLineBytes)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		result := ingestJsonLine(raw, save)
+		result.Line = lineNumber
+		err := emit(result)
+		if err != nil {
+			log.Printf("error emitting bulk ingestion result for line %d: %s", lineNumber, err)
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func ingestJsonLine(raw []byte, save payloadSaver) models_v1.IngestionResult {
+	var line models_v1.BulkIngestionLine
+	err := json.Unmarshal(raw, &line)
+	if err != nil {
+		return invalidResult("", fmt.Errorf("malformed json line: %w", err))
+	}
+	if line.Source == "" {
+		return invalidResult("", errors.New("line is missing source"))
+	}
+	if len(line.Payload) == 0 {
+		return invalidResult(line.Source, errors.New("line is missing payload"))
+	}
+
+	result, _ := save(line.Source, io.NopCloser(bytes.NewReader(line.Payload)))
+	result.Source = line.Source
+	return result
+}
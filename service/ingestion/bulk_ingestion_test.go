@@ -0,0 +1,76 @@
+package ingestion
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestJsonLines(t *testing.T) {
+	body := strings.Join([]string{
+		`{"source": "v1/source/prompt", "payload": {"promptText": "hello"}}`,
+		``,
+		`{"source": "v1/source/prompt", "payload": {"promptText": "dupe"}}`,
+		`not json`,
+		`{"source": "v1/source/prompt"}`,
+	}, "\n")
+
+	payloads := []string{}
+	save := func(source string, payloadIO io.ReadCloser) (models_v1.IngestionResult, error) {
+		raw, _ := io.ReadAll(payloadIO)
+		payloads = append(payloads, string(raw))
+		if strings.Contains(string(raw), "dupe") {
+			return models_v1.IngestionResult{Outcome: models_v1.OUTCOME_DUPLICATE, ContentHash: "h"}, nil
+		}
+		return models_v1.IngestionResult{Outcome: models_v1.OUTCOME_CREATED, LedgerID: "ledger-1"}, nil
+	}
+
+	results := []models_v1.IngestionResult{}
+	err := ingestJsonLines(strings.NewReader(body), func(r models_v1.IngestionResult) error {
+		results = append(results, r)
+		return nil
+	}, save)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`{"promptText": "hello"}`, `{"promptText": "dupe"}`}, payloads, "expected raw payloads passed through")
+	assert.Equal(t, 4, len(results), "expected blank lines to be skipped")
+
+	assert.Equal(t, 1, results[0].Line)
+	assert.Equal(t, models_v1.OUTCOME_CREATED, results[0].Outcome)
+	assert.Equal(t, "ledger-1", results[0].LedgerID)
+	assert.Equal(t, "v1/source/prompt", results[0].Source)
+
+	assert.Equal(t, 3, results[1].Line)
+	assert.Equal(t, models_v1.OUTCOME_DUPLICATE, results[1].Outcome)
+
+	assert.Equal(t, models_v1.OUTCOME_INVALID, results[2].Outcome, "expected malformed line to be invalid")
+	assert.Equal(t, models_v1.OUTCOME_INVALID, results[3].Outcome, "expected missing payload to be invalid")
+	assert.NotEmpty(t, results[3].Error)
+}
+
+func TestOnlySource(t *testing.T) {
+	body := strings.Join([]string{
+		`{"source": "v1/source/prompt", "payload": {"promptText": "hello"}}`,
+		`{"source": "v1/source/blog", "payload": {"text": "other"}}`,
+	}, "\n")
+	saved := []string{}
+	save := func(source string, payloadIO io.ReadCloser) (models_v1.IngestionResult, error) {
+		saved = append(saved, source)
+		return models_v1.IngestionResult{Outcome: models_v1.OUTCOME_CREATED}, nil
+	}
+
+	results := []models_v1.IngestionResult{}
+	err := ingestJsonLines(strings.NewReader(body), func(r models_v1.IngestionResult) error {
+		results = append(results, r)
+		return nil
+	}, onlySource("v1/source/prompt", save))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v1/source/prompt"}, saved, "expected lines of other sources not saved")
+	assert.Equal(t, models_v1.OUTCOME_CREATED, results[0].Outcome)
+	assert.Equal(t, models_v1.OUTCOME_INVALID, results[1].Outcome)
+	assert.Equal(t, "v1/source/blog", results[1].Source)
+}
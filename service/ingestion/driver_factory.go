@@ -2,29 +2,259 @@ package ingestion
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
+	env "github.com/bezalel-media-core/v2/configuration"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
 	"github.com/bezalel-media-core/v2/service/ingestion/drivers"
+	"github.com/bezalel-media-core/v2/service/ingestion/moderation"
 )
 
+type driverBuilder func(source string, payloadIO io.ReadCloser) (drivers.Driver, error)
+
+type driverRegistration struct {
+	requestSchema string // Name of the models_v1 request the driver decodes.
+	build         driverBuilder
+	// Side-effect free alternative to build for previews; defaults to build.
+	preview driverBuilder
+}
+
+// Driver types that manifest sources may declare.
+var driverTypes = map[string]driverRegistration{
+	manifest.DRIVER_TYPE_PROMPT: {
+		requestSchema: "CustomPromptRequest",
+		build: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			return drivers.NewCustomPromptDriver(payloadIO, source), nil
+		},
+	},
+	manifest.DRIVER_TYPE_BLOG: {
+		requestSchema: "BlogRequest",
+		build: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			return drivers.NewBlogPromptDriver(payloadIO, source), nil
+		},
+	},
+	manifest.DRIVER_TYPE_FORUM: {
+		requestSchema: "ForumDumpRequest",
+		build: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			return drivers.NewForumDriver(payloadIO, source, forumBudget()), nil
+		},
+	},
+	manifest.DRIVER_TYPE_FEED: {
+		requestSchema: "FeedItemRequest",
+		build: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			return drivers.NewFeedDriver(payloadIO, source), nil
+		},
+	},
+	manifest.DRIVER_TYPE_REACTION: {
+		requestSchema: "ReactionRequest",
+		build: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			policy, ok := manifest.GetManifestLoader().GetBatchPolicy(source)
+			if !ok {
+				return nil, errors.New("no batch policy found for batched source: " + source)
+			}
+			driverReact := drivers.NewReactDriver(source, policy, newEventScreen(moderator.Evaluate).moderate)
+			err := driverReact.WithMedia(payloadIO)
+			return driverReact, err
+		},
+		preview: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			return drivers.NewReactPreviewDriver(payloadIO, source), nil
+		},
+	},
+	manifest.DRIVER_TYPE_ARTICLE: {
+		requestSchema: "ArticleRequest",
+		build: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			return drivers.NewArticleDriver(payloadIO, source, articleFetcher()), nil
+		},
+	},
+	manifest.DRIVER_TYPE_UPLOAD: {
+		requestSchema: "MultipartUploadRequest",
+		build: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			// Multipart requests are handled by SaveSourceUploadToLedger.
+			driverUpload := drivers.NewUploadDriver(source, drivers.S3MediaStore{}, uploadLimits())
+			return nil, driverUpload.WithMedia(payloadIO)
+		},
+		preview: func(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+			return nil, errors.New("upload sources store media and cannot be previewed: " + source)
+		},
+	},
+}
+
+func articleFetcher() drivers.PageFetcher {
+	return drivers.NewHttpPageFetcher(time.Duration(env.GetEnvConfigs().ArticleFetchTimeoutSec) * time.Second)
+}
+
+func forumBudget() drivers.ForumBudget {
+	return drivers.ForumBudget{
+		MaxCommentChars: env.GetEnvConfigs().ForumCommentCharBudget,
+		MaxComments:     env.GetEnvConfigs().ForumMaxComments,
+	}
+}
+
+var registryOnce sync.Once
+var sourceToDriver map[string]driverRegistration
+var moderator *moderation.Moderator
+var registryErr error
+
+// Builds the source-to-driver registry and moderation rules from the manifest; main fails fast on the returned error.
+func InitSourceRegistry() error {
+	registryOnce.Do(func() {
+		var moderatorErr error
+		sourceToDriver, registryErr = buildSourceRegistry(manifest.GetManifestLoader())
+		moderator, moderatorErr = moderation.NewModerator(manifest.GetManifestLoader())
+		registryErr = errors.Join(registryErr, moderatorErr)
+	})
+	return registryErr
+}
+
 func GetDriver(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
-	var err error
-	switch {
-	case source == "v1/source/prompt":
-		val := drivers.NewCustomPromptDriver(payloadIO, source)
-		return val, nil
-	case source == "v1/source/blog" || source == "WorkflowIntegTest":
-		val := drivers.NewBlogPromptDriver(payloadIO, source)
-		return val, nil
-	case source == "v1/source/forum":
-		val := drivers.NewForumDriver(payloadIO, source)
-		return val, nil
-	case source == "v1/reactions/short/image" || source == "v1/reactions/short/video" ||
-		source == "v1/reactions/long/image" || source == "v1/reactions/long/video":
-		driverReact := drivers.NewReactDriver(source)
-		err = driverReact.WithMedia(payloadIO)
-		return driverReact, err
-	}
-
-	return nil, errors.New("no matching source-to-driver found")
+	err := InitSourceRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	registration, ok := sourceToDriver[source]
+	if !ok {
+		return nil, errors.New("no matching source-to-driver found: " + source)
+	}
+	return registration.build(source, payloadIO)
+}
+
+func moderateLedger(ledgerItem tables.Ledger) (moderation.Rejection, bool) {
+	InitSourceRegistry()
+	return moderator.Evaluate(ledgerItem)
+}
+
+// Driver that builds the same ledger as GetDriver without buffering or storing anything.
+func GetPreviewDriver(source string, payloadIO io.ReadCloser) (drivers.Driver, error) {
+	err := InitSourceRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	registration, ok := sourceToDriver[source]
+	if !ok {
+		return nil, errors.New("no matching source-to-driver found: " + source)
+	}
+	if registration.preview != nil {
+		return registration.preview(source, payloadIO)
+	}
+	return registration.build(source, payloadIO)
+}
+
+func buildSourceRegistry(m *manifest.ManifestLoader) (map[string]driverRegistration, error) {
+	errs := []error{}
+	registry := map[string]driverRegistration{}
+	routes := map[string]string{}
+	promptCategories := map[string]bool{}
+	for _, p := range m.ScriptPrompts.ScriptPrompts {
+		promptCategories[p.PromptCategoryKey] = true
+	}
+
+	for _, s := range m.SourceToScriptCategoryCollection.Sources {
+		if s.SourceName == "" {
+			errs = append(errs, errors.New("source registered without sourceName"))
+			continue
+		}
+		if _, exists := registry[s.SourceName]; exists {
+			errs = append(errs, fmt.Errorf("source %s registered more than once", s.SourceName))
+			continue
+		}
+
+		registration, ok := driverTypes[s.DriverType]
+		if !ok {
+			errs = append(errs, fmt.Errorf("source %s has unknown driverType: %s", s.SourceName, s.DriverType))
+			continue
+		}
+		if s.RequestSchema != registration.requestSchema {
+			errs = append(errs, fmt.Errorf("source %s declares requestSchema %s but driverType %s decodes %s",
+				s.SourceName, s.RequestSchema, s.DriverType, registration.requestSchema))
+		}
+
+		if s.Route != "" {
+			if !strings.HasPrefix(s.Route, "/") {
+				errs = append(errs, fmt.Errorf("source %s route must start with '/': %s", s.SourceName, s.Route))
+			}
+			if other, exists := routes[s.Route]; exists {
+				errs = append(errs, fmt.Errorf("sources %s and %s share route %s", other, s.SourceName, s.Route))
+			}
+			routes[s.Route] = s.SourceName
+		}
+
+		if s.SimilarityThreshold < 0 || s.SimilarityThreshold > 1 {
+			errs = append(errs, fmt.Errorf("source %s similarityThreshold must be within [0, 1]: %f", s.SourceName, s.SimilarityThreshold))
+		}
+
+		if len(s.ScriptCategories) == 0 {
+			errs = append(errs, fmt.Errorf("source %s has no scriptCategories", s.SourceName))
+		}
+		for _, c := range s.ScriptCategories {
+			if !promptCategories[c.CategoryKey] {
+				errs = append(errs, fmt.Errorf("source %s references categoryKey without a script prompt: %s", s.SourceName, c.CategoryKey))
+			}
+		}
+
+		if s.DriverType == manifest.DRIVER_TYPE_REACTION {
+			if _, ok := m.GetBatchPolicy(s.SourceName); !ok {
+				errs = append(errs, fmt.Errorf("batched source %s has no batch policy", s.SourceName))
+			}
+		}
+		registry[s.SourceName] = registration
+	}
+
+	for _, p := range m.BatchPolicies.Policies {
+		s, ok := m.GetSource(p.SourceName)
+		if !ok || s.DriverType != manifest.DRIVER_TYPE_REACTION {
+			errs = append(errs, fmt.Errorf("batch policy %s does not match a registered reaction source", p.SourceName))
+		}
+	}
+	for _, f := range m.FeedSources.Feeds {
+		s, ok := m.GetSource(f.SourceName)
+		if !ok || s.DriverType != manifest.DRIVER_TYPE_FEED {
+			errs = append(errs, fmt.Errorf("feed %s does not match a registered feed source", f.SourceName))
+		}
+	}
+
+	errs = append(errs, validateAdmissionPolicies(m)...)
+
+	return registry, errors.Join(errs...)
+}
+
+func validateAdmissionPolicies(m *manifest.ManifestLoader) []error {
+	errs := []error{}
+	admission := m.AdmissionPolicies
+	if admission.MaxInFlight <= 0 {
+		errs = append(errs, errors.New("admission maxInFlight must be positive"))
+	}
+	for _, l := range admission.Lanes {
+		if l.GlobalCeilingPercent <= 0 || l.GlobalCeilingPercent > 100 {
+			errs = append(errs, fmt.Errorf("admission lane %s globalCeilingPercent must be within (0, 100]: %d", l.Lane, l.GlobalCeilingPercent))
+		}
+	}
+	for _, lane := range []string{manifest.LANE_PRIORITY, manifest.LANE_STANDARD, manifest.LANE_BULK} {
+		if _, ok := m.GetAdmissionLane(lane); !ok {
+			errs = append(errs, fmt.Errorf("admission lane %s is not declared", lane))
+		}
+	}
+
+	policies := append([]manifest.AdmissionPolicy{admission.DefaultPolicy}, admission.Policies...)
+	for i, p := range policies {
+		name := p.SourceName
+		if i == 0 {
+			name = "defaultPolicy"
+		} else if _, ok := m.GetSource(p.SourceName); !ok {
+			errs = append(errs, fmt.Errorf("admission policy %s does not match a registered source", p.SourceName))
+		}
+		if _, ok := m.GetAdmissionLane(p.Lane); !ok {
+			errs = append(errs, fmt.Errorf("admission policy %s has unknown lane: %s", name, p.Lane))
+		}
+		if p.RatePerMinute <= 0 || p.Burst < 1 || p.MaxInFlight < 1 {
+			errs = append(errs, fmt.Errorf("admission policy %s requires positive ratePerMinute, burst, and maxInFlight", name))
+		}
+	}
+	return errs
 }
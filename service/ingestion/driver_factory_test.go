@@ -0,0 +1,71 @@
+package ingestion
+
+import (
+	"testing"
+
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func testManifest(sources ...manifest.Source) *manifest.ManifestLoader {
+	return &manifest.ManifestLoader{
+		ScriptPrompts: manifest.ScriptPromptCollection{
+			ScriptPrompts: []manifest.Prompt{{PromptCategoryKey: "Blog.Default"}},
+		},
+		SourceToScriptCategoryCollection: manifest.SourceCollection{Sources: sources},
+		AdmissionPolicies: manifest.AdmissionPolicyCollection{
+			MaxInFlight: 10,
+			Lanes: []manifest.AdmissionLane{
+				{Lane: manifest.LANE_PRIORITY, GlobalCeilingPercent: 100},
+				{Lane: manifest.LANE_STANDARD, GlobalCeilingPercent: 80},
+				{Lane: manifest.LANE_BULK, GlobalCeilingPercent: 50},
+			},
+			DefaultPolicy: manifest.AdmissionPolicy{Lane: manifest.LANE_STANDARD, RatePerMinute: 1, Burst: 1, MaxInFlight: 1},
+		},
+	}
+}
+
+func testSource(name string, route string, driverType string, schema string) manifest.Source {
+	return manifest.Source{
+		SourceName:       name,
+		Route:            route,
+		DriverType:       driverType,
+		RequestSchema:    schema,
+		ScriptCategories: []manifest.ScriptCategory{{CategoryKey: "Blog.Default"}},
+	}
+}
+
+func TestBuildSourceRegistry(t *testing.T) {
+	m := testManifest(
+		testSource("v1/source/prompt", "/v1/source/prompt", manifest.DRIVER_TYPE_PROMPT, "CustomPromptRequest"),
+		testSource("WorkflowIntegTest", "", manifest.DRIVER_TYPE_BLOG, "BlogRequest"),
+	)
+	registry, err := buildSourceRegistry(m)
+	assert.Nil(t, err, "expected consistent registry")
+	assert.Equal(t, 2, len(registry))
+}
+
+func TestBuildSourceRegistryInconsistencies(t *testing.T) {
+	cases := map[string]*manifest.ManifestLoader{
+		"unknown driver type": testManifest(testSource("a", "/a", "unknown", "BlogRequest")),
+		"schema mismatch":     testManifest(testSource("a", "/a", manifest.DRIVER_TYPE_BLOG, "ReactionRequest")),
+		"duplicate route": testManifest(
+			testSource("a", "/a", manifest.DRIVER_TYPE_BLOG, "BlogRequest"),
+			testSource("b", "/a", manifest.DRIVER_TYPE_BLOG, "BlogRequest")),
+		"missing batch policy": testManifest(testSource("r", "/r", manifest.DRIVER_TYPE_REACTION, "ReactionRequest")),
+	}
+	missingCategory := testSource("a", "/a", manifest.DRIVER_TYPE_BLOG, "BlogRequest")
+	missingCategory.ScriptCategories = []manifest.ScriptCategory{{CategoryKey: "Blog.Missing"}}
+	cases["missing script prompt"] = testManifest(missingCategory)
+	unknownLane := testManifest(testSource("a", "/a", manifest.DRIVER_TYPE_BLOG, "BlogRequest"))
+	unknownLane.AdmissionPolicies.Policies = []manifest.AdmissionPolicy{{SourceName: "a", Lane: "express", RatePerMinute: 1, Burst: 1, MaxInFlight: 1}}
+	cases["unknown admission lane"] = unknownLane
+	unregisteredPolicy := testManifest(testSource("a", "/a", manifest.DRIVER_TYPE_BLOG, "BlogRequest"))
+	unregisteredPolicy.AdmissionPolicies.Policies = []manifest.AdmissionPolicy{{SourceName: "b", Lane: manifest.LANE_BULK, RatePerMinute: 1, Burst: 1, MaxInFlight: 1}}
+	cases["unregistered admission policy"] = unregisteredPolicy
+
+	for name, m := range cases {
+		_, err := buildSourceRegistry(m)
+		assert.NotNil(t, err, "expected registry error for: "+name)
+	}
+}
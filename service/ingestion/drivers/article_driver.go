@@ -0,0 +1,87 @@
+package drivers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+)
+
+const maxArticleUrls = 5
+
+// Builds one ledger from the readable text of one or more fetched article pages.
+type ArticleDriver struct {
+	PayloadIO io.ReadCloser
+	Source    string
+	Fetcher   PageFetcher
+}
+
+func NewArticleDriver(payloadIO io.ReadCloser, source string, fetcher PageFetcher) Driver {
+	return &ArticleDriver{PayloadIO: payloadIO, Source: source, Fetcher: fetcher}
+}
+
+func (d ArticleDriver) WithMedia(payloadIO io.ReadCloser) error {
+	return nil
+}
+
+func (d ArticleDriver) IsReady() bool {
+	return true
+}
+
+func (d ArticleDriver) BuildEventPayload() (tables.Ledger, error) {
+	rawEvent, err := d.decode(d.PayloadIO)
+	if err != nil {
+		log.Printf("error decoding raw event payload: %s", err)
+		return tables.Ledger{}, err
+	}
+	urls := []string{}
+	for _, u := range rawEvent.Urls {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 {
+		return tables.Ledger{}, errors.New("article request has no urls")
+	}
+	if len(urls) > maxArticleUrls {
+		return tables.Ledger{}, fmt.Errorf("article request has more than %d urls", maxArticleUrls)
+	}
+
+	sections := []string{}
+	for _, u := range urls {
+		article, err := d.fetchArticle(u)
+		if err != nil {
+			log.Printf("error extracting article %s: %s", u, err)
+			return tables.Ledger{}, err
+		}
+		sections = append(sections, fmt.Sprintf("Title:\n%s\n\nUrl:\n%s\n\nText:\n%s", article.Title, u, article.Text))
+	}
+
+	ledgerItem := newLedgerFromText(rawEvent.TargetLanguage, strings.Join(sections, "\n\n---\n\n"), d.Source)
+	ledgerItem.TriggerEventWebsiteUrls = strings.Join(urls, ",")
+	return ledgerItem, nil
+}
+
+func (d ArticleDriver) fetchArticle(pageUrl string) (ReadableArticle, error) {
+	page, err := d.Fetcher.Fetch(pageUrl)
+	if err != nil {
+		return ReadableArticle{}, err
+	}
+	defer page.Close()
+	return ExtractArticle(page)
+}
+
+func (d ArticleDriver) decode(payloadIO io.ReadCloser) (models_v1.ArticleRequest, error) {
+	decoder := json.NewDecoder(payloadIO)
+	var payload models_v1.ArticleRequest
+	err := decoder.Decode(&payload)
+	if err != nil {
+		return payload, err
+	}
+	return payload, err
+}
@@ -0,0 +1,118 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testArticlePage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Site | Rivers Rise</title>
+  <meta property="og:title" content="Rivers Rise After Storm">
+  <script>trackVisitor();</script>
+  <style>p { color: red; }</style>
+</head>
+<body>
+  <nav><a href="/">Home</a><a href="/news">News</a></nav>
+  <div class="ad-slot">Buy one get one free today only!</div>
+  <div id="content">
+    <h1>Rivers Rise After Storm</h1>
+    <p>Heavy rain over the weekend pushed the river above flood stage in three counties.</p>
+    <div class="share-buttons">Share on social media</div>
+    <p>Officials opened shelters and asked residents near the banks to move to higher ground.</p>
+    <p>By Staff</p>
+  </div>
+  <aside><p>Related: ten photos of last year's floods you need to see.</p></aside>
+  <footer><p>Copyright 2024 Example News. All rights reserved worldwide.</p></footer>
+</body>
+</html>`
+
+func TestExtractArticle(t *testing.T) {
+	article, err := ExtractArticle(strings.NewReader(testArticlePage))
+	assert.Nil(t, err)
+	assert.Equal(t, "Rivers Rise After Storm", article.Title)
+	assert.Equal(t, "Rivers Rise After Storm\n\n"+
+		"Heavy rain over the weekend pushed the river above flood stage in three counties.\n\n"+
+		"Officials opened shelters and asked residents near the banks to move to higher ground.\n\n"+
+		"By Staff", article.Text)
+
+	for _, dropped := range []string{"trackVisitor", "color", "Home", "Buy one", "Share", "Related", "Copyright"} {
+		assert.NotContains(t, article.Text, dropped)
+	}
+}
+
+func TestExtractArticlePrefersArticleElement(t *testing.T) {
+	page := `<html><head><title>Page Title</title></head><body>
+		<div><p>A long unrelated paragraph in a teaser block that is not the story itself at all.</p></div>
+		<article><p>The story.</p></article></body></html>`
+	article, err := ExtractArticle(strings.NewReader(page))
+	assert.Nil(t, err)
+	assert.Equal(t, "Page Title", article.Title)
+	assert.Equal(t, "The story.", article.Text)
+
+	_, err = ExtractArticle(strings.NewReader(`<html><body><nav>Only navigation</nav></body></html>`))
+	assert.NotNil(t, err, "expected error without readable text")
+}
+
+func TestArticleDriver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/story":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, testArticlePage)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	fetcher := HttpPageFetcher{Client: server.Client()} // The test server is on loopback.
+
+	payload := fmt.Sprintf(`{"targetLanguage": "en", "urls": ["%s/story"]}`, server.URL)
+	driver := NewArticleDriver(io.NopCloser(strings.NewReader(payload)), "v1/source/article", fetcher)
+	ledger, err := driver.BuildEventPayload()
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/story", ledger.TriggerEventWebsiteUrls)
+	assert.Contains(t, ledger.TriggerEventPayload, "Title:\nRivers Rise After Storm")
+	assert.Contains(t, ledger.TriggerEventPayload, "Officials opened shelters")
+	assert.Equal(t, "v1/source/article", ledger.TriggerEventSource)
+
+	for _, path := range []string{"/missing", "/image"} {
+		payload = fmt.Sprintf(`{"urls": ["%s%s"]}`, server.URL, path)
+		driver = NewArticleDriver(io.NopCloser(strings.NewReader(payload)), "v1/source/article", fetcher)
+		_, err = driver.BuildEventPayload()
+		assert.NotNil(t, err, "expected fetch error for: "+path)
+	}
+
+	driver = NewArticleDriver(io.NopCloser(strings.NewReader(`{"urls": ["file:///etc/passwd"]}`)), "v1/source/article", fetcher)
+	_, err = driver.BuildEventPayload()
+	assert.NotNil(t, err, "expected non-http url to be rejected")
+}
+
+func TestHttpPageFetcherRejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, testArticlePage)
+	}))
+	defer server.Close()
+
+	_, err := NewHttpPageFetcher(5 * time.Second).Fetch(server.URL)
+	assert.True(t, errors.Is(err, ErrPrivateAddress), "expected loopback page to be rejected")
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1",
+		"0.0.0.0", "::1", "fe80::1", "fd00:ec2::254", "::ffff:127.0.0.1"} {
+		assert.False(t, isPublicAddress(net.ParseIP(ip)), "expected private address: "+ip)
+	}
+	assert.True(t, isPublicAddress(net.ParseIP("93.184.216.34")))
+	assert.True(t, isPublicAddress(net.ParseIP("2606:2800:220:1::1")))
+}
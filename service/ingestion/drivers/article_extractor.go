@@ -0,0 +1,213 @@
+package drivers
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type ReadableArticle struct {
+	Title string
+	Text  string // Paragraphs separated by blank lines.
+}
+
+// Elements never part of the readable text.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Iframe: true, atom.Svg: true,
+	atom.Canvas: true, atom.Select: true, atom.Dialog: true,
+}
+
+// class/id tokens marking ads and page chrome.
+var boilerplateTokens = map[string]bool{
+	"ad": true, "ads": true, "advert": true, "advertisement": true, "sponsor": true, "sponsored": true,
+	"promo": true, "banner": true, "cookie": true, "cookies": true, "newsletter": true, "subscribe": true,
+	"share": true, "social": true, "related": true, "comments": true, "sidebar": true, "popup": true,
+	"modal": true, "nav": true, "menu": true, "breadcrumb": true, "breadcrumbs": true,
+}
+
+// Elements whose text forms one paragraph of the article.
+var textBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Blockquote: true, atom.Pre: true,
+}
+
+// Paragraphs shorter than this don't count towards content density, e.g. bylines and captions.
+const minScoredParagraphChars = 25
+
+// Extracts the title and main readable text of an html page.
+func ExtractArticle(page io.Reader) (ReadableArticle, error) {
+	doc, err := html.Parse(page)
+	if err != nil {
+		return ReadableArticle{}, err
+	}
+
+	article := ReadableArticle{Title: extractTitle(doc)}
+	pruneBoilerplate(doc)
+	root := findContentRoot(doc)
+	if root == nil {
+		return article, errors.New("page has no body")
+	}
+
+	paragraphs := []string{}
+	collectTextBlocks(root, &paragraphs)
+	if len(paragraphs) == 0 {
+		if text := nodeText(root); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	article.Text = strings.Join(paragraphs, "\n\n")
+	if article.Text == "" {
+		return article, errors.New("page has no readable text")
+	}
+	return article, nil
+}
+
+func extractTitle(doc *html.Node) string {
+	var metaTitle, pageTitle, heading string
+	walk(doc, func(n *html.Node) bool {
+		switch {
+		case n.DataAtom == atom.Meta && metaTitle == "":
+			if attr(n, "property") == "og:title" || attr(n, "name") == "twitter:title" {
+				metaTitle = collapseSpace(attr(n, "content"))
+			}
+		case n.DataAtom == atom.Title && pageTitle == "":
+			pageTitle = nodeText(n)
+		case n.DataAtom == atom.H1 && heading == "":
+			heading = nodeText(n)
+		}
+		return true
+	})
+	for _, title := range []string{metaTitle, pageTitle, heading} {
+		if title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+func pruneBoilerplate(doc *html.Node) {
+	pruned := []*html.Node{}
+	walk(doc, func(n *html.Node) bool {
+		if n.Type == html.CommentNode || (n.Type == html.ElementNode && isBoilerplate(n)) {
+			pruned = append(pruned, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range pruned {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if droppedElements[n.DataAtom] {
+		return true
+	}
+	if attr(n, "aria-hidden") == "true" || attr(n, "role") == "navigation" {
+		return true
+	}
+	tokens := strings.FieldsFunc(strings.ToLower(attr(n, "class")+" "+attr(n, "id")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, t := range tokens {
+		if boilerplateTokens[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefers semantic article markup, falling back to the element holding the most paragraph text.
+func findContentRoot(doc *html.Node) *html.Node {
+	var article, main, body *html.Node
+	scores := map[*html.Node]int{}
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Article:
+			if article == nil {
+				article = n
+			}
+		case atom.Main:
+			if main == nil {
+				main = n
+			}
+		case atom.Body:
+			body = n
+		case atom.P:
+			if length := len(nodeText(n)); length >= minScoredParagraphChars && n.Parent != nil {
+				scores[n.Parent] += length
+			}
+		}
+		return true
+	})
+	if article != nil {
+		return article
+	}
+	if main != nil {
+		return main
+	}
+
+	var best *html.Node
+	for n, score := range scores {
+		if best == nil || score > scores[best] {
+			best = n
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return body
+}
+
+func collectTextBlocks(n *html.Node, paragraphs *[]string) {
+	walk(n, func(c *html.Node) bool {
+		if !textBlocks[c.DataAtom] {
+			return true
+		}
+		if text := nodeText(c); text != "" {
+			*paragraphs = append(*paragraphs, text)
+		}
+		return false
+	})
+}
+
+// Visits n and its descendants depth-first; visit returns false to skip a node's children.
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, visit)
+	}
+}
+
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+			sb.WriteString(" ")
+		}
+		return true
+	})
+	return collapseSpace(sb.String())
+}
+
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
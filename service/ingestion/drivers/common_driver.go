@@ -16,6 +16,17 @@ type Driver interface {
 	WithMedia(io.ReadCloser) error
 }
 
+// Implemented by drivers that store media before the ledger is saved; Promote runs before the ledger is created or
+// parked, so workflows find the media of its urls.
+type StagedMediaDriver interface {
+	Promote() error
+}
+
+// Implemented by drivers that moderate each item as it is buffered; their batches aren't moderated again.
+type PreModeratedDriver interface {
+	IsPreModerated() bool
+}
+
 func newLedgerFromText(targetLanguage string, text string, source string) tables.Ledger {
 	return tables.Ledger{
 		LedgerID:                   uuid.New().String(),
@@ -23,7 +34,7 @@ func newLedgerFromText(targetLanguage string, text string, source string) tables
 		TriggerEventPayload:        text,
 		TriggerEventSource:         source,
 		TriggerEventContentHash:    getMD5Hash(text),
-		TriggerEventTargetLanguage: targetLanguage, // TODO: Expand ISO Language Code to canonical name.
+		TriggerEventTargetLanguage: targetLanguage, // Normalized, or detected when empty, by ingestion before saving.
 	}
 }
 
@@ -34,7 +45,7 @@ func newLedgerFromUrls(targetLanguage string, urls []string, source string) tabl
 		TriggerEventMediaUrls:      strings.Join(urls, ","),
 		TriggerEventSource:         source,
 		TriggerEventContentHash:    getMD5Hash(strings.Join(urls, ",")),
-		TriggerEventTargetLanguage: targetLanguage, // TODO: Expand ISO Language Code to canonical name.
+		TriggerEventTargetLanguage: targetLanguage, // Normalized, or detected when empty, by ingestion before saving.
 	}
 }
 
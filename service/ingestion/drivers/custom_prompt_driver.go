@@ -32,7 +32,7 @@ func (d CustomPromptDriver) BuildEventPayload() (tables.Ledger, error) {
 		log.Printf("error decoding raw event payload: %s", err)
 	}
 
-	return newLedgerFromText("EN", rawEvent.PromptText, d.Source), err
+	return newLedgerFromText(rawEvent.TargetLanguage, rawEvent.PromptText, d.Source), err
 }
 
 func (d CustomPromptDriver) decode(payloadIO io.ReadCloser) (models_v1.CustomPromptRequest, error) {
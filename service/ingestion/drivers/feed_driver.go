@@ -0,0 +1,68 @@
+package drivers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+)
+
+// Builds one ledger per RSS/Atom item; items are submitted by the feed poller daemon.
+type FeedDriver struct {
+	PayloadIO io.ReadCloser
+	Source    string
+}
+
+func NewFeedDriver(payloadIO io.ReadCloser, source string) Driver {
+	return &FeedDriver{PayloadIO: payloadIO, Source: source}
+}
+
+func (d FeedDriver) WithMedia(payloadIO io.ReadCloser) error {
+	return nil
+}
+
+func (d FeedDriver) IsReady() bool {
+	return true
+}
+
+func (d FeedDriver) BuildEventPayload() (tables.Ledger, error) {
+	rawEvent, err := d.decode(d.PayloadIO)
+	if err != nil {
+		log.Printf("error decoding raw event payload: %s", err)
+	}
+	payload := fmt.Sprintf(`
+		Title:
+		%s
+
+		Summary:
+		%s
+		`, rawEvent.Title, rawEvent.Summary)
+	ledgerItem := newLedgerFromText(rawEvent.TargetLanguage, payload, d.Source)
+	ledgerItem.TriggerEventWebsiteUrls = rawEvent.Link
+	// Dedupe on item identity rather than text; publishers frequently re-edit summaries.
+	ledgerItem.TriggerEventContentHash = getMD5Hash(d.Source + "|" + d.itemIdentity(rawEvent))
+	return ledgerItem, err
+}
+
+func (d FeedDriver) itemIdentity(rawEvent models_v1.FeedItemRequest) string {
+	if rawEvent.Guid != "" {
+		return rawEvent.Guid
+	}
+	if rawEvent.Link != "" {
+		return rawEvent.Link
+	}
+	return rawEvent.Title + rawEvent.Summary
+}
+
+func (d FeedDriver) decode(payloadIO io.ReadCloser) (models_v1.FeedItemRequest, error) {
+	decoder := json.NewDecoder(payloadIO)
+	var payload models_v1.FeedItemRequest
+	err := decoder.Decode(&payload)
+	if err != nil {
+		return payload, err
+	}
+	return payload, err
+}
@@ -0,0 +1,216 @@
+package drivers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+type FeedItem struct {
+	Guid        string
+	Title       string
+	Link        string
+	Summary     string
+	PublishedAt time.Time // Zero when the feed omits or mangles the date.
+}
+
+// RSS 2.0: <rss><channel><item>...
+type rssDocument struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Guid           string `xml:"guid"`
+	Title          string `xml:"title"`
+	Link           string `xml:"link"`
+	Description    string `xml:"description"`
+	ContentEncoded string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate        string `xml:"pubDate"`
+}
+
+// Atom: <feed><entry>...
+type atomDocument struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Id        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02",
+}
+
+// ParseFeed detects RSS 2.0 or Atom from the root element and returns the items in document order.
+func ParseFeed(feedIO io.Reader) ([]FeedItem, error) {
+	raw, err := io.ReadAll(feedIO)
+	if err != nil {
+		return []FeedItem{}, err
+	}
+
+	rootName, err := feedRootName(raw)
+	if err != nil {
+		return []FeedItem{}, err
+	}
+
+	switch rootName {
+	case "rss":
+		return parseRss(raw)
+	case "feed":
+		return parseAtom(raw)
+	}
+	return []FeedItem{}, errors.New("unsupported feed format, expected rss or atom root element: " + rootName)
+}
+
+func feedRootName(raw []byte) (string, error) {
+	decoder := newFeedDecoder(raw)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func newFeedDecoder(raw []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	return decoder
+}
+
+func parseRss(raw []byte) ([]FeedItem, error) {
+	var doc rssDocument
+	err := newFeedDecoder(raw).Decode(&doc)
+	if err != nil {
+		return []FeedItem{}, err
+	}
+
+	result := []FeedItem{}
+	for _, i := range doc.Channel.Items {
+		summary := i.ContentEncoded
+		if strings.TrimSpace(summary) == "" {
+			summary = i.Description
+		}
+		result = append(result, FeedItem{
+			Guid:        strings.TrimSpace(i.Guid),
+			Title:       htmlToText(i.Title),
+			Link:        strings.TrimSpace(i.Link),
+			Summary:     htmlToText(summary),
+			PublishedAt: parseFeedDate(i.PubDate),
+		})
+	}
+	return result, nil
+}
+
+func parseAtom(raw []byte) ([]FeedItem, error) {
+	var doc atomDocument
+	err := newFeedDecoder(raw).Decode(&doc)
+	if err != nil {
+		return []FeedItem{}, err
+	}
+
+	result := []FeedItem{}
+	for _, e := range doc.Entries {
+		summary := e.Content
+		if strings.TrimSpace(summary) == "" {
+			summary = e.Summary
+		}
+		published := e.Published
+		if published == "" {
+			published = e.Updated
+		}
+		result = append(result, FeedItem{
+			Guid:        strings.TrimSpace(e.Id),
+			Title:       htmlToText(e.Title),
+			Link:        atomAlternateLink(e.Links),
+			Summary:     htmlToText(summary),
+			PublishedAt: parseFeedDate(published),
+		})
+	}
+	return result, nil
+}
+
+func atomAlternateLink(links []atomLink) string {
+	for _, l := range links {
+		// Rel defaults to alternate when omitted.
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Feed summaries are commonly escaped HTML; collapse them to plain text for prompting.
+func htmlToText(fragment string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	var sb strings.Builder
+	skipDepth := 0
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(sb.String()), " ")
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "script" || string(name) == "style" {
+				skipDepth++
+			}
+			sb.WriteString(" ")
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if (string(name) == "script" || string(name) == "style") && skipDepth > 0 {
+				skipDepth--
+			}
+			sb.WriteString(" ")
+		case html.TextToken:
+			if skipDepth == 0 {
+				sb.Write(tokenizer.Text())
+			}
+		}
+	}
+}
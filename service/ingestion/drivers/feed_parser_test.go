@@ -0,0 +1,73 @@
+package drivers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleRss = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Example News</title>
+    <item>
+      <guid>item-1</guid>
+      <title>City council &amp; the budget</title>
+      <link>https://example.com/news/1</link>
+      <description>&lt;p&gt;The council &lt;b&gt;approved&lt;/b&gt; the budget.&lt;/p&gt;</description>
+      <pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate>
+    </item>
+    <item>
+      <title>Second story</title>
+      <link>https://example.com/news/2</link>
+      <description>Plain summary.</description>
+      <content:encoded><![CDATA[<p>Full body text.</p><script>track()</script>]]></content:encoded>
+    </item>
+  </channel>
+</rss>`
+
+const sampleAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Blog</title>
+  <entry>
+    <id>urn:uuid:1225c695</id>
+    <title>Atom entry</title>
+    <link rel="self" href="https://example.com/self/1"/>
+    <link href="https://example.com/posts/1"/>
+    <summary>Some summary text.</summary>
+    <updated>2003-12-13T18:30:02Z</updated>
+  </entry>
+</feed>`
+
+func TestParseRssFeed(t *testing.T) {
+	items, err := ParseFeed(strings.NewReader(sampleRss))
+	assert.Nil(t, err, "expected rss to parse")
+	assert.Equal(t, 2, len(items), "expected two rss items")
+
+	assert.Equal(t, "item-1", items[0].Guid)
+	assert.Equal(t, "City council & the budget", items[0].Title)
+	assert.Equal(t, "https://example.com/news/1", items[0].Link)
+	assert.Equal(t, "The council approved the budget.", items[0].Summary)
+	assert.Equal(t, int64(1136239445), items[0].PublishedAt.Unix())
+
+	assert.Equal(t, "Full body text.", items[1].Summary, "expected content:encoded to win over description")
+	assert.True(t, items[1].PublishedAt.IsZero(), "expected zero time when pubDate is missing")
+}
+
+func TestParseAtomFeed(t *testing.T) {
+	items, err := ParseFeed(strings.NewReader(sampleAtom))
+	assert.Nil(t, err, "expected atom to parse")
+	assert.Equal(t, 1, len(items), "expected one atom entry")
+
+	assert.Equal(t, "urn:uuid:1225c695", items[0].Guid)
+	assert.Equal(t, "https://example.com/posts/1", items[0].Link, "expected alternate link")
+	assert.Equal(t, "Some summary text.", items[0].Summary)
+	assert.Equal(t, time.Date(2003, 12, 13, 18, 30, 2, 0, time.UTC), items[0].PublishedAt.UTC())
+}
+
+func TestParseUnsupportedFeed(t *testing.T) {
+	_, err := ParseFeed(strings.NewReader(`<html><body>not a feed</body></html>`))
+	assert.NotNil(t, err, "expected unsupported root element error")
+}
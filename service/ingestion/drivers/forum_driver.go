@@ -13,10 +13,11 @@ import (
 type ForumDriver struct {
 	PayloadIO io.ReadCloser
 	Source    string
+	Budget    ForumBudget
 }
 
-func NewForumDriver(payloadIO io.ReadCloser, source string) Driver {
-	return &ForumDriver{PayloadIO: payloadIO, Source: source}
+func NewForumDriver(payloadIO io.ReadCloser, source string, budget ForumBudget) Driver {
+	return &ForumDriver{PayloadIO: payloadIO, Source: source, Budget: budget}
 }
 
 func (d ForumDriver) WithMedia(payloadIO io.ReadCloser) error {
@@ -32,6 +33,10 @@ func (d ForumDriver) BuildEventPayload() (tables.Ledger, error) {
 	if err != nil {
 		log.Printf("error decoding raw event payload: %s", err)
 	}
+	if rawEvent.Thread != nil {
+		payload := BuildForumThreadPayload(*rawEvent.Thread, d.Budget)
+		return newLedgerFromText(rawEvent.TargetLanguage, payload, d.Source), err
+	}
 	payload := fmt.Sprintf(`
 		Main Post:
 		%s
@@ -0,0 +1,148 @@
+package drivers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+)
+
+// Limits on the comments kept from a forum thread; roughly 4 characters per LLM token.
+type ForumBudget struct {
+	MaxCommentChars int
+	MaxComments     int
+}
+
+var deletedCommentBodies = map[string]bool{"": true, "[deleted]": true, "[removed]": true}
+var droppedAuthors = map[string]bool{"automoderator": true, "[deleted]": true, "remindmebot": true, "sneakpeekbot": true,
+	"repostsleuthbot": true, "savevideo": true, "haikusbot": true}
+
+// Bot account naming conventions; a bare "bot" suffix would also drop users such as "abbot" or "talbot".
+var botAuthorSuffixes = []string{"[bot]", "-bot", "_bot"}
+
+// Renders a thread as the forum payload, keeping the highest ranked comments within budget.
+// Authors are replaced by stable labels, "OP" and "Commenter N", so usernames are never narrated.
+// The output only depends on the thread, so identical threads dedupe.
+func BuildForumThreadPayload(thread models_v1.ForumThread, budget ForumBudget) string {
+	selected := selectForumComments(thread, budget)
+
+	labels := map[string]string{}
+	label := func(author string) string {
+		if author != "" && author == thread.Author {
+			return "OP"
+		}
+		if _, ok := labels[author]; !ok {
+			labels[author] = fmt.Sprintf("Commenter %d", len(labels)+1)
+		}
+		return labels[author]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Main Post:\n")
+	if title := strings.TrimSpace(thread.Title); title != "" {
+		sb.WriteString(title + "\n\n")
+	}
+	sb.WriteString(strings.TrimSpace(thread.Body) + "\n\nPost Comments:\n")
+	for _, c := range selected {
+		sb.WriteString(fmt.Sprintf("%s- %s (%+d): %s\n", strings.Repeat("  ", c.Depth), label(c.Author),
+			c.Score, collapseSpace(c.Body)))
+	}
+	return sb.String()
+}
+
+// Selected comments in thread order: ranked top-level comments, each followed by its ranked replies.
+func selectForumComments(thread models_v1.ForumThread, budget ForumBudget) []models_v1.ForumComment {
+	ids := map[string]bool{}
+	candidates := []models_v1.ForumComment{}
+	for _, c := range thread.Comments {
+		if isDroppedComment(c) {
+			continue
+		}
+		ids[c.ID] = true
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return rankBefore(candidates[i], candidates[j])
+	})
+
+	// Replies are only kept under a kept parent; repeat until no more comments fit.
+	kept := map[string]bool{}
+	keptOrder := []models_v1.ForumComment{}
+	remaining := budget.MaxCommentChars
+	for added := true; added; {
+		added = false
+		for _, c := range candidates {
+			if kept[c.ID] || len(keptOrder) >= budget.MaxComments {
+				continue
+			}
+			isReply := c.ParentID != "" && ids[c.ParentID]
+			if isReply && !kept[c.ParentID] {
+				continue
+			}
+			cost := len(collapseSpace(c.Body))
+			if cost > remaining {
+				continue
+			}
+			remaining -= cost
+			kept[c.ID] = true
+			keptOrder = append(keptOrder, c)
+			added = true
+		}
+	}
+	return threadOrder(keptOrder, ids)
+}
+
+// Higher score first; among equal scores shallower comments first, so replies don't crowd out top-level comments.
+func rankBefore(a models_v1.ForumComment, b models_v1.ForumComment) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	if a.CreatedAt != b.CreatedAt {
+		return a.CreatedAt < b.CreatedAt
+	}
+	return a.ID < b.ID
+}
+
+func threadOrder(ranked []models_v1.ForumComment, ids map[string]bool) []models_v1.ForumComment {
+	children := map[string][]models_v1.ForumComment{}
+	roots := []models_v1.ForumComment{}
+	for _, c := range ranked {
+		if c.ParentID != "" && ids[c.ParentID] {
+			children[c.ParentID] = append(children[c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+	result := []models_v1.ForumComment{}
+	var visit func(c models_v1.ForumComment)
+	visit = func(c models_v1.ForumComment) {
+		result = append(result, c)
+		for _, child := range children[c.ID] {
+			visit(child)
+		}
+	}
+	for _, r := range roots {
+		visit(r)
+	}
+	return result
+}
+
+func isDroppedComment(c models_v1.ForumComment) bool {
+	if deletedCommentBodies[strings.ToLower(strings.TrimSpace(c.Body))] || c.IsBot {
+		return true
+	}
+	author := strings.ToLower(c.Author)
+	if droppedAuthors[author] {
+		return true
+	}
+	for _, suffix := range botAuthorSuffixes {
+		if strings.HasSuffix(author, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package drivers
+
+import (
+	"strings"
+	"testing"
+
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func testForumThread() models_v1.ForumThread {
+	return models_v1.ForumThread{
+		Title:  "AITA for skipping my sister's wedding?",
+		Author: "op_user",
+		Body:   "She scheduled it on my graduation day.",
+		Comments: []models_v1.ForumComment{
+			{ID: "c1", Author: "alice", Body: "NTA, she picked the date.", Score: 120, CreatedAt: 10},
+			{ID: "c2", Author: "bob", Body: "YTA, weddings are once in a lifetime.", Score: 40, CreatedAt: 5},
+			{ID: "c3", ParentID: "c1", Author: "op_user", Body: "Thanks, I told her months ago.", Score: 90, Depth: 1, CreatedAt: 20},
+			{ID: "c4", Author: "AutoModerator", Body: "Please vote below.", Score: 500, CreatedAt: 1},
+			{ID: "c5", Author: "[deleted]", Body: "[deleted]", Score: 300, CreatedAt: 2},
+			{ID: "c6", ParentID: "c2", Author: "carol", Body: "Agreed with bob.", Score: 1, Depth: 1, CreatedAt: 30},
+			{ID: "c7", Author: "remindme-bot", Body: "I will remind you in 3 days.", Score: 200, CreatedAt: 3, IsBot: true},
+		},
+	}
+}
+
+func TestBuildForumThreadPayload(t *testing.T) {
+	payload := BuildForumThreadPayload(testForumThread(), ForumBudget{MaxCommentChars: 1000, MaxComments: 10})
+	expected := "Main Post:\nAITA for skipping my sister's wedding?\n\nShe scheduled it on my graduation day.\n\nPost Comments:\n" +
+		"- Commenter 1 (+120): NTA, she picked the date.\n" +
+		"  - OP (+90): Thanks, I told her months ago.\n" +
+		"- Commenter 2 (+40): YTA, weddings are once in a lifetime.\n" +
+		"  - Commenter 3 (+1): Agreed with bob.\n"
+	assert.Equal(t, expected, payload)
+	assert.NotContains(t, payload, "alice")
+}
+
+func TestSelectForumCommentsBudget(t *testing.T) {
+	selected := selectForumComments(testForumThread(), ForumBudget{MaxCommentChars: 60, MaxComments: 10})
+	ids := []string{}
+	for _, c := range selected {
+		ids = append(ids, c.ID)
+	}
+	// c2 no longer fits after c1 and its reply, so its reply c6 is dropped too.
+	assert.Equal(t, []string{"c1", "c3"}, ids)
+
+	selected = selectForumComments(testForumThread(), ForumBudget{MaxCommentChars: 1000, MaxComments: 2})
+	assert.Len(t, selected, 2)
+}
+
+func TestBuildForumThreadPayloadDeterministic(t *testing.T) {
+	thread := testForumThread()
+	first := BuildForumThreadPayload(thread, ForumBudget{MaxCommentChars: 1000, MaxComments: 10})
+	for i, j := 0, len(thread.Comments)-1; i < j; i, j = i+1, j-1 {
+		thread.Comments[i], thread.Comments[j] = thread.Comments[j], thread.Comments[i]
+	}
+	assert.Equal(t, first, BuildForumThreadPayload(thread, ForumBudget{MaxCommentChars: 1000, MaxComments: 10}))
+	assert.False(t, strings.Contains(first, "AutoModerator"))
+}
+
+func TestIsDroppedComment(t *testing.T) {
+	assert.True(t, isDroppedComment(models_v1.ForumComment{Author: "dependabot[bot]", Body: "Bump."}))
+	assert.True(t, isDroppedComment(models_v1.ForumComment{Author: "Summary-Bot", Body: "TL;DR"}))
+	assert.True(t, isDroppedComment(models_v1.ForumComment{Author: "RemindMeBot", Body: "I will remind you."}))
+	assert.False(t, isDroppedComment(models_v1.ForumComment{Author: "Talbot", Body: "NTA."}))
+	assert.False(t, isDroppedComment(models_v1.ForumComment{Author: "abbot", Body: "YTA."}))
+}
+
+func TestRankBefore(t *testing.T) {
+	top := models_v1.ForumComment{ID: "a", Score: -4}
+	reply := models_v1.ForumComment{ID: "b", Score: -4, Depth: 3}
+	deepReply := models_v1.ForumComment{ID: "c", Score: -2, Depth: 3}
+	assert.True(t, rankBefore(deepReply, top), "a higher score ranks first at any depth")
+	assert.True(t, rankBefore(top, reply), "equal scores rank the shallower comment first")
+	assert.False(t, rankBefore(reply, top))
+}
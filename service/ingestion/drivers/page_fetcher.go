@@ -0,0 +1,122 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Fetches article pages; swapped for a local test server in tests.
+type PageFetcher interface {
+	Fetch(pageUrl string) (io.ReadCloser, error)
+}
+
+const (
+	maxPageBytes     = 5 * 1024 * 1024
+	maxPageRedirects = 5
+)
+
+// Not reachable by page fetches: loopback, private, link-local (incl. the instance metadata service),
+// carrier-grade NAT, multicast, and unspecified addresses.
+var ErrPrivateAddress = errors.New("page address is not public")
+
+var sharedAddressSpace = net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+type HttpPageFetcher struct {
+	Client *http.Client
+}
+
+// Fetches public pages only; every dialed address, including those of redirects, is checked after resolution.
+func NewHttpPageFetcher(timeout time.Duration) HttpPageFetcher {
+	dialer := &net.Dialer{Timeout: timeout, Control: rejectPrivateAddress}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return HttpPageFetcher{Client: &http.Client{Timeout: timeout, Transport: transport, CheckRedirect: checkPageRedirect}}
+}
+
+func (f HttpPageFetcher) Fetch(pageUrl string) (io.ReadCloser, error) {
+	parsed, err := url.Parse(pageUrl)
+	if err != nil || !isHttpUrl(parsed) {
+		return nil, fmt.Errorf("not an http(s) url: %s", pageUrl)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, pageUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		log.Printf("error fetching page %s: %s", pageUrl, err)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("page %s returned status %d", pageUrl, resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("page %s is not html: %s", pageUrl, mediaType)
+	}
+	return limitedReadCloser{Reader: io.LimitReader(resp.Body, maxPageBytes), Closer: resp.Body}, nil
+}
+
+func isHttpUrl(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Rejects redirects to other schemes or to hosts resolving to private addresses; the dialer checks the address
+// actually connected to.
+func checkPageRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxPageRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxPageRedirects)
+	}
+	if !isHttpUrl(req.URL) {
+		return fmt.Errorf("redirect to a non http(s) url: %s", req.URL)
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(req.Context(), req.URL.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !isPublicAddress(ip.IP) {
+			return fmt.Errorf("%w: redirect to %s resolves to %s", ErrPrivateAddress, req.URL.Host, ip.IP)
+		}
+	}
+	return nil
+}
+
+func rejectPrivateAddress(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, address)
+	}
+	return nil
+}
+
+func isPublicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) ||
+		ip.To4() != nil && ip.To4()[0] == 0)
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
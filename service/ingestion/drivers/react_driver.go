@@ -2,78 +2,166 @@ package drivers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
-	"strings"
-	"sync"
+	"time"
 
+	dal "github.com/bezalel-media-core/v2/dal"
 	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
 	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/bezalel-media-core/v2/service/ingestion/moderation"
 )
 
+type ModerateFunc func(ledgerItem tables.Ledger) (moderation.Rejection, bool)
+
+// Returned by WithMedia for a reaction rejected by moderation, which is never buffered.
+type RejectedReactionError struct {
+	Ledger    tables.Ledger // Ledger of the single reaction that was moderated.
+	Rejection moderation.Rejection
+}
+
+func (e *RejectedReactionError) Error() string {
+	return fmt.Sprintf("reaction rejected by %s rules: %s", e.Rejection.Scope, e.Rejection.Reason)
+}
+
+// Reaction urls are buffered per-source in DynamoDB so batches survive restarts
+// and can be filled by any core instance. One ledger is created per flushed batch.
 type ReactDriver struct {
-	Source    string
-	List      ConcurrentList
-	MaxBuffer int
-	Mutex     sync.Mutex
+	Source   string
+	Policy   manifest.BatchPolicy
+	Moderate ModerateFunc // Applied per reaction before buffering, so one rejected reaction can't drop a batch.
+
+	buffered dal.ReactionBufferEntry // Buffer state as observed after this request's append.
+	claimed  dal.ReactionBufferEntry // Batch owned by this driver once IsReady wins the claim.
 }
 
-var mutex sync.Mutex
-var sourceToDriver = make(map[string]*ReactDriver)
+func NewReactDriver(source string, policy manifest.BatchPolicy, moderate ModerateFunc) Driver {
+	return &ReactDriver{Source: source, Policy: policy, Moderate: moderate}
+}
 
-// TODO: create multiple ReactDriver instances
-// Maintain six ReactDriver instances: short videos, long videos, short images, long images, short audio, long audio
-func NewReactDriver(source string) Driver {
-	mutex.Lock()
-	defer mutex.Unlock()
-	driverInst, ok := sourceToDriver[source]
-	if ok && driverInst != nil {
-		return driverInst
+// For flushing an already observed buffer without a new request, e.g. from the batch flush daemon.
+func NewReactDriverFromBuffer(source string, policy manifest.BatchPolicy, buffered dal.ReactionBufferEntry) Driver {
+	return &ReactDriver{Source: source, Policy: policy, buffered: buffered}
+}
+
+func (d *ReactDriver) WithMedia(payloadIO io.ReadCloser) error {
+	raw, err := io.ReadAll(payloadIO)
+	if err != nil {
+		log.Printf("error reading raw event payload: %s", err)
+		return err
 	}
-	maxBuffer := 2
-	if strings.Contains(source, "long") {
-		maxBuffer = 20
+	rawEvent, err := decodeReactionRequest(raw)
+	if err != nil {
+		return err
+	}
+	if d.Moderate != nil {
+		reaction := newLedgerFromUrls(rawEvent.TargetLanguage, []string{rawEvent.ContentUrl}, d.Source)
+		rejection, rejected := d.Moderate(reaction)
+		if rejected {
+			return &RejectedReactionError{Ledger: reaction, Rejection: rejection}
+		}
+	}
+
+	entry, err := dal.AppendReactionBuffer(d.Source, rawEvent.TargetLanguage, rawEvent.ContentUrl, int64(len(raw)))
+	if err != nil {
+		log.Printf("error appending reaction to buffer for source %s: %s", d.Source, err)
+		return err
 	}
-	driverNew := &ReactDriver{Source: source,
-		List: *NewConcurrentList(), MaxBuffer: maxBuffer, Mutex: sync.Mutex{}}
-	sourceToDriver[source] = driverNew
-	return driverNew
+	d.buffered = entry
+	return nil
 }
 
-func (d ReactDriver) WithMedia(payloadIO io.ReadCloser) error {
-	rawEvent, err := d.decode(payloadIO)
+func decodeReactionRequest(raw []byte) (models_v1.ReactionRequest, error) {
+	var rawEvent models_v1.ReactionRequest
+	err := json.Unmarshal(raw, &rawEvent)
 	if err != nil {
 		log.Printf("error decoding raw event payload: %s", err)
+		return rawEvent, err
 	}
-	d.List.Add(rawEvent)
-	return nil
+	if rawEvent.ContentUrl == "" {
+		return rawEvent, errors.New("reaction request is missing contentUrl")
+	}
+	return rawEvent, nil
+}
+
+// Ready only for the caller that claims a due buffer; concurrent callers observing
+// the same buffer lose the versioned claim and report not-ready.
+func (d *ReactDriver) IsReady() bool {
+	if !IsBatchFlushDue(d.buffered, d.Policy, time.Now()) {
+		return false
+	}
+
+	claimed, ok, err := dal.ClaimReactionBuffer(d.Source, d.buffered.BufferVersion)
+	if err != nil {
+		log.Printf("error claiming reaction buffer for source %s: %s", d.Source, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	d.claimed = claimed
+	return true
 }
 
-func (d ReactDriver) IsReady() bool {
-	return d.List.Size() >= d.MaxBuffer
+// Reactions are moderated as they are buffered; see WithMedia.
+func (d *ReactDriver) IsPreModerated() bool {
+	return true
 }
 
-func (d ReactDriver) BuildEventPayload() (tables.Ledger, error) {
-	d.Mutex.Lock()
-	defer d.Mutex.Unlock()
-	firstEntry, _ := d.List.Get(0)
-	request := firstEntry.(models_v1.ReactionRequest)
-	mediaUrls := []string{}
-	for i := 0; i < d.List.Size(); i++ {
-		entry, _ := d.List.Get(i)
-		requestEntry := entry.(models_v1.ReactionRequest)
-		mediaUrls = append(mediaUrls, requestEntry.ContentUrl)
+func (d *ReactDriver) BuildEventPayload() (tables.Ledger, error) {
+	if len(d.claimed.ContentUrls) == 0 {
+		return tables.Ledger{}, errors.New("no claimed reaction batch to build ledger from")
 	}
-	d.List.Flush()
-	return newLedgerFromUrls(request.TargetLanguage, mediaUrls, request.Source), nil
+	return newLedgerFromUrls(d.claimed.TargetLanguage, d.claimed.ContentUrls, d.Source), nil
+}
+
+// A non-empty batch is due once any enabled limit of the policy is reached.
+func IsBatchFlushDue(entry dal.ReactionBufferEntry, policy manifest.BatchPolicy, now time.Time) bool {
+	if len(entry.ContentUrls) == 0 {
+		return false
+	}
+	if policy.MaxItems > 0 && len(entry.ContentUrls) >= policy.MaxItems {
+		return true
+	}
+	if policy.MaxPayloadBytes > 0 && entry.PayloadBytes >= policy.MaxPayloadBytes {
+		return true
+	}
+	ageMilli := now.UnixMilli() - entry.FirstBufferedAtEpochMilli
+	if policy.MaxAgeSec > 0 && entry.FirstBufferedAtEpochMilli > 0 && ageMilli >= policy.MaxAgeSec*1000 {
+		return true
+	}
+	return false
+}
+
+// Builds the ledger of a single reaction without touching the buffer; for previews.
+type ReactPreviewDriver struct {
+	PayloadIO io.ReadCloser
+	Source    string
+}
+
+func NewReactPreviewDriver(payloadIO io.ReadCloser, source string) Driver {
+	return &ReactPreviewDriver{PayloadIO: payloadIO, Source: source}
 }
 
-func (d ReactDriver) decode(payloadIO io.ReadCloser) (models_v1.ReactionRequest, error) {
-	decoder := json.NewDecoder(payloadIO)
-	var payload models_v1.ReactionRequest
-	err := decoder.Decode(&payload)
+func (d ReactPreviewDriver) WithMedia(payloadIO io.ReadCloser) error {
+	return nil
+}
+
+func (d ReactPreviewDriver) IsReady() bool {
+	return true
+}
+
+func (d ReactPreviewDriver) BuildEventPayload() (tables.Ledger, error) {
+	raw, err := io.ReadAll(d.PayloadIO)
+	if err != nil {
+		return tables.Ledger{}, err
+	}
+	rawEvent, err := decodeReactionRequest(raw)
 	if err != nil {
-		return payload, err
+		return tables.Ledger{}, err
 	}
-	return payload, err
+	return newLedgerFromUrls(rawEvent.TargetLanguage, []string{rawEvent.ContentUrl}, d.Source), nil
 }
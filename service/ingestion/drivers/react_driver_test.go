@@ -0,0 +1,69 @@
+package drivers
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/bezalel-media-core/v2/service/ingestion/moderation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBatchFlushDue(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	policy := manifest.BatchPolicy{MaxItems: 3, MaxAgeSec: 60, MaxPayloadBytes: 1000}
+	fresh := dal.ReactionBufferEntry{
+		ContentUrls:               []string{"a"},
+		PayloadBytes:              100,
+		FirstBufferedAtEpochMilli: now.UnixMilli() - 1000,
+	}
+
+	assert.False(t, IsBatchFlushDue(fresh, policy, now), "expected fresh small batch to wait")
+	assert.False(t, IsBatchFlushDue(dal.ReactionBufferEntry{}, policy, now), "expected empty batch to never flush")
+
+	full := fresh
+	full.ContentUrls = []string{"a", "b", "c"}
+	assert.True(t, IsBatchFlushDue(full, policy, now), "expected flush on max items")
+
+	large := fresh
+	large.PayloadBytes = 1000
+	assert.True(t, IsBatchFlushDue(large, policy, now), "expected flush on max payload bytes")
+
+	stale := fresh
+	stale.FirstBufferedAtEpochMilli = now.UnixMilli() - 61_000
+	assert.True(t, IsBatchFlushDue(stale, policy, now), "expected flush on max age")
+	assert.False(t, IsBatchFlushDue(stale, manifest.BatchPolicy{MaxItems: 3}, now), "expected zero age limit to be disabled")
+}
+
+func TestReactPreviewDriver(t *testing.T) {
+	payload := `{"targetLanguage": "en", "contentUrl": "https://example.com/clip.mp4"}`
+	driver := NewReactPreviewDriver(io.NopCloser(strings.NewReader(payload)), "v1/source/reaction/short/video")
+	assert.True(t, driver.IsReady(), "expected preview to build without a claimed batch")
+
+	ledger, err := driver.BuildEventPayload()
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/clip.mp4", ledger.TriggerEventMediaUrls)
+	assert.Equal(t, "en", ledger.TriggerEventTargetLanguage)
+
+	driver = NewReactPreviewDriver(io.NopCloser(strings.NewReader(`{"targetLanguage": "en"}`)), "v1/source/reaction/short/video")
+	_, err = driver.BuildEventPayload()
+	assert.NotNil(t, err, "expected missing contentUrl to fail")
+}
+
+func TestReactDriverRejectsBeforeBuffering(t *testing.T) {
+	moderate := func(ledgerItem tables.Ledger) (moderation.Rejection, bool) {
+		return moderation.Rejection{Scope: "default", Reason: moderation.REASON_URL_NOT_ALLOWED}, true
+	}
+	driver := NewReactDriver("Reaction", manifest.BatchPolicy{MaxItems: 2}, moderate)
+	err := driver.WithMedia(io.NopCloser(strings.NewReader(`{"contentUrl": "https://spam.example.com/v/1"}`)))
+
+	var rejected *RejectedReactionError
+	assert.ErrorAs(t, err, &rejected)
+	assert.Equal(t, "https://spam.example.com/v/1", rejected.Ledger.TriggerEventMediaUrls)
+	assert.Equal(t, moderation.REASON_URL_NOT_ALLOWED, rejected.Rejection.Reason)
+	assert.False(t, driver.IsReady(), "a rejected reaction is never buffered")
+}
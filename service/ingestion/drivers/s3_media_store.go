@@ -0,0 +1,70 @@
+package drivers
+
+import (
+	"io"
+	"log"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	configs "github.com/bezalel-media-core/v2/configuration"
+)
+
+// Media bucket store; uploads are streamed in parts rather than buffered.
+type S3MediaStore struct{}
+
+var s3_uploader = s3manager.NewUploader(configs.GetAwsSession())
+var s3_svc = s3.New(configs.GetAwsSession())
+
+func (s S3MediaStore) Put(key string, contentType string, body io.Reader) (string, error) {
+	output, err := s3_uploader.Upload(&s3manager.UploadInput{
+		Bucket:      aws.String(configs.GetEnvConfigs().S3MediaBucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        body,
+	})
+	if err != nil {
+		log.Printf("error uploading %s to media bucket: %s", key, err)
+		return "", err
+	}
+	return output.Location, nil
+}
+
+// Url of the key, as returned by Put.
+func (s S3MediaStore) URL(key string) string {
+	req, _ := s3_svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(configs.GetEnvConfigs().S3MediaBucket),
+		Key:    aws.String(key),
+	})
+	err := req.Build()
+	if err != nil {
+		log.Printf("error building url of %s in media bucket: %s", key, err)
+		return ""
+	}
+	return req.HTTPRequest.URL.String()
+}
+
+func (s S3MediaStore) Copy(fromKey string, toKey string) error {
+	bucket := configs.GetEnvConfigs().S3MediaBucket
+	_, err := s3_svc.CopyObject(&s3.CopyObjectInput{
+		Bucket:     aws.String(bucket),
+		CopySource: aws.String(url.PathEscape(bucket + "/" + fromKey)),
+		Key:        aws.String(toKey),
+	})
+	if err != nil {
+		log.Printf("error copying %s to %s in media bucket: %s", fromKey, toKey, err)
+	}
+	return err
+}
+
+func (s S3MediaStore) Delete(key string) error {
+	_, err := s3_svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(configs.GetEnvConfigs().S3MediaBucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Printf("error deleting %s from media bucket: %s", key, err)
+	}
+	return err
+}
@@ -0,0 +1,233 @@
+package drivers
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/google/uuid"
+)
+
+// Validation failures of an upload; anything else is a storage error.
+var ErrUploadRejected = errors.New("upload rejected")
+
+const uploadLanguageField = "targetLanguage"
+
+type uploadFormat struct {
+	mediaType tables.MediaType
+	extension string
+}
+
+// Sniffed content types accepted for upload.
+var uploadFormats = map[string]uploadFormat{
+	"image/png":  {tables.MEDIA_IMAGE, "png"},
+	"image/jpeg": {tables.MEDIA_IMAGE, "jpg"},
+	"image/gif":  {tables.MEDIA_IMAGE, "gif"},
+	"image/webp": {tables.MEDIA_IMAGE, "webp"},
+	"video/mp4":  {tables.MEDIA_VIDEO, "mp4"},
+	"video/webm": {tables.MEDIA_VIDEO, "webm"},
+}
+
+// Stores uploaded media; see S3MediaStore.
+type MediaStore interface {
+	Put(key string, contentType string, body io.Reader) (url string, err error)
+	URL(key string) string
+	Copy(fromKey string, toKey string) error
+	Delete(key string) error
+}
+
+type UploadLimits struct {
+	MaxFileBytes int64
+	MaxFiles     int
+}
+
+// Streams multipart files into the media store and builds one ledger of their urls.
+// Files are staged under tables.UPLOAD_STAGING_PREFIX until Promote, which runs just before the ledger is saved so
+// its urls exist once workflows run.
+type UploadDriver struct {
+	Source string
+	Store  MediaStore
+	Limits UploadLimits
+
+	ledgerID       string
+	targetLanguage string
+	stagedKeys     []string
+	keys           []string
+	promotedKeys   []string
+	urls           []string
+	contentHashes  []string
+}
+
+func NewUploadDriver(source string, store MediaStore, limits UploadLimits) *UploadDriver {
+	return &UploadDriver{Source: source, Store: store, Limits: limits, ledgerID: uuid.New().String()}
+}
+
+func (d *UploadDriver) WithMedia(payloadIO io.ReadCloser) error {
+	return fmt.Errorf("%w: source %s only accepts multipart uploads", ErrUploadRejected, d.Source)
+}
+
+// Stores every file part; on error, files stored so far are discarded.
+func (d *UploadDriver) WithMultipart(reader *multipart.Reader) error {
+	err := d.readParts(reader)
+	if err == nil && len(d.urls) == 0 {
+		err = fmt.Errorf("%w: no files uploaded", ErrUploadRejected)
+	}
+	if err != nil {
+		d.Discard()
+	}
+	return err
+}
+
+func (d *UploadDriver) readParts(reader *multipart.Reader) error {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			log.Printf("error reading multipart upload: %s", err)
+			return fmt.Errorf("%w: malformed multipart body: %s", ErrUploadRejected, err)
+		}
+
+		if part.FileName() == "" {
+			err = d.readField(part)
+		} else {
+			err = d.storeFile(part)
+		}
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *UploadDriver) readField(part *multipart.Part) error {
+	if part.FormName() != uploadLanguageField {
+		return nil
+	}
+	value, err := io.ReadAll(io.LimitReader(part, 64))
+	if err != nil {
+		return err
+	}
+	d.targetLanguage = strings.TrimSpace(string(value))
+	return nil
+}
+
+func (d *UploadDriver) storeFile(part *multipart.Part) error {
+	if len(d.urls) >= d.Limits.MaxFiles {
+		return fmt.Errorf("%w: more than %d files", ErrUploadRejected, d.Limits.MaxFiles)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	format, ok := uploadFormats[contentType]
+	if !ok {
+		return fmt.Errorf("%w: %s has unsupported content type %s", ErrUploadRejected, part.FileName(), contentType)
+	}
+	declared := part.Header.Get("Content-Type")
+	if declared != "" && declared != "application/octet-stream" && declared != contentType {
+		return fmt.Errorf("%w: %s declared as %s but contains %s", ErrUploadRejected, part.FileName(), declared, contentType)
+	}
+
+	// Same layout as MediaEvent.SetContentLookupKey so the ledger can be redriven from the key.
+	key := fmt.Sprintf("%s.%s.%s.%s", format.mediaType, d.ledgerID, uuid.New().String(), format.extension)
+	stagedKey := tables.UPLOAD_STAGING_PREFIX + key
+	limited := &sizeLimitedReader{reader: io.MultiReader(bytes.NewReader(head), part), remaining: d.Limits.MaxFileBytes}
+	hash := md5.New()
+	_, err = d.Store.Put(stagedKey, contentType, io.TeeReader(limited, hash))
+	d.stagedKeys = append(d.stagedKeys, stagedKey) // May be partially written on error.
+	if limited.exceeded {
+		return fmt.Errorf("%w: %s exceeds %d bytes", ErrUploadRejected, part.FileName(), d.Limits.MaxFileBytes)
+	}
+	if err != nil {
+		log.Printf("error storing uploaded file %s: %s", stagedKey, err)
+		return err
+	}
+
+	d.keys = append(d.keys, key)
+	d.urls = append(d.urls, d.Store.URL(key))
+	d.contentHashes = append(d.contentHashes, hex.EncodeToString(hash.Sum(nil)))
+	return nil
+}
+
+func (d *UploadDriver) LedgerID() string {
+	return d.ledgerID
+}
+
+// Copies staged files to the keys of the ledger urls, then removes the staged files. Files copied before a
+// failure stay promoted until Discard.
+func (d *UploadDriver) Promote() error {
+	for i, key := range d.keys {
+		err := d.Store.Copy(d.stagedKeys[i], key)
+		if err != nil {
+			log.Printf("correlationID: %s error promoting uploaded file %s: %s", d.ledgerID, key, err)
+			return err
+		}
+		d.promotedKeys = append(d.promotedKeys, key)
+	}
+	d.deleteKeys(d.stagedKeys)
+	d.stagedKeys = nil
+	return nil
+}
+
+// Removes staged and promoted files, e.g. when the upload isn't saved as a new ledger.
+func (d *UploadDriver) Discard() {
+	d.deleteKeys(d.stagedKeys)
+	d.deleteKeys(d.promotedKeys)
+	d.stagedKeys = nil
+	d.promotedKeys = nil
+}
+
+func (d *UploadDriver) deleteKeys(keys []string) {
+	for _, key := range keys {
+		err := d.Store.Delete(key)
+		if err != nil {
+			log.Printf("error discarding uploaded file %s: %s", key, err)
+		}
+	}
+}
+
+func (d *UploadDriver) IsReady() bool {
+	return true
+}
+
+func (d *UploadDriver) BuildEventPayload() (tables.Ledger, error) {
+	if len(d.urls) == 0 {
+		return tables.Ledger{}, fmt.Errorf("%w: no files uploaded", ErrUploadRejected)
+	}
+	ledger := newLedgerFromUrls(d.targetLanguage, d.urls, d.Source)
+	ledger.LedgerID = d.ledgerID // Already embedded in the stored keys.
+	// Stored keys are unique per request; dedupe on the file contents instead.
+	ledger.TriggerEventContentHash = getMD5Hash(strings.Join(d.contentHashes, ","))
+	return ledger, nil
+}
+
+// Fails the read once more than remaining bytes are consumed, aborting the store.
+type sizeLimitedReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		r.exceeded = true
+		return n, errors.New("upload size limit exceeded")
+	}
+	return n, err
+}
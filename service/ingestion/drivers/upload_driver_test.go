@@ -0,0 +1,126 @@
+package drivers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryMediaStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryMediaStore) Put(key string, contentType string, body io.Reader) (string, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	s.objects[key] = raw
+	return s.URL(key), nil
+}
+
+func (s *memoryMediaStore) URL(key string) string {
+	return "https://media.example.com/" + key
+}
+
+func (s *memoryMediaStore) Copy(fromKey string, toKey string) error {
+	s.objects[toKey] = s.objects[fromKey]
+	return nil
+}
+
+func (s *memoryMediaStore) Delete(key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+type testPart struct {
+	field       string
+	filename    string
+	contentType string
+	body        []byte
+}
+
+func multipartReader(t *testing.T, parts ...testPart) *multipart.Reader {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		disposition := `form-data; name="` + p.field + `"`
+		if p.filename != "" {
+			disposition += `; filename="` + p.filename + `"`
+		}
+		header.Set("Content-Disposition", disposition)
+		if p.contentType != "" {
+			header.Set("Content-Type", p.contentType)
+		}
+		w, err := writer.CreatePart(header)
+		assert.Nil(t, err)
+		w.Write(p.body)
+	}
+	writer.Close()
+	return multipart.NewReader(&buf, writer.Boundary())
+}
+
+var testPng = append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 64)...)
+
+func TestUploadDriver(t *testing.T) {
+	store := &memoryMediaStore{objects: map[string][]byte{}}
+	driver := NewUploadDriver("upload", store, UploadLimits{MaxFileBytes: 1024, MaxFiles: 2})
+	err := driver.WithMultipart(multipartReader(t,
+		testPart{field: "targetLanguage", body: []byte("es")},
+		testPart{field: "media", filename: "a.png", contentType: "image/png", body: testPng},
+	))
+	assert.Nil(t, err)
+
+	ledger, err := driver.BuildEventPayload()
+	assert.Nil(t, err)
+	assert.Equal(t, "es", ledger.TriggerEventTargetLanguage)
+	assert.Equal(t, 1, len(store.objects))
+	for key := range store.objects {
+		assert.True(t, strings.HasPrefix(key, tables.UPLOAD_STAGING_PREFIX), "expected file staged until promoted: "+key)
+	}
+
+	assert.Nil(t, driver.Promote())
+	assert.Equal(t, 1, len(store.objects), "expected staged file removed once promoted")
+	for key := range store.objects {
+		assert.True(t, strings.HasPrefix(key, "Image."+ledger.LedgerID+"."), "expected content lookup key layout: "+key)
+		assert.True(t, strings.HasSuffix(key, ".png"))
+		assert.Equal(t, "https://media.example.com/"+key, ledger.TriggerEventMediaUrls)
+	}
+
+	promoted := NewUploadDriver("upload", store, UploadLimits{MaxFileBytes: 1024, MaxFiles: 2})
+	promoted.WithMultipart(multipartReader(t, testPart{field: "media", filename: "c.png", body: testPng}))
+	assert.Nil(t, promoted.Promote())
+	assert.Equal(t, 2, len(store.objects))
+	promoted.Discard()
+	assert.Equal(t, 1, len(store.objects), "expected promoted files of an unsaved upload discarded")
+
+	again := NewUploadDriver("upload", store, UploadLimits{MaxFileBytes: 1024, MaxFiles: 2})
+	again.WithMultipart(multipartReader(t, testPart{field: "media", filename: "b.png", body: testPng}))
+	againLedger, _ := again.BuildEventPayload()
+	assert.Equal(t, ledger.TriggerEventContentHash, againLedger.TriggerEventContentHash, "expected hash of contents, not keys")
+}
+
+func TestUploadDriverRejections(t *testing.T) {
+	cases := map[string][]testPart{
+		"no files":         {{field: "targetLanguage", body: []byte("en")}},
+		"unsupported type": {{field: "media", filename: "a.txt", body: []byte("plain text")}},
+		"mismatched type":  {{field: "media", filename: "a.png", contentType: "video/mp4", body: testPng}},
+		"too large":        {{field: "media", filename: "a.png", body: append(testPng, make([]byte, 1024)...)}},
+		"too many files":   {{field: "a", filename: "a.png", body: testPng}, {field: "b", filename: "b.png", body: testPng}, {field: "c", filename: "c.png", body: testPng}},
+	}
+	for name, parts := range cases {
+		store := &memoryMediaStore{objects: map[string][]byte{}}
+		driver := NewUploadDriver("upload", store, UploadLimits{MaxFileBytes: 1024, MaxFiles: 2})
+		err := driver.WithMultipart(multipartReader(t, parts...))
+		assert.True(t, errors.Is(err, ErrUploadRejected), "expected rejection for: "+name)
+		assert.Equal(t, 0, len(store.objects), "expected stored files discarded for: "+name)
+	}
+}
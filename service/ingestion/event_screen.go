@@ -0,0 +1,89 @@
+package ingestion
+
+import (
+	"log"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/bezalel-media-core/v2/service/ingestion/moderation"
+)
+
+// Dedupe and moderation of an event ahead of admission. Exact duplicates are skipped before moderation, and
+// rejections are remembered by content hash, so resubmitted content, e.g. re-polled feed items, is only
+// moderated and recorded once.
+type eventScreen struct {
+	evaluate                func(ledgerItem tables.Ledger) (moderation.Rejection, bool)
+	getHashEntry            func(rawContentHash string) (dal.HashEntry, error)
+	createRejectedHashEntry func(rawContentHash string, reason string) error
+	recordRejection         func(source string, contentHash string, scope string, reason string, detail string) error
+}
+
+func newEventScreen(evaluate func(ledgerItem tables.Ledger) (moderation.Rejection, bool)) eventScreen {
+	return eventScreen{
+		evaluate:                evaluate,
+		getHashEntry:            dal.GetHashEntry,
+		createRejectedHashEntry: dal.CreateRejectedHashEntry,
+		recordRejection:         dal.CreateModerationRejection,
+	}
+}
+
+// Returns the result of an event that is a duplicate or rejected, or false when it should be saved.
+// Events of preModerated drivers were moderated item by item and are only deduped.
+func (s eventScreen) screen(source string, ledgerItem tables.Ledger, preModerated bool) (models_v1.IngestionResult, bool) {
+	result := models_v1.IngestionResult{
+		Source:      source,
+		ContentHash: ledgerItem.TriggerEventContentHash,
+	}
+	entry, err := s.getHashEntry(ledgerItem.TriggerEventContentHash)
+	if err != nil {
+		log.Printf("failed to get hash entry: %s", err)
+	}
+	if entry.RejectedReason != "" {
+		log.Printf("previously rejected ingestion event, skipping: %s", ledgerItem.TriggerEventContentHash)
+		return rejectedResult(source, ledgerItem, moderation.Rejection{Reason: entry.RejectedReason}), true
+	}
+	if len(entry.EventHash) != 0 {
+		log.Printf("duplicate ingestion event, skipping: %s", ledgerItem.TriggerEventContentHash)
+		result.Outcome = models_v1.OUTCOME_DUPLICATE
+		result.LedgerID = entry.LedgerID
+		return result, true
+	}
+	if preModerated {
+		return result, false
+	}
+	rejection, rejected := s.moderateNew(source, ledgerItem)
+	if rejected {
+		return rejectedResult(source, ledgerItem, rejection), true
+	}
+	return result, false
+}
+
+// Moderates one item apart from its batch, e.g. a reaction as it is buffered.
+func (s eventScreen) moderate(ledgerItem tables.Ledger) (moderation.Rejection, bool) {
+	entry, err := s.getHashEntry(ledgerItem.TriggerEventContentHash)
+	if err != nil {
+		log.Printf("failed to get hash entry: %s", err)
+	}
+	if entry.RejectedReason != "" {
+		return moderation.Rejection{Reason: entry.RejectedReason}, true
+	}
+	return s.moderateNew(ledgerItem.TriggerEventSource, ledgerItem)
+}
+
+func (s eventScreen) moderateNew(source string, ledgerItem tables.Ledger) (moderation.Rejection, bool) {
+	rejection, rejected := s.evaluate(ledgerItem)
+	if !rejected {
+		return rejection, false
+	}
+	log.Printf("moderation rejected event of source %s by %s rules: %s %s", source, rejection.Scope, rejection.Reason, rejection.Detail)
+	s.recordRejection(source, ledgerItem.TriggerEventContentHash, rejection.Scope, rejection.Reason, rejection.Detail)
+	if rejection.Reason == moderation.REASON_CLASSIFIER_ERROR {
+		return rejection, true // Fails closed, but may pass once resubmitted.
+	}
+	err := s.createRejectedHashEntry(ledgerItem.TriggerEventContentHash, rejection.Reason)
+	if err != nil {
+		log.Printf("failed to create a rejected hash entry: %s", err)
+	}
+	return rejection, true
+}
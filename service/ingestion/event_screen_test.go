@@ -0,0 +1,88 @@
+package ingestion
+
+import (
+	"testing"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/bezalel-media-core/v2/service/ingestion/moderation"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingClassifier struct {
+	calls *int
+}
+
+func (c countingClassifier) Name() string {
+	return "counting_screen_test"
+}
+
+func (c countingClassifier) Classify(ledgerItem tables.Ledger) (moderation.Verdict, error) {
+	*c.calls++
+	return moderation.Verdict{Flagged: true, Label: "spam"}, nil
+}
+
+// Screen backed by an in-memory dedupe table, counting recorded rejections.
+func testEventScreen(evaluate func(tables.Ledger) (moderation.Rejection, bool), rejections *int) eventScreen {
+	entries := map[string]dal.HashEntry{}
+	return eventScreen{
+		evaluate: evaluate,
+		getHashEntry: func(rawContentHash string) (dal.HashEntry, error) {
+			return entries[rawContentHash], nil
+		},
+		createRejectedHashEntry: func(rawContentHash string, reason string) error {
+			entries[rawContentHash] = dal.HashEntry{EventHash: rawContentHash, RejectedReason: reason}
+			return nil
+		},
+		recordRejection: func(source string, contentHash string, scope string, reason string, detail string) error {
+			*rejections++
+			return nil
+		},
+	}
+}
+
+func TestEventScreenRejectsResubmissionsOnce(t *testing.T) {
+	classified := 0
+	moderation.RegisterClassifier(countingClassifier{calls: &classified})
+	moderator, err := moderation.NewModerator(&manifest.ManifestLoader{ModerationRules: manifest.ModerationRuleCollection{
+		DefaultRules: manifest.ModerationRules{Classifiers: []string{"counting_screen_test"}},
+	}})
+	assert.Nil(t, err)
+	rejections := 0
+	screen := testEventScreen(moderator.Evaluate, &rejections)
+
+	ledgerItem := tables.Ledger{TriggerEventSource: "feed", TriggerEventPayload: "buy now", TriggerEventContentHash: "h1"}
+	for i := 0; i < 2; i++ {
+		result, screened := screen.screen("feed", ledgerItem, false)
+		assert.True(t, screened)
+		assert.Equal(t, models_v1.OUTCOME_REJECTED, result.Outcome)
+		assert.Equal(t, moderation.REASON_CLASSIFIER, result.Reason)
+	}
+	assert.Equal(t, 1, classified, "expected the resubmission rejected without classifying it again")
+	assert.Equal(t, 1, rejections, "expected one rejection recorded")
+
+	_, rejected := screen.moderate(ledgerItem)
+	assert.True(t, rejected)
+	assert.Equal(t, 1, classified)
+}
+
+func TestEventScreenDedupesBeforeModerating(t *testing.T) {
+	evaluated := 0
+	evaluate := func(tables.Ledger) (moderation.Rejection, bool) {
+		evaluated++
+		return moderation.Rejection{}, false
+	}
+	rejections := 0
+	screen := testEventScreen(evaluate, &rejections)
+	screen.getHashEntry = func(rawContentHash string) (dal.HashEntry, error) {
+		return dal.HashEntry{EventHash: rawContentHash, LedgerID: "l1"}, nil
+	}
+
+	result, screened := screen.screen("feed", tables.Ledger{TriggerEventContentHash: "h1"}, false)
+	assert.True(t, screened)
+	assert.Equal(t, models_v1.OUTCOME_DUPLICATE, result.Outcome)
+	assert.Equal(t, "l1", result.LedgerID)
+	assert.Equal(t, 0, evaluated)
+}
@@ -1,58 +1,189 @@
 package ingestion
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"time"
 
 	env "github.com/bezalel-media-core/v2/configuration"
 	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/bezalel-media-core/v2/service/ingestion/drivers"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/bezalel-media-core/v2/service/ingestion/moderation"
+	"github.com/bezalel-media-core/v2/service/ingestion/redaction"
 )
 
-func SaveSourceEventToLedger(source string, r *http.Request) error {
-	driver, err := GetDriver(source, r.Body)
+func SaveSourceEventToLedger(source string, r *http.Request) (models_v1.IngestionResult, error) {
+	return SaveSourcePayloadToLedger(source, r.Body)
+}
+
+// Entrypoint for non-HTTP producers such as the feed poller.
+// Returned errors are always reflected in the result's outcome.
+func SaveSourcePayloadToLedger(source string, payloadIO io.ReadCloser) (models_v1.IngestionResult, error) {
+	return savePayloadToLedger(source, payloadIO, "")
+}
+
+// lane optionally lowers the admission lane of the source; see admitEvent.
+func savePayloadToLedger(source string, payloadIO io.ReadCloser, lane string) (models_v1.IngestionResult, error) {
+	driver, err := GetDriver(source, payloadIO)
+	var rejected *drivers.RejectedReactionError
+	if errors.As(err, &rejected) {
+		return rejectedResult(source, rejected.Ledger, rejected.Rejection), nil
+	}
 	if err != nil {
 		log.Printf("error retreiving driver: %s", err)
+		return invalidResult(source, err), err
 	}
+	return saveDriverToLedger(source, driver, lane)
+}
+
+// Flushes batched sources whose buffers became due without a new request arriving, e.g. max age.
+func FlushStaleBatches() {
+	for _, policy := range manifest.GetManifestLoader().BatchPolicies.Policies {
+		entry, err := dal.GetReactionBuffer(policy.SourceName)
+		if err != nil {
+			log.Printf("error retrieving batch buffer for source %s: %s", policy.SourceName, err)
+			continue
+		}
+		if !drivers.IsBatchFlushDue(entry, policy, time.Now()) {
+			continue
+		}
 
+		driver := drivers.NewReactDriverFromBuffer(policy.SourceName, policy, entry)
+		_, err = saveDriverToLedger(policy.SourceName, driver, "")
+		if err != nil {
+			log.Printf("error flushing stale batch for source %s: %s", policy.SourceName, err)
+		}
+	}
+}
+
+func saveDriverToLedger(source string, driver drivers.Driver, lane string) (models_v1.IngestionResult, error) {
 	if !driver.IsReady() {
-		return nil
+		return models_v1.IngestionResult{Source: source, Outcome: models_v1.OUTCOME_BUFFERED}, nil
 	}
 
-	ledgerItem, err := driver.BuildEventPayload()
+	ledgerItem, err := prepareLedger(driver)
 	if err != nil {
-		log.Printf("driver failed to get raw event payload: %s", err)
-		return err
+		return invalidResult(source, err), err
+	}
+	preModerated, ok := driver.(drivers.PreModeratedDriver)
+	result, screened := newEventScreen(moderateLedger).screen(source, ledgerItem, ok && preModerated.IsPreModerated())
+	if screened {
+		return result, nil
 	}
 
-	entry, err := dal.GetHashEntry(ledgerItem.TriggerEventContentHash)
-	if err != nil {
-		log.Printf("failed to get hash entry: %s", err)
+	match, found := findNearDuplicate(ledgerItem)
+	if found {
+		log.Printf("near duplicate ingestion event of ledger %s, skipping: %s", match.ledgerID, ledgerItem.TriggerEventContentHash)
+		result.Outcome = models_v1.OUTCOME_NEAR_DUPLICATE
+		result.LedgerID = match.ledgerID
+		result.Similarity = match.similarity
+		return result, nil
 	}
 
-	if len(entry.EventHash) != 0 {
-		log.Printf("duplicate ingestion event, skipping: %s", ledgerItem.TriggerEventContentHash)
-		return nil
+	admitted, reserved, reason := admitEvent(source, lane)
+	if staged, ok := driver.(drivers.StagedMediaDriver); ok {
+		err = staged.Promote()
+		if err != nil {
+			if reserved {
+				dal.ReleaseAdmission(source)
+			}
+			return failedResult(result, err), err
+		}
+	}
+	if admitted {
+		ledgerItem.AdmissionReserved = reserved
+		// Triggers downstream workflows via CDC on dynamo table.
+		err = dal.CreateLedger(ledgerItem)
+		if err != nil {
+			log.Printf("failed to create a new ledger item: %s", err)
+			if reserved {
+				dal.ReleaseAdmission(source)
+			}
+			return failedResult(result, err), err
+		}
+		result.Outcome = models_v1.OUTCOME_CREATED
+	} else {
+		log.Printf("source %s not admitted (%s) - parking event for retry: %s", source, reason, ledgerItem.LedgerID)
+		err = parkEvent(ledgerItem, source, lane, reason)
+		if err != nil {
+			result.Outcome = models_v1.OUTCOME_SHED
+			return result, nil
+		}
+		result.Outcome = models_v1.OUTCOME_PARKED
 	}
+	result.LedgerID = ledgerItem.LedgerID
 
-	if dal.IsOverflow(ledgerItem.TriggerEventSource, env.GetEnvConfigs().MaxSourceOverflow) {
-		log.Printf("source has surplus unprocessed events - load shedding new events: %s", ledgerItem.TriggerEventSource)
-		return nil
+	err = dal.CreateHashEntry(ledgerItem.TriggerEventContentHash, ledgerItem.LedgerID)
+	if err != nil {
+		log.Printf("failed to create a new hash entry: %s", err)
+		return failedResult(result, err), err
 	}
+	saveFingerprint(ledgerItem)
 
-	// Triggers downstream workflows via CDC on dynamo table.
-	err = dal.CreateLedger(ledgerItem)
+	return result, err
+}
+
+// Builds and validates the ledger of a ready driver; shared with previews, so without side effects.
+func prepareLedger(driver drivers.Driver) (tables.Ledger, error) {
+	ledgerItem, err := driver.BuildEventPayload()
 	if err != nil {
-		log.Printf("failed to create a new ledger item: %s", err)
-		return err
+		log.Printf("driver failed to get raw event payload: %s", err)
+		return ledgerItem, err
+	}
+	if ledgerItem.TriggerEventPayload == "" && ledgerItem.TriggerEventMediaUrls == "" {
+		return ledgerItem, errors.New("event payload has no text or media urls")
 	}
+	targetLanguage, err := resolveTargetLanguage(ledgerItem)
+	if err != nil {
+		return ledgerItem, err
+	}
+	ledgerItem.TriggerEventTargetLanguage = targetLanguage
+	return redactLedger(ledgerItem)
+}
 
-	err = dal.CreateHashEntry(ledgerItem.TriggerEventContentHash)
+// Redacts the payload of sources with redactPersonalInfo; the content hash still reflects the raw event.
+func redactLedger(ledgerItem tables.Ledger) (tables.Ledger, error) {
+	s, ok := manifest.GetManifestLoader().GetSource(ledgerItem.TriggerEventSource)
+	if !ok || !s.RedactPersonalInfo {
+		return ledgerItem, nil
+	}
+	redacted, report := redaction.Redact(ledgerItem.TriggerEventPayload, []byte(env.GetEnvConfigs().RedactionHashKey))
+	if len(report.Redactions) == 0 {
+		return ledgerItem, nil
+	}
+	reportJson, err := json.Marshal(report)
 	if err != nil {
-		log.Printf("failed to create a new hash entry: %s", err)
-		return err
+		log.Printf("correlationID: %s error marshalling redaction report: %s", ledgerItem.LedgerID, err)
+		return ledgerItem, err
+	}
+	ledgerItem.TriggerEventPayload = redacted
+	ledgerItem.TriggerEventRedactions = string(reportJson)
+	return ledgerItem, nil
+}
+
+func rejectedResult(source string, ledgerItem tables.Ledger, rejection moderation.Rejection) models_v1.IngestionResult {
+	return models_v1.IngestionResult{
+		Source:      source,
+		ContentHash: ledgerItem.TriggerEventContentHash,
+		Outcome:     models_v1.OUTCOME_REJECTED,
+		Reason:      rejection.Reason,
 	}
+}
+
+func invalidResult(source string, err error) models_v1.IngestionResult {
+	return models_v1.IngestionResult{Source: source, Outcome: models_v1.OUTCOME_INVALID, Error: err.Error()}
+}
 
-	return err
+func failedResult(result models_v1.IngestionResult, err error) models_v1.IngestionResult {
+	result.Outcome = models_v1.OUTCOME_FAILED
+	result.Error = err.Error()
+	return result
 }
 
 func AggregateSources(source string, r *http.Request) error {
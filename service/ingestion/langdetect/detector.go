@@ -0,0 +1,162 @@
+package langdetect
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Offline detection: writing script first, then character trigram profiles for Latin-script languages.
+// Profiles are built from the embedded corpora/<bcp47>.txt samples; add a file to support a language.
+
+//go:embed corpora/*.txt
+var corpora embed.FS
+
+const profileSize = 300
+const minLetters = 20 // Shorter texts are reported as undetected.
+
+type profile map[string]int // trigram -> rank
+
+var latinProfiles = loadProfiles()
+
+// Non-Latin scripts that identify a single language well enough for prompting.
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Greek, "el"},
+	{unicode.Han, "zh"},
+}
+
+// Detect returns a BCP-47 language code, or false when the text is too short or ambiguous.
+func Detect(text string) (string, bool) {
+	letters := 0
+	latin := 0
+	kana := 0
+	scriptCounts := make([]int, len(scriptLanguages))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for i, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					scriptCounts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters < minLetters {
+		return "", false
+	}
+
+	// Japanese mixes kana with Han; any meaningful kana share means Japanese.
+	if kana*10 >= letters {
+		return "ja", true
+	}
+	for i, s := range scriptLanguages {
+		if scriptCounts[i]*2 > letters {
+			return s.code, true
+		}
+	}
+	if latin*2 <= letters {
+		return "", false
+	}
+	return detectLatin(text)
+}
+
+func detectLatin(text string) (string, bool) {
+	textProfile := buildProfile(text)
+	bestCode := ""
+	bestDistance := -1
+	for code, p := range latinProfiles {
+		d := outOfPlaceDistance(textProfile, p)
+		if bestDistance < 0 || d < bestDistance || (d == bestDistance && code < bestCode) {
+			bestCode = code
+			bestDistance = d
+		}
+	}
+	return bestCode, bestCode != ""
+}
+
+// Cavnar-Trenkle rank distance; trigrams missing from the language profile cost the maximum.
+func outOfPlaceDistance(textProfile profile, languageProfile profile) int {
+	distance := 0
+	for trigram, rank := range textProfile {
+		languageRank, ok := languageProfile[trigram]
+		if !ok {
+			distance += profileSize
+			continue
+		}
+		if rank > languageRank {
+			distance += rank - languageRank
+		} else {
+			distance += languageRank - rank
+		}
+	}
+	return distance
+}
+
+func buildProfile(text string) profile {
+	counts := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		padded := []rune(" " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts[string(padded[i:i+3])]++
+		}
+	}
+
+	trigrams := make([]string, 0, len(counts))
+	for t := range counts {
+		trigrams = append(trigrams, t)
+	}
+	sort.Slice(trigrams, func(i, j int) bool {
+		if counts[trigrams[i]] != counts[trigrams[j]] {
+			return counts[trigrams[i]] > counts[trigrams[j]]
+		}
+		return trigrams[i] < trigrams[j]
+	})
+
+	result := profile{}
+	for i, t := range trigrams {
+		if i >= profileSize {
+			break
+		}
+		result[t] = i
+	}
+	return result
+}
+
+func loadProfiles() map[string]profile {
+	entries, err := corpora.ReadDir("corpora")
+	if err != nil {
+		panic(err) // Embedded at build time; unreachable unless the embed pattern changes.
+	}
+	result := map[string]profile{}
+	for _, e := range entries {
+		raw, err := corpora.ReadFile(path.Join("corpora", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		result[strings.TrimSuffix(e.Name(), ".txt")] = buildProfile(string(raw))
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package langdetect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	cases := map[string]string{
+		"My landlord kept the whole deposit and now I am not sure what my options are.":              "en",
+		"Mi casero se quedó con toda la fianza y ahora no sé qué opciones tengo.":                    "es",
+		"Mon propriétaire a gardé toute la caution et je ne sais pas quelles sont mes options.":      "fr",
+		"Mein Vermieter hat die ganze Kaution behalten und jetzt weiß ich nicht, was ich tun kann.":  "de",
+		"O meu senhorio ficou com a caução toda e agora não sei quais são as minhas opções.":         "pt",
+		"Il mio padrone di casa ha tenuto tutta la caparra e ora non so quali siano le mie opzioni.": "it",
+		"Mijn huisbaas heeft de hele borg gehouden en nu weet ik niet wat mijn opties zijn.":         "nl",
+		"Мой арендодатель оставил себе весь залог, и теперь я не знаю, что делать.":                  "ru",
+		"大家が敷金を全部返してくれなかったので、どうすればいいかわかりません。":                                                        "ja",
+		"房东扣下了全部押金，我现在不知道该怎么办才好，请大家给点建议。":                                                            "zh",
+		"집주인이 보증금을 전부 돌려주지 않아서 이제 어떻게 해야 할지 모르겠습니다.":                                                 "ko",
+	}
+	for text, expected := range cases {
+		actual, ok := Detect(text)
+		assert.True(t, ok, text)
+		assert.Equal(t, expected, actual, text)
+	}
+}
+
+func TestDetectTooShort(t *testing.T) {
+	_, ok := Detect("hello")
+	assert.False(t, ok)
+	_, ok = Detect("1234567890 !!! 2024-01-01 ???")
+	assert.False(t, ok, "expected digits and punctuation to not count as letters")
+}
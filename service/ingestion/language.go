@@ -0,0 +1,27 @@
+package ingestion
+
+import (
+	"fmt"
+
+	env "github.com/bezalel-media-core/v2/configuration"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/bezalel-media-core/v2/service/ingestion/langdetect"
+)
+
+// Canonical BCP-47 target language for the ledger: the provided value normalized, otherwise detected
+// from the payload text, otherwise the configured default. Malformed provided values are rejected.
+func resolveTargetLanguage(ledgerItem tables.Ledger) (string, error) {
+	if ledgerItem.TriggerEventTargetLanguage != "" {
+		canonical, err := tables.NormalizeLanguageCode(ledgerItem.TriggerEventTargetLanguage)
+		if err != nil {
+			return "", fmt.Errorf("invalid targetLanguage %q: %w", ledgerItem.TriggerEventTargetLanguage, err)
+		}
+		return canonical, nil
+	}
+
+	detected, ok := langdetect.Detect(ledgerItem.TriggerEventPayload)
+	if ok {
+		return detected, nil
+	}
+	return tables.NormalizeLanguageCode(env.GetEnvConfigs().DefaultTargetLanguage)
+}
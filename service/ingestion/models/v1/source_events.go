@@ -1,8 +1,9 @@
 package models
 
 type CustomPromptRequest struct {
-	Source     string `json:"source"`
-	PromptText string `json:"promptText"`
+	Source         string `json:"source"`
+	TargetLanguage string `json:"targetLanguage"` // Optional; detected from promptText when omitted.
+	PromptText     string `json:"promptText"`
 }
 
 type BlogRequest struct {
@@ -12,10 +13,30 @@ type BlogRequest struct {
 }
 
 type ForumDumpRequest struct {
-	Source         string `json:"source"`
-	TargetLanguage string `json:"targetLanguage"`
-	ForumMainPost  string `json:"forumMainPost"`
-	Comments       string `json:"comments"`
+	Source         string       `json:"source"`
+	TargetLanguage string       `json:"targetLanguage"`
+	ForumMainPost  string       `json:"forumMainPost"`
+	Comments       string       `json:"comments"` // Unstructured comments; ignored when thread is set.
+	Thread         *ForumThread `json:"thread,omitempty"`
+}
+
+// Structured forum post; comments are ranked and trimmed by the forum driver.
+type ForumThread struct {
+	Title    string         `json:"title"`
+	Author   string         `json:"author"`
+	Body     string         `json:"body"`
+	Comments []ForumComment `json:"comments"`
+}
+
+type ForumComment struct {
+	ID        string `json:"id"`
+	ParentID  string `json:"parentId"` // Empty, or not a comment id, for replies to the post.
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	Score     int64  `json:"score"`
+	Depth     int    `json:"depth"`     // 0 for replies to the post.
+	CreatedAt int64  `json:"createdAt"` // epoch seconds
+	IsBot     bool   `json:"isBot"`
 }
 
 type ReactionRequest struct {
@@ -23,3 +44,26 @@ type ReactionRequest struct {
 	TargetLanguage string `json:"targetLanguage"`
 	ContentUrl     string `json:"contentUrl"`
 }
+
+type FeedItemRequest struct {
+	Source         string `json:"source"`
+	TargetLanguage string `json:"targetLanguage"`
+	FeedUrl        string `json:"feedUrl"`
+	Guid           string `json:"guid"`
+	Title          string `json:"title"`
+	Link           string `json:"link"`
+	Summary        string `json:"summary"`
+	PublishedAt    int64  `json:"publishedAt"` // epoch seconds
+}
+
+// multipart/form-data fields of an upload source request.
+// Every file part is stored as media; "targetLanguage" is an optional text field.
+type MultipartUploadRequest struct {
+	TargetLanguage string `json:"targetLanguage"`
+}
+
+type ArticleRequest struct {
+	Source         string   `json:"source"`
+	TargetLanguage string   `json:"targetLanguage"`
+	Urls           []string `json:"urls"` // Article pages to fetch; cited by the generated script.
+}
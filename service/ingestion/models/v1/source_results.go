@@ -0,0 +1,57 @@
+package models
+
+import "encoding/json"
+
+const (
+	OUTCOME_CREATED   = "created"   // New ledger saved.
+	OUTCOME_DUPLICATE = "duplicate" // Content hash already ingested within the dedupe TTL.
+	// Text similar to a recent ledger of the same source, per the source's similarityThreshold.
+	OUTCOME_NEAR_DUPLICATE = "near_duplicate"
+	OUTCOME_PARKED         = "parked"   // Not admitted yet; LedgerID is created once a retry is admitted.
+	OUTCOME_SHED           = "shed"     // Not admitted and could not be parked; safe to retry.
+	OUTCOME_BUFFERED       = "buffered" // Accepted into a batch that isn't ready to flush.
+	OUTCOME_INVALID        = "invalid"  // Unknown source or malformed payload.
+	OUTCOME_REJECTED       = "rejected" // Blocked by manifest moderation_rules; see Reason.
+	OUTCOME_FAILED         = "failed"   // Storage or downstream error; safe to retry.
+)
+
+type IngestionResult struct {
+	Line        int     `json:"line,omitempty"` // 1-based line number for bulk ingestion.
+	Source      string  `json:"source,omitempty"`
+	Outcome     string  `json:"outcome"`
+	LedgerID    string  `json:"ledgerId,omitempty"` // For (near) duplicates, the existing ledger.
+	ContentHash string  `json:"contentHash,omitempty"`
+	Similarity  float64 `json:"similarity,omitempty"` // For near duplicates, estimated similarity to LedgerID.
+	Reason      string  `json:"reason,omitempty"`     // For rejections, the moderation reason code.
+	Error       string  `json:"error,omitempty"`
+}
+
+// One line of a bulk JSONL ingestion body.
+type BulkIngestionLine struct {
+	Source  string          `json:"source"`
+	Payload json.RawMessage `json:"payload"` // Request body for the source's requestSchema.
+}
+
+// Dry run of an ingestion request: the ledger and script prompts it would produce.
+type PreviewResult struct {
+	Source         string          `json:"source"`
+	Error          string          `json:"error,omitempty"`
+	TargetLanguage string          `json:"targetLanguage,omitempty"`
+	ContentHash    string          `json:"contentHash,omitempty"`
+	Payload        string          `json:"payload,omitempty"`
+	MediaUrls      string          `json:"mediaUrls,omitempty"`
+	WebsiteUrls    string          `json:"websiteUrls,omitempty"`
+	Redactions     json.RawMessage `json:"redactions,omitempty"` // Personal information redacted from the payload.
+	Moderation     string          `json:"moderation,omitempty"` // Reason code when moderation would reject the event.
+	Scripts        []ScriptPreview `json:"scripts,omitempty"`
+}
+
+type ScriptPreview struct {
+	PromptCategoryKey  string   `json:"promptCategoryKey"`
+	EventID            string   `json:"eventId,omitempty"`
+	DistributionFormat string   `json:"distributionFormat,omitempty"`
+	Channels           []string `json:"channels,omitempty"`
+	SystemPrompt       string   `json:"systemPrompt,omitempty"`
+	Prompt             string   `json:"prompt,omitempty"`
+	Error              string   `json:"error,omitempty"`
+}
@@ -0,0 +1,71 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Pluggable content check, e.g. a hosted moderation model; referenced by name from manifest moderation_rules.
+type Classifier interface {
+	Name() string
+	Classify(ledgerItem tables.Ledger) (Verdict, error)
+}
+
+type Verdict struct {
+	Flagged bool
+	Label   string // Why the content was flagged, e.g. a model category.
+}
+
+var classifiersMu sync.RWMutex
+var classifiers = map[string]Classifier{
+	linkSpamClassifier{}.Name(): linkSpamClassifier{},
+}
+
+// Registers a classifier before the source registry is initialized.
+func RegisterClassifier(c Classifier) {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+	classifiers[c.Name()] = c
+}
+
+func getClassifier(name string) (Classifier, error) {
+	classifiersMu.RLock()
+	defer classifiersMu.RUnlock()
+	c, ok := classifiers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown moderation classifier: %s", name)
+	}
+	return c, nil
+}
+
+// Flags payloads that are mostly links.
+type linkSpamClassifier struct{}
+
+const (
+	linkSpamMinWords    = 10
+	linkSpamMaxLinkRate = 0.5
+)
+
+func (c linkSpamClassifier) Name() string {
+	return "link_spam"
+}
+
+func (c linkSpamClassifier) Classify(ledgerItem tables.Ledger) (Verdict, error) {
+	words := strings.Fields(ledgerItem.TriggerEventPayload)
+	if len(words) < linkSpamMinWords {
+		return Verdict{}, nil
+	}
+	links := 0
+	for _, w := range words {
+		if strings.HasPrefix(w, "http://") || strings.HasPrefix(w, "https://") || strings.HasPrefix(w, "www.") {
+			links++
+		}
+	}
+	if float64(links)/float64(len(words)) > linkSpamMaxLinkRate {
+		return Verdict{Flagged: true, Label: fmt.Sprintf("%d of %d words are links", links, len(words))}, nil
+	}
+	return Verdict{}, nil
+}
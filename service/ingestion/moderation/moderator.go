@@ -0,0 +1,190 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+)
+
+const (
+	REASON_BLOCKED_KEYWORD  = "blocked_keyword"
+	REASON_BLOCKED_PATTERN  = "blocked_pattern"
+	REASON_MAX_LENGTH       = "max_length"
+	REASON_URL_NOT_ALLOWED  = "url_not_allowed"
+	REASON_CLASSIFIER       = "classifier"
+	REASON_CLASSIFIER_ERROR = "classifier_error" // Fails closed; the event may be resubmitted.
+)
+
+type Rejection struct {
+	Scope  string // default, source:<sourceName>, or niche:<niche>
+	Reason string
+	Detail string
+}
+
+type ruleSet struct {
+	scope             string
+	keywords          []*regexp.Regexp
+	patterns          []*regexp.Regexp
+	maxPayloadChars   int
+	allowedUrlDomains []string
+	classifiers       []Classifier
+}
+
+// Compiled moderation rules, resolved per source.
+type Moderator struct {
+	sourceRules map[string][]ruleSet
+	defaults    []ruleSet
+}
+
+// Compiles the manifest rules; every invalid rule is reported.
+func NewModerator(m *manifest.ManifestLoader) (*Moderator, error) {
+	errs := []error{}
+	compile := func(scope string, rules manifest.ModerationRules) ruleSet {
+		set, err := compileRules(scope, rules)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return set
+	}
+
+	moderator := &Moderator{sourceRules: map[string][]ruleSet{}}
+	moderator.defaults = []ruleSet{compile("default", m.ModerationRules.DefaultRules)}
+
+	nicheRules := map[string][]ruleSet{}
+	for _, rules := range m.ModerationRules.NicheRules {
+		nicheRules[rules.Niche] = append(nicheRules[rules.Niche], compile("niche:"+rules.Niche, rules))
+	}
+	for _, rules := range m.ModerationRules.SourceRules {
+		if _, ok := m.GetSource(rules.SourceName); !ok {
+			errs = append(errs, fmt.Errorf("moderation rules %s do not match a registered source", rules.SourceName))
+		}
+		set := compile("source:"+rules.SourceName, rules)
+		moderator.sourceRules[rules.SourceName] = append(moderator.sourceRules[rules.SourceName], set)
+	}
+
+	for _, s := range m.SourceToScriptCategoryCollection.Sources {
+		niches := map[string]bool{}
+		for _, p := range m.GetScriptPromptsFromSource(s.SourceName) {
+			niche := p.GetNiche()
+			if !niches[niche] {
+				niches[niche] = true
+				moderator.sourceRules[s.SourceName] = append(moderator.sourceRules[s.SourceName], nicheRules[niche]...)
+			}
+		}
+	}
+	return moderator, errors.Join(errs...)
+}
+
+func compileRules(scope string, rules manifest.ModerationRules) (ruleSet, error) {
+	errs := []error{}
+	set := ruleSet{scope: scope, maxPayloadChars: rules.MaxPayloadChars}
+	for _, k := range rules.BlockedKeywords {
+		// Whole words or phrases, tolerant of varied whitespace.
+		words := strings.Fields(k)
+		for i := range words {
+			words[i] = regexp.QuoteMeta(words[i])
+		}
+		if len(words) == 0 {
+			continue
+		}
+		set.keywords = append(set.keywords, regexp.MustCompile(`(?i)(^|[^\pL\pN])`+strings.Join(words, `\s+`)+`($|[^\pL\pN])`))
+	}
+	for _, p := range rules.BlockedPatterns {
+		compiled, err := regexp.Compile(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("moderation rules %s have invalid pattern %q: %w", scope, p, err))
+			continue
+		}
+		set.patterns = append(set.patterns, compiled)
+	}
+	for _, d := range rules.AllowedUrlDomains {
+		set.allowedUrlDomains = append(set.allowedUrlDomains, strings.ToLower(strings.TrimPrefix(d, ".")))
+	}
+	for _, name := range rules.Classifiers {
+		c, err := getClassifier(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("moderation rules %s: %w", scope, err))
+			continue
+		}
+		set.classifiers = append(set.classifiers, c)
+	}
+	return set, errors.Join(errs...)
+}
+
+// Returns the first rule the ledger breaks, checking default, source, then niche rules.
+func (m *Moderator) Evaluate(ledgerItem tables.Ledger) (Rejection, bool) {
+	sets := append(append([]ruleSet{}, m.defaults...), m.sourceRules[ledgerItem.TriggerEventSource]...)
+	for _, set := range sets {
+		if rejection, rejected := set.evaluate(ledgerItem); rejected {
+			rejection.Scope = set.scope
+			return rejection, true
+		}
+	}
+	return Rejection{}, false
+}
+
+func (s ruleSet) evaluate(ledgerItem tables.Ledger) (Rejection, bool) {
+	text := ledgerItem.TriggerEventPayload
+	if length := utf8.RuneCountInString(text); s.maxPayloadChars > 0 && length > s.maxPayloadChars {
+		return Rejection{Reason: REASON_MAX_LENGTH, Detail: fmt.Sprintf("%d chars exceeds %d", length, s.maxPayloadChars)}, true
+	}
+	for _, k := range s.keywords {
+		if k.MatchString(text) {
+			return Rejection{Reason: REASON_BLOCKED_KEYWORD, Detail: k.String()}, true
+		}
+	}
+	for _, p := range s.patterns {
+		if p.MatchString(text) {
+			return Rejection{Reason: REASON_BLOCKED_PATTERN, Detail: p.String()}, true
+		}
+	}
+	if len(s.allowedUrlDomains) > 0 {
+		for _, u := range ledgerUrls(ledgerItem) {
+			if !s.isAllowedUrl(u) {
+				return Rejection{Reason: REASON_URL_NOT_ALLOWED, Detail: u}, true
+			}
+		}
+	}
+	for _, c := range s.classifiers {
+		verdict, err := c.Classify(ledgerItem)
+		if err != nil {
+			return Rejection{Reason: REASON_CLASSIFIER_ERROR, Detail: c.Name() + ": " + err.Error()}, true
+		}
+		if verdict.Flagged {
+			return Rejection{Reason: REASON_CLASSIFIER, Detail: c.Name() + ": " + verdict.Label}, true
+		}
+	}
+	return Rejection{}, false
+}
+
+func (s ruleSet) isAllowedUrl(rawUrl string) bool {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, d := range s.allowedUrlDomains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func ledgerUrls(ledgerItem tables.Ledger) []string {
+	urls := []string{}
+	for _, csv := range []string{ledgerItem.TriggerEventMediaUrls, ledgerItem.TriggerEventWebsiteUrls} {
+		for _, u := range strings.Split(csv, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubClassifier struct {
+	verdict Verdict
+	err     error
+}
+
+func (c stubClassifier) Name() string {
+	return "stub"
+}
+
+func (c stubClassifier) Classify(ledgerItem tables.Ledger) (Verdict, error) {
+	return c.verdict, c.err
+}
+
+func testModerationManifest(rules manifest.ModerationRuleCollection) *manifest.ManifestLoader {
+	return &manifest.ManifestLoader{
+		ScriptPrompts: manifest.ScriptPromptCollection{
+			ScriptPrompts: []manifest.Prompt{{PromptCategoryKey: "Blog.Personal"}, {PromptCategoryKey: "Blog.NewsUS"}},
+		},
+		SourceToScriptCategoryCollection: manifest.SourceCollection{Sources: []manifest.Source{
+			{SourceName: "blog", ScriptCategories: []manifest.ScriptCategory{{CategoryKey: "Blog.Personal"}}},
+			{SourceName: "news", ScriptCategories: []manifest.ScriptCategory{{CategoryKey: "Blog.NewsUS"}}},
+		}},
+		ModerationRules: rules,
+	}
+}
+
+func TestModeratorEvaluate(t *testing.T) {
+	m := testModerationManifest(manifest.ModerationRuleCollection{
+		DefaultRules: manifest.ModerationRules{
+			MaxPayloadChars: 40,
+			BlockedKeywords: []string{"free  money"},
+		},
+		SourceRules: []manifest.ModerationRules{
+			{SourceName: "news", BlockedPatterns: []string{`(?i)\bcasino\b`}},
+		},
+		NicheRules: []manifest.ModerationRules{
+			{Niche: "Personal", AllowedUrlDomains: []string{"example.com"}},
+		},
+	})
+	moderator, err := NewModerator(m)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		ledger tables.Ledger
+		scope  string
+		reason string
+	}{
+		{tables.Ledger{TriggerEventSource: "blog", TriggerEventPayload: "hello"}, "", ""},
+		{tables.Ledger{TriggerEventSource: "blog", TriggerEventPayload: strings.Repeat("a", 41)}, "default", REASON_MAX_LENGTH},
+		{tables.Ledger{TriggerEventSource: "blog", TriggerEventPayload: "Get FREE money now"}, "default", REASON_BLOCKED_KEYWORD},
+		{tables.Ledger{TriggerEventSource: "blog", TriggerEventPayload: "freemoney"}, "", ""},
+		{tables.Ledger{TriggerEventSource: "news", TriggerEventPayload: "New Casino opens"}, "source:news", REASON_BLOCKED_PATTERN},
+		{tables.Ledger{TriggerEventSource: "blog", TriggerEventPayload: "New Casino opens"}, "", ""},
+		{tables.Ledger{TriggerEventSource: "blog", TriggerEventMediaUrls: "https://cdn.example.com/a.png"}, "", ""},
+		{tables.Ledger{TriggerEventSource: "blog", TriggerEventMediaUrls: "https://example.com.evil.io/a.png"}, "niche:Personal", REASON_URL_NOT_ALLOWED},
+		{tables.Ledger{TriggerEventSource: "news", TriggerEventMediaUrls: "https://evil.io/a.png"}, "", ""},
+	}
+	for _, c := range cases {
+		rejection, rejected := moderator.Evaluate(c.ledger)
+		assert.Equal(t, c.reason != "", rejected, "unexpected verdict for: %+v", c.ledger)
+		assert.Equal(t, c.scope, rejection.Scope)
+		assert.Equal(t, c.reason, rejection.Reason)
+	}
+}
+
+func TestModeratorClassifiers(t *testing.T) {
+	rules := manifest.ModerationRuleCollection{DefaultRules: manifest.ModerationRules{Classifiers: []string{"stub"}}}
+	ledger := tables.Ledger{TriggerEventSource: "blog", TriggerEventPayload: "hello"}
+
+	RegisterClassifier(stubClassifier{verdict: Verdict{Flagged: true, Label: "toxic"}})
+	moderator, err := NewModerator(testModerationManifest(rules))
+	assert.Nil(t, err)
+	rejection, rejected := moderator.Evaluate(ledger)
+	assert.True(t, rejected)
+	assert.Equal(t, REASON_CLASSIFIER, rejection.Reason)
+	assert.Equal(t, "stub: toxic", rejection.Detail)
+
+	RegisterClassifier(stubClassifier{err: errors.New("timeout")})
+	moderator, _ = NewModerator(testModerationManifest(rules))
+	rejection, _ = moderator.Evaluate(ledger)
+	assert.Equal(t, REASON_CLASSIFIER_ERROR, rejection.Reason, "expected classifier errors to fail closed")
+
+	spam := tables.Ledger{TriggerEventPayload: strings.Repeat("https://spam.io ", 8) + "buy now please"}
+	verdict, _ := linkSpamClassifier{}.Classify(spam)
+	assert.True(t, verdict.Flagged)
+}
+
+func TestNewModeratorInvalidRules(t *testing.T) {
+	cases := map[string]manifest.ModerationRuleCollection{
+		"invalid pattern":    {DefaultRules: manifest.ModerationRules{BlockedPatterns: []string{"("}}},
+		"unknown classifier": {DefaultRules: manifest.ModerationRules{Classifiers: []string{"missing"}}},
+		"unknown source":     {SourceRules: []manifest.ModerationRules{{SourceName: "missing"}}},
+	}
+	for name, rules := range cases {
+		_, err := NewModerator(testModerationManifest(rules))
+		assert.NotNil(t, err, "expected error for: "+name)
+	}
+}
@@ -0,0 +1,65 @@
+package ingestion
+
+import (
+	"log"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/bezalel-media-core/v2/service/ingestion/similarity"
+)
+
+type nearDuplicate struct {
+	ledgerID   string
+	similarity float64
+}
+
+// Most similar recent ledger of the same source at or above the source's similarityThreshold.
+// Lookup failures are logged and treated as no match; exact-hash dedupe still applies.
+func findNearDuplicate(ledgerItem tables.Ledger) (nearDuplicate, bool) {
+	source, ok := manifest.GetManifestLoader().GetSource(ledgerItem.TriggerEventSource)
+	if !ok || source.SimilarityThreshold <= 0 {
+		return nearDuplicate{}, false
+	}
+	signature, ok := similarity.MinHash(ledgerItem.TriggerEventPayload)
+	if !ok {
+		return nearDuplicate{}, false
+	}
+
+	candidates, err := dal.GetFingerprintCandidates(ledgerItem.TriggerEventSource, signature.Bands())
+	if err != nil {
+		log.Printf("failed to get fingerprint candidates: %s", err)
+		return nearDuplicate{}, false
+	}
+
+	best := nearDuplicate{}
+	for _, c := range candidates {
+		candidateSignature, err := similarity.DecodeSignature(c.Signature)
+		if err != nil {
+			log.Printf("correlationID: %s malformed fingerprint signature: %s", c.LedgerID, err)
+			continue
+		}
+		score := similarity.Similarity(signature, candidateSignature)
+		if score >= source.SimilarityThreshold && score > best.similarity {
+			best = nearDuplicate{ledgerID: c.LedgerID, similarity: score}
+		}
+	}
+	return best, best.ledgerID != ""
+}
+
+// Records the ledger's signature so later near-duplicates can be linked to it.
+func saveFingerprint(ledgerItem tables.Ledger) {
+	source, ok := manifest.GetManifestLoader().GetSource(ledgerItem.TriggerEventSource)
+	if !ok || source.SimilarityThreshold <= 0 {
+		return
+	}
+	signature, ok := similarity.MinHash(ledgerItem.TriggerEventPayload)
+	if !ok {
+		return
+	}
+
+	err := dal.CreateFingerprintEntries(ledgerItem.TriggerEventSource, ledgerItem.LedgerID, signature.Encode(), signature.Bands())
+	if err != nil {
+		log.Printf("correlationID: %s failed to create fingerprint entries: %s", ledgerItem.LedgerID, err)
+	}
+}
@@ -0,0 +1,77 @@
+package ingestion
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	env "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+)
+
+const parkedEventsPerLane = 25
+
+func parkEvent(ledgerItem tables.Ledger, source string, requestedLane string, reason string) error {
+	m := manifest.GetManifestLoader()
+	lane := effectiveLane(m, m.GetAdmissionPolicy(source).Lane, requestedLane)
+	ttl := time.Duration(env.GetEnvConfigs().ParkedEventTTLHours) * time.Hour
+	return dal.CreateParkedEvent(ledgerItem, lane, reason, int64(ttl.Seconds()))
+}
+
+// Retries parked events oldest first, draining higher-priority lanes before lower ones.
+func RetryParkedEvents() {
+	lanes := append([]manifest.AdmissionLane{}, manifest.GetManifestLoader().AdmissionPolicies.Lanes...)
+	sort.SliceStable(lanes, func(i, j int) bool { return lanes[i].Rank < lanes[j].Rank })
+
+	for _, lane := range lanes {
+		entries, err := dal.GetParkedEvents(lane.Lane, parkedEventsPerLane)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			admitted, reserved, reason := admitEvent(entry.Source, entry.Lane)
+			if !admitted {
+				dal.RecordParkedEventAttempt(entry, reason)
+				if reason == ADMISSION_LANE_AT_CAPACITY {
+					// Lower lanes have lower ceilings; nothing else can be admitted this round.
+					return
+				}
+				continue
+			}
+			entry.Ledger.AdmissionReserved = reserved
+			retryParkedEvent(entry)
+		}
+	}
+}
+
+func retryParkedEvent(entry dal.ParkedEventEntry) {
+	ledgerId := entry.Ledger.LedgerID
+	existing, err := dal.GetLedger(ledgerId)
+	if err != nil {
+		releaseParkedEventAdmission(entry)
+		return
+	}
+	if existing.LedgerID != "" {
+		// Created by an earlier attempt that failed to delete the parked entry.
+		releaseParkedEventAdmission(entry)
+		dal.DeleteParkedEvent(entry)
+		return
+	}
+
+	err = dal.CreateLedger(entry.Ledger)
+	if err != nil {
+		log.Printf("correlationID: %s failed to create ledger from parked event: %s", ledgerId, err)
+		releaseParkedEventAdmission(entry)
+		return
+	}
+	dal.DeleteParkedEvent(entry)
+}
+
+// Frees the slots admission took for the retry when no ledger was created from it.
+func releaseParkedEventAdmission(entry dal.ParkedEventEntry) {
+	if entry.Ledger.AdmissionReserved {
+		dal.ReleaseAdmission(entry.Source)
+	}
+}
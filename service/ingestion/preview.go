@@ -0,0 +1,64 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/bezalel-media-core/v2/service/orchestration"
+)
+
+// Runs the source's driver and renders its script prompts without writing a ledger,
+// reading or writing dedupe entries, or publishing.
+func PreviewSourcePayload(source string, payloadIO io.ReadCloser) (models_v1.PreviewResult, error) {
+	result := models_v1.PreviewResult{Source: source}
+	driver, err := GetPreviewDriver(source, payloadIO)
+	if err != nil {
+		log.Printf("error retreiving preview driver: %s", err)
+		result.Error = err.Error()
+		return result, err
+	}
+	ledgerItem, err := prepareLedger(driver)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.TargetLanguage = ledgerItem.TriggerEventTargetLanguage
+	result.ContentHash = ledgerItem.TriggerEventContentHash
+	result.Payload = ledgerItem.TriggerEventPayload
+	result.MediaUrls = ledgerItem.TriggerEventMediaUrls
+	result.WebsiteUrls = ledgerItem.TriggerEventWebsiteUrls
+	if ledgerItem.TriggerEventRedactions != "" {
+		result.Redactions = json.RawMessage(ledgerItem.TriggerEventRedactions)
+	}
+	if rejection, rejected := moderateLedger(ledgerItem); rejected {
+		result.Moderation = rejection.Reason
+	}
+
+	m := manifest.GetManifestLoader()
+	prompts := m.GetScriptPromptsFromSource(source)
+	if len(prompts) == 0 {
+		err = fmt.Errorf("no prompts received from source: %s", source)
+		result.Error = err.Error()
+		return result, err
+	}
+	for _, p := range prompts {
+		script := models_v1.ScriptPreview{PromptCategoryKey: p.PromptCategoryKey}
+		mediaEvent, err := orchestration.RenderScriptPrompt(p, ledgerItem)
+		if err != nil {
+			script.Error = err.Error()
+			result.Scripts = append(result.Scripts, script)
+			continue
+		}
+		script.EventID = mediaEvent.EventID
+		script.DistributionFormat = string(mediaEvent.DistributionFormat)
+		script.Channels = m.ChannelNamesFromFormat(script.DistributionFormat)
+		script.SystemPrompt = mediaEvent.SystemPromptInstruction
+		script.Prompt = mediaEvent.PromptInstruction
+		result.Scripts = append(result.Scripts, script)
+	}
+	return result, nil
+}
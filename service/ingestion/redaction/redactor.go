@@ -0,0 +1,108 @@
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	KIND_PROFILE_URL = "PROFILE_URL"
+	KIND_EMAIL       = "EMAIL"
+	KIND_ADDRESS     = "ADDRESS"
+	KIND_PHONE       = "PHONE"
+	KIND_HANDLE      = "HANDLE"
+)
+
+// Audit of the personal information stripped from a payload.
+// Values are keyed-hashed rather than kept so the report itself holds no personal information.
+type Report struct {
+	Redactions []Redaction `json:"redactions"`
+}
+
+type Redaction struct {
+	Kind        string `json:"kind"`
+	Placeholder string `json:"placeholder"`
+	ValueHash   string `json:"valueHash,omitempty"` // HMAC-SHA256 of the lowercased value; omitted without a hash key.
+	Occurrences int    `json:"occurrences"`
+}
+
+type detector struct {
+	kind    string
+	pattern *regexp.Regexp // When the pattern has a capture group, only the group is redacted.
+}
+
+// Ordered so that broader matches win, e.g. profile urls before the handles within them.
+var detectors = []detector{
+	{KIND_PROFILE_URL, regexp.MustCompile(`(?i)\b(?:https?://)?(?:www\.|m\.|old\.)?(?:` +
+		`(?:twitter|x|instagram|facebook|threads|github)\.com/@?[A-Za-z0-9_.-]+` +
+		`|tiktok\.com/@[A-Za-z0-9_.-]+` +
+		`|youtube\.com/(?:@|c/|user/|channel/)[A-Za-z0-9_.-]+` +
+		`|linkedin\.com/in/[A-Za-z0-9_%-]+` +
+		`|reddit\.com/(?:u|user)/[A-Za-z0-9_-]+` +
+		`)/?`)},
+	{KIND_EMAIL, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{KIND_ADDRESS, regexp.MustCompile(`\b\d{1,6}\s+(?:[NSEW]\.?\s+)?(?:[A-Z][A-Za-z]+\.?\s+){1,4}` +
+		`(?i:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl|Terrace|Ter|Circle|Cir|Highway|Hwy|Parkway|Pkwy)\b\.?` +
+		`(?:,?\s+(?i:Apt|Apartment|Suite|Ste|Unit)\.?\s*#?[A-Za-z0-9-]+|,?\s+#[A-Za-z0-9-]+)?`)},
+	{KIND_PHONE, regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)\s?|\b\d{3}[\s.-])\d{3}[\s.-]\d{4}\b` +
+		`|\+\d{1,3}(?:[\s.-]\d{2,4}){3,5}\b`)},
+	{KIND_HANDLE, regexp.MustCompile(`(?:^|[^\w@./])(@[A-Za-z0-9_]{2,30}\b|/?\bu/[A-Za-z0-9_-]{3,20}\b)`)},
+}
+
+// Replaces personal information with numbered placeholders, e.g. [EMAIL_1].
+// Repeated values share a placeholder so the text stays coherent for prompting.
+// Values are hashed with hashKey, which keeps short values such as phone numbers from being brute forced.
+func Redact(text string, hashKey []byte) (string, Report) {
+	report := Report{Redactions: []Redaction{}}
+	placeholders := map[string]int{} // kind + value to report index.
+	counts := map[string]int{}
+
+	for _, d := range detectors {
+		text = replaceMatches(text, d.pattern, func(value string) string {
+			key := d.kind + "\x00" + strings.ToLower(value)
+			i, ok := placeholders[key]
+			if !ok {
+				counts[d.kind]++
+				i = len(report.Redactions)
+				placeholders[key] = i
+				report.Redactions = append(report.Redactions, Redaction{
+					Kind:        d.kind,
+					Placeholder: fmt.Sprintf("[%s_%d]", d.kind, counts[d.kind]),
+					ValueHash:   hashValue(value, hashKey),
+				})
+			}
+			report.Redactions[i].Occurrences++
+			return report.Redactions[i].Placeholder
+		})
+	}
+	return text, report
+}
+
+func replaceMatches(text string, pattern *regexp.Regexp, replace func(string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(replace(text[start:end]))
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+func hashValue(value string, hashKey []byte) string {
+	if len(hashKey) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write([]byte(strings.ToLower(value)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
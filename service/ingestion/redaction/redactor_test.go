@@ -0,0 +1,39 @@
+package redaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	text := "Email me at Jane.Doe@example.com or jane.doe@example.com, call (206) 555-0147 or +44 20 7946 0958.\n" +
+		"I live at 1234 N Maple Grove Ave, Apt 5B and my ex @jdoe_99 posted on u/throwaway_jd.\n" +
+		"See https://twitter.com/jdoe_99 and https://www.linkedin.com/in/jane-doe-123/ but read https://news.example.com/story/1."
+
+	redacted, report := Redact(text, []byte("test-key"))
+	assert.Equal(t, "Email me at [EMAIL_1] or [EMAIL_1], call [PHONE_1] or [PHONE_2].\n"+
+		"I live at [ADDRESS_1] and my ex [HANDLE_1] posted on [HANDLE_2].\n"+
+		"See [PROFILE_URL_1] and [PROFILE_URL_2] but read https://news.example.com/story/1.", redacted)
+
+	kinds := map[string]int{}
+	for _, r := range report.Redactions {
+		kinds[r.Kind]++
+		assert.Len(t, r.ValueHash, 64)
+		assert.NotContains(t, r.ValueHash, "@")
+	}
+	assert.Equal(t, map[string]int{KIND_EMAIL: 1, KIND_PHONE: 2, KIND_ADDRESS: 1, KIND_HANDLE: 2, KIND_PROFILE_URL: 2}, kinds)
+	assert.Equal(t, 2, report.Redactions[2].Occurrences, "expected case-insensitive repeats to share a placeholder")
+
+	_, rekeyed := Redact(text, []byte("other-key"))
+	assert.NotEqual(t, report.Redactions[0].ValueHash, rekeyed.Redactions[0].ValueHash)
+	_, unkeyed := Redact(text, nil)
+	assert.Empty(t, unkeyed.Redactions[0].ValueHash)
+}
+
+func TestRedactKeepsOrdinaryText(t *testing.T) {
+	text := "In 2024 the team scored 3 goals in 90 minutes; version 1.2.3 shipped to 10,000 users. Price: $19.99."
+	redacted, report := Redact(text, nil)
+	assert.Equal(t, text, redacted)
+	assert.Empty(t, report.Redactions)
+}
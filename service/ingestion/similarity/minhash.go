@@ -0,0 +1,128 @@
+package similarity
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+const shingleSize = 3 // words per shingle
+
+// 16 bands of 4 rows: pairs with Jaccard similarity 0.7 become LSH candidates ~99% of the time,
+// while unrelated texts almost never share a band.
+const BandCount = 16
+const rowsPerBand = 4
+const SignatureSize = BandCount * rowsPerBand
+
+// Fewer words than this produce unstable signatures; such texts are only exact-deduped.
+const MinSignatureWords = 8
+
+type Signature []uint32
+
+var hashSeeds = newHashSeeds(SignatureSize)
+
+// Lowercases, strips punctuation, and collapses whitespace so formatting-only edits sign identically.
+func NormalizeText(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// MinHash signature over word shingles of the normalized text.
+func MinHash(text string) (Signature, bool) {
+	words := NormalizeText(text)
+	if len(words) < MinSignatureWords {
+		return Signature{}, false
+	}
+
+	signature := make(Signature, SignatureSize)
+	for i := range signature {
+		signature[i] = ^uint32(0)
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+shingleSize], " ")))
+		shingleHash := h.Sum64()
+		for s, seed := range hashSeeds {
+			v := uint32(mix64(shingleHash^seed) >> 32)
+			if v < signature[s] {
+				signature[s] = v
+			}
+		}
+	}
+	return signature, true
+}
+
+// Estimated Jaccard similarity of the shingle sets, [0, 1].
+func Similarity(a Signature, b Signature) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	matches := 0
+	for i := range a {
+		if a[i] == b[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(a))
+}
+
+// Band keys for LSH lookup; <bandIndex>.<bandHashHex>.
+func (s Signature) Bands() []string {
+	result := []string{}
+	for b := 0; b < BandCount && (b+1)*rowsPerBand <= len(s); b++ {
+		h := fnv.New64a()
+		for _, v := range s[b*rowsPerBand : (b+1)*rowsPerBand] {
+			binary.Write(h, binary.BigEndian, v)
+		}
+		result = append(result, fmt.Sprintf("%d.%016x", b, h.Sum64()))
+	}
+	return result
+}
+
+func (s Signature) Encode() string {
+	buf := make([]byte, 4*len(s))
+	for i, v := range s {
+		binary.BigEndian.PutUint32(buf[i*4:], v)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func DecodeSignature(encoded string) (Signature, error) {
+	buf, err := hex.DecodeString(encoded)
+	if err != nil {
+		return Signature{}, err
+	}
+	if len(buf)%4 != 0 {
+		return Signature{}, errors.New("malformed signature length")
+	}
+	result := make(Signature, len(buf)/4)
+	for i := range result {
+		result[i] = binary.BigEndian.Uint32(buf[i*4:])
+	}
+	return result, nil
+}
+
+// splitmix64 finalizer; derives independent hash functions from a single shingle hash.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func newHashSeeds(count int) []uint64 {
+	seeds := make([]uint64, count)
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}
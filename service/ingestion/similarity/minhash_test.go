@@ -0,0 +1,59 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const forumDump = `Main Post: My landlord kept my entire security deposit after I moved out, claiming the carpet
+was damaged even though it was already worn when I moved in three years ago. I have photos from move-in day.
+Post Comments: Send a demand letter citing your state deposit law. Small claims court is cheap and landlords
+often settle once they see the photos. Check whether normal wear and tear is excluded in your lease.`
+
+func TestMinHashIgnoresFormatting(t *testing.T) {
+	a, ok := MinHash(forumDump)
+	assert.True(t, ok)
+	b, _ := MinHash("  MAIN POST:   my landlord kept my entire security deposit, after I moved out -- claiming the carpet " +
+		"was damaged even though it was already worn when I moved in three years ago! I have photos from move-in day. " +
+		"Post comments: send a demand letter citing your state deposit law. Small claims court is cheap and landlords " +
+		"often settle once they see the photos. Check whether normal wear and tear is excluded in your lease.")
+	assert.Equal(t, a, b, "expected whitespace, case, and punctuation to be ignored")
+}
+
+func TestMinHashNearDuplicate(t *testing.T) {
+	a, _ := MinHash(forumDump)
+	b, _ := MinHash(forumDump + " Also document everything in writing.")
+	assert.GreaterOrEqual(t, Similarity(a, b), 0.8, "expected one extra comment to stay similar")
+	assert.True(t, sharesBand(a, b), "expected near duplicates to share an LSH band")
+
+	c, _ := MinHash(`The quarterly earnings report showed revenue growth across every region, driven by cloud
+subscriptions and a rebound in hardware sales after two slow years of supply chain constraints.`)
+	assert.Less(t, Similarity(a, c), 0.2, "expected unrelated text to be dissimilar")
+	assert.False(t, sharesBand(a, c), "expected unrelated text to share no LSH band")
+}
+
+func TestMinHashShortText(t *testing.T) {
+	_, ok := MinHash("too short to sign")
+	assert.False(t, ok)
+}
+
+func TestSignatureEncoding(t *testing.T) {
+	a, _ := MinHash(forumDump)
+	decoded, err := DecodeSignature(a.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, a, decoded)
+}
+
+func sharesBand(a Signature, b Signature) bool {
+	bandsB := map[string]bool{}
+	for _, band := range b.Bands() {
+		bandsB[band] = true
+	}
+	for _, band := range a.Bands() {
+		if bandsB[band] {
+			return true
+		}
+	}
+	return false
+}
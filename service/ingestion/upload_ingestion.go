@@ -0,0 +1,73 @@
+package ingestion
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	env "github.com/bezalel-media-core/v2/configuration"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/bezalel-media-core/v2/service/ingestion/drivers"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+)
+
+// Multipart overhead allowed on top of the file limits, e.g. part headers and text fields.
+const uploadRequestOverheadBytes = 1024 * 1024
+
+// The request body failed verification once streamed; see authorization.VerifySourceRequestStream.
+var ErrUnverifiedUpload = errors.New("upload not verified")
+
+// Entrypoint for upload sources; files are staged while the body is streamed, and verifyBody is called once it
+// was read. Staged files are promoted just before a new ledger is created, or parked, from them, and staged or
+// promoted files are discarded when no ledger is saved.
+func SaveSourceUploadToLedger(source string, w http.ResponseWriter, r *http.Request, verifyBody func() error) (models_v1.IngestionResult, error) {
+	s, ok := manifest.GetManifestLoader().GetSource(source)
+	if !ok || s.DriverType != manifest.DRIVER_TYPE_UPLOAD {
+		err := fmt.Errorf("source %s does not accept uploads", source)
+		return invalidResult(source, err), err
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadRequestBytes())
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return invalidResult(source, err), err
+	}
+
+	driver := drivers.NewUploadDriver(source, drivers.S3MediaStore{}, uploadLimits())
+	err = driver.WithMultipart(reader)
+	if err != nil {
+		log.Printf("error storing upload for source %s: %s", source, err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.Is(err, drivers.ErrUploadRejected) || errors.As(err, &maxBytesErr) {
+			return invalidResult(source, err), err
+		}
+		return failedResult(models_v1.IngestionResult{Source: source}, err), err
+	}
+	err = verifyBody()
+	if err != nil {
+		log.Printf("rejected upload for source %s: %s", source, err)
+		driver.Discard()
+		return models_v1.IngestionResult{Source: source}, fmt.Errorf("%w: %s", ErrUnverifiedUpload, err)
+	}
+
+	result, err := saveDriverToLedger(source, driver, "")
+	// Duplicates report the ledger of the earlier upload.
+	if result.LedgerID != driver.LedgerID() {
+		driver.Discard()
+	}
+	return result, err
+}
+
+func uploadLimits() drivers.UploadLimits {
+	return drivers.UploadLimits{
+		MaxFileBytes: env.GetEnvConfigs().MaxUploadFileBytes,
+		MaxFiles:     env.GetEnvConfigs().MaxUploadFiles,
+	}
+}
+
+// Upper bound of an upload request body, given the configured file limits.
+func MaxUploadRequestBytes() int64 {
+	limits := uploadLimits()
+	return limits.MaxFileBytes*int64(limits.MaxFiles) + uploadRequestOverheadBytes
+}
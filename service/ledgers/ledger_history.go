@@ -0,0 +1,253 @@
+package ledgers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+var ErrInvalidHistoryRange = errors.New("invalid ledger history version range")
+
+type LedgerHistoryView struct {
+	LedgerID string                   `json:"ledgerId"`
+	Appends  []LedgerAppendRecordView `json:"appends"`
+}
+
+// One append to the ledger and the events it added.
+type LedgerAppendRecordView struct {
+	HistoryVersion       int64              `json:"historyVersion"`
+	EventType            string             `json:"eventType"`
+	EventsVersion        int64              `json:"eventsVersion"`
+	WorkflowName         string             `json:"workflowName"`
+	ProcessID            string             `json:"processId"`
+	RecordedAtEpochMilli int64              `json:"recordedAtEpochMilli"`
+	MediaEvents          []MediaEventView   `json:"mediaEvents,omitempty"`
+	PublishEvents        []PublishEventView `json:"publishEvents,omitempty"`
+	Invalidation         *InvalidationView  `json:"invalidation,omitempty"` // Set for redrives.
+}
+
+// Events a redrive invalidated so the workflows re-execute them.
+type InvalidationView struct {
+	Stage           string   `json:"stage"`
+	Channel         string   `json:"channel,omitempty"`
+	MediaEventIDs   []string `json:"mediaEventIds"`
+	PublishEventIDs []string `json:"publishEventIds"`
+}
+
+// Changes between two history versions; events only leave a ledger when a redrive invalidates them.
+type LedgerDiffView struct {
+	LedgerID           string                   `json:"ledgerId"`
+	FromVersion        int64                    `json:"fromVersion"`
+	ToVersion          int64                    `json:"toVersion"`
+	Appends            []LedgerAppendRecordView `json:"appends"`
+	AddedMediaEvents   []MediaEventView         `json:"addedMediaEvents"`
+	AddedPublishEvents []PublishEventView       `json:"addedPublishEvents"`
+	PublishTransitions []PublishTransitionView  `json:"publishTransitions"`
+}
+
+// Latest publish status of one assignment, before and after the diffed range.
+type PublishTransitionView struct {
+	DistributionChannel string `json:"distributionChannel"`
+	AccountID           string `json:"accountId"`
+	PublisherProfileID  string `json:"publisherProfileId"`
+	RootMediaEventID    string `json:"rootMediaEventId"`
+	FromStatus          string `json:"fromStatus,omitempty"` // Empty when not yet assigned at FromVersion.
+	ToStatus            string `json:"toStatus"`             // INVALIDATED_STATUS when a redrive invalidated the latest status.
+}
+
+const INVALIDATED_STATUS = dal.LEDGER_EVENT_INVALIDATED
+
+// Returns false when the ledger has neither history nor a ledger item.
+func GetLedgerHistoryView(ledgerID string) (LedgerHistoryView, bool, error) {
+	history, found, err := getLedgerHistory(ledgerID)
+	if err != nil || !found {
+		return LedgerHistoryView{}, found, err
+	}
+	view := LedgerHistoryView{LedgerID: ledgerID, Appends: []LedgerAppendRecordView{}}
+	for _, h := range history {
+		record, err := toLedgerAppendRecordView(h)
+		if err != nil {
+			return view, true, err
+		}
+		view.Appends = append(view.Appends, record)
+	}
+	return view, true, nil
+}
+
+// A negative toVersion diffs through the latest version.
+func GetLedgerDiffView(ledgerID string, fromVersion int64, toVersion int64) (LedgerDiffView, bool, error) {
+	history, found, err := getLedgerHistory(ledgerID)
+	if err != nil || !found {
+		return LedgerDiffView{}, found, err
+	}
+	view, err := DiffLedgerHistory(ledgerID, history, fromVersion, toVersion)
+	return view, true, err
+}
+
+func getLedgerHistory(ledgerID string) ([]dal.LedgerHistoryEntry, bool, error) {
+	history, err := dal.GetLedgerHistory(ledgerID)
+	if err != nil {
+		log.Printf("correlationID: %s error retrieving ledger history: %s", ledgerID, err)
+		return history, false, err
+	}
+	if len(history) > 0 {
+		return history, true, nil
+	}
+	ledgerItem, err := dal.GetLedger(ledgerID)
+	if err != nil {
+		log.Printf("correlationID: %s error retrieving ledger for history: %s", ledgerID, err)
+		return history, false, err
+	}
+	return history, ledgerItem.LedgerID != "", nil
+}
+
+// Version 0 is the ledger before its first recorded append.
+func DiffLedgerHistory(ledgerID string, history []dal.LedgerHistoryEntry, fromVersion int64, toVersion int64) (LedgerDiffView, error) {
+	latest := int64(0)
+	if len(history) > 0 {
+		latest = history[len(history)-1].HistoryVersion
+	}
+	if toVersion < 0 {
+		toVersion = latest
+	}
+	if fromVersion < 0 || fromVersion > toVersion || toVersion > latest {
+		return LedgerDiffView{}, fmt.Errorf("%w: from %d to %d of latest %d", ErrInvalidHistoryRange, fromVersion, toVersion, latest)
+	}
+
+	view := LedgerDiffView{
+		LedgerID:           ledgerID,
+		FromVersion:        fromVersion,
+		ToVersion:          toVersion,
+		Appends:            []LedgerAppendRecordView{},
+		AddedMediaEvents:   []MediaEventView{},
+		AddedPublishEvents: []PublishEventView{},
+		PublishTransitions: []PublishTransitionView{},
+	}
+	fromStatus := map[string]tables.PublishEvent{}
+	toStatus := map[string]tables.PublishEvent{}
+	assignmentOrder := []string{}
+	assignmentOfEvent := map[string]string{} // publish EventID -> assignmentKey
+	for _, h := range history {
+		if h.HistoryVersion > toVersion {
+			break
+		}
+		invalidation, isInvalidation, err := h.GetInvalidation()
+		if err != nil {
+			log.Printf("correlationID: %s error decoding invalidation of history version %d: %s", ledgerID, h.HistoryVersion, err)
+			return view, err
+		}
+		if isInvalidation {
+			for _, id := range invalidation.PublishEventIDs {
+				key := assignmentOfEvent[id]
+				invalidateAssignment(toStatus, key, id)
+				if h.HistoryVersion <= fromVersion {
+					invalidateAssignment(fromStatus, key, id)
+				}
+			}
+		}
+		publishEvents, err := h.GetAddedPublishEvents()
+		if err != nil {
+			log.Printf("correlationID: %s error decoding publish events of history version %d: %s", ledgerID, h.HistoryVersion, err)
+			return view, err
+		}
+		for _, p := range publishEvents {
+			key := assignmentKey(p)
+			assignmentOfEvent[p.GetEventID()] = key
+			if _, ok := toStatus[key]; !ok {
+				assignmentOrder = append(assignmentOrder, key)
+			}
+			toStatus[key] = p
+			if h.HistoryVersion <= fromVersion {
+				fromStatus[key] = p
+			}
+		}
+		if h.HistoryVersion <= fromVersion {
+			continue
+		}
+
+		record, err := toLedgerAppendRecordView(h)
+		if err != nil {
+			return view, err
+		}
+		view.Appends = append(view.Appends, record)
+		view.AddedMediaEvents = append(view.AddedMediaEvents, record.MediaEvents...)
+		view.AddedPublishEvents = append(view.AddedPublishEvents, record.PublishEvents...)
+	}
+
+	for _, key := range assignmentOrder {
+		to := toStatus[key]
+		from, existed := fromStatus[key]
+		if existed && from.PublishStatus == to.PublishStatus {
+			continue
+		}
+		transition := PublishTransitionView{
+			DistributionChannel: to.DistributionChannel,
+			AccountID:           to.AccountID,
+			PublisherProfileID:  to.PublisherProfileID,
+			RootMediaEventID:    to.RootMediaEventID,
+			ToStatus:            string(to.PublishStatus),
+		}
+		if existed {
+			transition.FromStatus = string(from.PublishStatus)
+		}
+		view.PublishTransitions = append(view.PublishTransitions, transition)
+	}
+	return view, nil
+}
+
+// Marks the assignment invalidated when the invalidated event is its latest status.
+func invalidateAssignment(statuses map[string]tables.PublishEvent, key string, eventID string) {
+	latest, ok := statuses[key]
+	if ok && latest.GetEventID() == eventID {
+		latest.PublishStatus = INVALIDATED_STATUS
+		statuses[key] = latest
+	}
+}
+
+func assignmentKey(p tables.PublishEvent) string {
+	return fmt.Sprintf("%s.%s.%s.%s", p.DistributionChannel, p.AccountID, p.PublisherProfileID, p.RootMediaEventID)
+}
+
+func toLedgerAppendRecordView(h dal.LedgerHistoryEntry) (LedgerAppendRecordView, error) {
+	record := LedgerAppendRecordView{
+		HistoryVersion:       h.HistoryVersion,
+		EventType:            h.EventType,
+		EventsVersion:        h.EventsVersion,
+		WorkflowName:         h.WorkflowName,
+		ProcessID:            h.ProcessID,
+		RecordedAtEpochMilli: h.RecordedAtEpochMilli,
+	}
+	mediaEvents, err := h.GetAddedMediaEvents()
+	if err != nil {
+		log.Printf("correlationID: %s error decoding media events of history version %d: %s", h.LedgerID, h.HistoryVersion, err)
+		return record, err
+	}
+	for _, m := range mediaEvents {
+		record.MediaEvents = append(record.MediaEvents, toMediaEventView(m))
+	}
+	publishEvents, err := h.GetAddedPublishEvents()
+	if err != nil {
+		log.Printf("correlationID: %s error decoding publish events of history version %d: %s", h.LedgerID, h.HistoryVersion, err)
+		return record, err
+	}
+	for _, p := range publishEvents {
+		record.PublishEvents = append(record.PublishEvents, toPublishEventView(p))
+	}
+	invalidation, isInvalidation, err := h.GetInvalidation()
+	if err != nil {
+		log.Printf("correlationID: %s error decoding invalidation of history version %d: %s", h.LedgerID, h.HistoryVersion, err)
+		return record, err
+	}
+	if isInvalidation {
+		record.Invalidation = &InvalidationView{
+			Stage:           invalidation.Stage,
+			Channel:         invalidation.Channel,
+			MediaEventIDs:   invalidation.MediaEventIDs,
+			PublishEventIDs: invalidation.PublishEventIDs,
+		}
+	}
+	return record, nil
+}
@@ -0,0 +1,81 @@
+package ledgers
+
+import (
+	"testing"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLedgerHistory(t *testing.T) []dal.LedgerHistoryEntry {
+	store := dal.NewMemoryLedgerStore()
+	store.CreateLedger(tables.Ledger{LedgerID: "ledger-1"})
+	assigned := tables.PublishEvent{DistributionChannel: "Medium", AccountID: "account-1", PublisherProfileID: "profile-1",
+		RootMediaEventID: "Text.abc", PublishStatus: tables.ASSIGNED}
+	completed := assigned
+	completed.PublishStatus = tables.COMPLETE
+
+	store.AppendLedgerMediaEvents(dal.LedgerAudit{WorkflowName: "ScriptWorkflow", ProcessID: "p1"}, "ledger-1",
+		[]tables.MediaEvent{{EventID: "Text.abc", MediaType: tables.MEDIA_TEXT}})
+	store.AppendLedgerPublishEvents(dal.LedgerAudit{WorkflowName: "AssignmentWorkflow", ProcessID: "p2"}, "ledger-1",
+		[]tables.PublishEvent{assigned})
+	store.AppendLedgerPublishEvents(dal.LedgerAudit{WorkflowName: "PublishWorkFlow", ProcessID: "p3"}, "ledger-1",
+		[]tables.PublishEvent{completed})
+	history, err := store.GetLedgerHistory("ledger-1")
+	assert.NoError(t, err)
+	return history
+}
+
+func TestDiffLedgerHistory(t *testing.T) {
+	history := testLedgerHistory(t)
+
+	diff, err := DiffLedgerHistory("ledger-1", history, 1, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), diff.ToVersion)
+	assert.Len(t, diff.Appends, 2)
+	assert.Equal(t, "PublishWorkFlow", diff.Appends[1].WorkflowName)
+	assert.Empty(t, diff.AddedMediaEvents)
+	assert.Len(t, diff.AddedPublishEvents, 2)
+	assert.Equal(t, []PublishTransitionView{{
+		DistributionChannel: "Medium", AccountID: "account-1", PublisherProfileID: "profile-1",
+		RootMediaEventID: "Text.abc", FromStatus: "", ToStatus: string(tables.COMPLETE),
+	}}, diff.PublishTransitions)
+
+	diff, err = DiffLedgerHistory("ledger-1", history, 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, string(tables.ASSIGNED), diff.PublishTransitions[0].FromStatus)
+	assert.Equal(t, "p3", diff.Appends[0].ProcessID)
+
+	diff, err = DiffLedgerHistory("ledger-1", history, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Text.abc", diff.AddedMediaEvents[0].EventID)
+	assert.Empty(t, diff.PublishTransitions)
+}
+
+func TestDiffLedgerHistoryInvalidRange(t *testing.T) {
+	history := testLedgerHistory(t)
+	_, err := DiffLedgerHistory("ledger-1", history, 2, 1)
+	assert.ErrorIs(t, err, ErrInvalidHistoryRange)
+	_, err = DiffLedgerHistory("ledger-1", history, 0, 4)
+	assert.ErrorIs(t, err, ErrInvalidHistoryRange)
+}
+
+func TestDiffLedgerHistoryInvalidation(t *testing.T) {
+	store := dal.NewMemoryLedgerStore()
+	store.CreateLedger(tables.Ledger{LedgerID: "ledger-1"})
+	completed := tables.PublishEvent{DistributionChannel: "Medium", AccountID: "account-1", PublisherProfileID: "profile-1",
+		RootMediaEventID: "Text.abc", PublishStatus: tables.COMPLETE}
+	store.AppendLedgerPublishEvents(dal.LedgerAudit{WorkflowName: "PublishWorkFlow"}, "ledger-1", []tables.PublishEvent{completed})
+	store.InvalidateLedgerEvents(dal.LedgerAudit{WorkflowName: "Redrive"}, dal.LedgerInvalidation{
+		LedgerID: "ledger-1", Stage: "Publish", Channel: "Medium", PublishEventIDs: []string{completed.GetEventID()}})
+	history, _ := store.GetLedgerHistory("ledger-1")
+
+	diff, err := DiffLedgerHistory("ledger-1", history, 1, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, string(tables.COMPLETE), diff.PublishTransitions[0].FromStatus)
+	assert.Equal(t, INVALIDATED_STATUS, diff.PublishTransitions[0].ToStatus)
+	assert.Equal(t, &InvalidationView{Stage: "Publish", Channel: "Medium", PublishEventIDs: []string{completed.GetEventID()}},
+		diff.Appends[0].Invalidation)
+	assert.Empty(t, diff.AddedPublishEvents)
+}
@@ -0,0 +1,171 @@
+package ledgers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+const defaultListLimit = 50
+const maxListLimit = 200
+const payloadPreviewChars = 280
+
+var ErrInvalidListQuery = errors.New("invalid ledger list query")
+
+type LedgerListView struct {
+	Ledgers       []LedgerSummaryView `json:"ledgers"`
+	NextPageToken string              `json:"nextPageToken,omitempty"` // Pass as pageToken for the next page.
+}
+
+type LedgerSummaryView struct {
+	LedgerID                   string   `json:"ledgerId"`
+	LedgerStatus               string   `json:"ledgerStatus"`
+	LedgerCreatedAtEpochMilli  int64    `json:"ledgerCreatedAtEpochMilli"`
+	TriggerEventSource         string   `json:"triggerEventSource"`
+	TriggerEventTargetLanguage string   `json:"triggerEventTargetLanguage"`
+	HeartbeatCount             int64    `json:"heartbeatCount"`
+	HistoryVersion             int64    `json:"historyVersion"`
+	GeneratedTitles            []string `json:"generatedTitles"`
+	PayloadPreview             string   `json:"payloadPreview"`
+	// Set for Quarantined ledgers.
+	QuarantineReason        string              `json:"quarantineReason,omitempty"`
+	QuarantinedAtEpochMilli int64               `json:"quarantinedAtEpochMilli,omitempty"`
+	LastWorkflowErrors      []WorkflowErrorView `json:"lastWorkflowErrors,omitempty"`
+}
+
+type WorkflowErrorView struct {
+	WorkflowName string `json:"workflowName"`
+	Error        string `json:"error"`
+}
+
+type LedgerListQuery struct {
+	Filter    dal.LedgerFilter
+	Limit     int
+	PageToken string
+}
+
+// Query params: status, source, language, q (search text), createdAfter and createdBefore (epoch millis),
+// olderThan and newerThan (durations before now, e.g. 6h), order (asc or desc), limit and pageToken.
+func ParseLedgerListQuery(params url.Values, now time.Time) (LedgerListQuery, error) {
+	query := LedgerListQuery{
+		Filter: dal.LedgerFilter{
+			Source:   strings.TrimSpace(params.Get("source")),
+			Language: strings.TrimSpace(params.Get("language")),
+			Search:   strings.TrimSpace(params.Get("q")),
+		},
+		Limit:     defaultListLimit,
+		PageToken: params.Get("pageToken"),
+	}
+	var err error
+	if status := params.Get("status"); status != "" {
+		query.Filter.Status, err = tables.ParseLedgerStatus(status)
+		if err != nil {
+			return query, fmt.Errorf("%w: %s", ErrInvalidListQuery, err)
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > maxListLimit {
+			return query, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListQuery, maxListLimit)
+		}
+	}
+	switch params.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Filter.NewestFirst = true
+	default:
+		return query, fmt.Errorf("%w: order must be asc or desc", ErrInvalidListQuery)
+	}
+
+	query.Filter.CreatedAfterEpochMilli, err = parseEpochMilliParam(params, "createdAfter")
+	if err != nil {
+		return query, err
+	}
+	query.Filter.CreatedBeforeEpochMilli, err = parseEpochMilliParam(params, "createdBefore")
+	if err != nil {
+		return query, err
+	}
+	if olderThan := params.Get("olderThan"); olderThan != "" {
+		d, err := time.ParseDuration(olderThan)
+		if err != nil || d < 0 {
+			return query, fmt.Errorf("%w: olderThan must be a duration such as 6h", ErrInvalidListQuery)
+		}
+		before := now.Add(-d).UnixMilli()
+		if query.Filter.CreatedBeforeEpochMilli == 0 || before < query.Filter.CreatedBeforeEpochMilli {
+			query.Filter.CreatedBeforeEpochMilli = before
+		}
+	}
+	if newerThan := params.Get("newerThan"); newerThan != "" {
+		d, err := time.ParseDuration(newerThan)
+		if err != nil || d < 0 {
+			return query, fmt.Errorf("%w: newerThan must be a duration such as 30m", ErrInvalidListQuery)
+		}
+		after := now.Add(-d).UnixMilli()
+		if after > query.Filter.CreatedAfterEpochMilli {
+			query.Filter.CreatedAfterEpochMilli = after
+		}
+	}
+	if query.Filter.CreatedBeforeEpochMilli > 0 && query.Filter.CreatedBeforeEpochMilli <= query.Filter.CreatedAfterEpochMilli {
+		return query, fmt.Errorf("%w: empty created-at range", ErrInvalidListQuery)
+	}
+	return query, nil
+}
+
+func parseEpochMilliParam(params url.Values, name string) (int64, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	epochMilli, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || epochMilli < 0 {
+		return 0, fmt.Errorf("%w: %s must be epoch millis", ErrInvalidListQuery, name)
+	}
+	return epochMilli, nil
+}
+
+func ListLedgerViews(query LedgerListQuery) (LedgerListView, error) {
+	view := LedgerListView{Ledgers: []LedgerSummaryView{}}
+	page, err := dal.ListLedgers(query.Filter, query.Limit, query.PageToken)
+	if errors.Is(err, dal.ErrInvalidPageToken) {
+		return view, fmt.Errorf("%w: %s", ErrInvalidListQuery, err)
+	}
+	if err != nil {
+		log.Printf("error listing ledgers: %s", err)
+		return view, err
+	}
+	for _, l := range page.Ledgers {
+		view.Ledgers = append(view.Ledgers, ToLedgerSummaryView(l))
+	}
+	view.NextPageToken = page.NextPageToken
+	return view, nil
+}
+
+func ToLedgerSummaryView(ledgerItem tables.Ledger) LedgerSummaryView {
+	view := LedgerSummaryView{
+		LedgerID:                   ledgerItem.LedgerID,
+		LedgerStatus:               string(ledgerItem.LedgerStatus),
+		LedgerCreatedAtEpochMilli:  ledgerItem.LedgerCreatedAtEpochMilli,
+		TriggerEventSource:         ledgerItem.TriggerEventSource,
+		TriggerEventTargetLanguage: ledgerItem.TriggerEventTargetLanguage,
+		HeartbeatCount:             ledgerItem.HeartbeatCount,
+		HistoryVersion:             ledgerItem.HistoryVersion,
+		GeneratedTitles:            append([]string{}, ledgerItem.GeneratedTitles...),
+		PayloadPreview:             ledgerItem.TriggerEventPayload,
+		QuarantineReason:           ledgerItem.QuarantineReason,
+		QuarantinedAtEpochMilli:    ledgerItem.QuarantinedAtEpochMilli,
+	}
+	for _, e := range ledgerItem.LastWorkflowErrors {
+		view.LastWorkflowErrors = append(view.LastWorkflowErrors, WorkflowErrorView{WorkflowName: e.WorkflowName, Error: e.Error})
+	}
+	if runes := []rune(view.PayloadPreview); len(runes) > payloadPreviewChars {
+		view.PayloadPreview = string(runes[:payloadPreviewChars]) + "..."
+	}
+	return view
+}
@@ -0,0 +1,68 @@
+package ledgers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLedgerListQuery(t *testing.T) {
+	now := time.UnixMilli(100_000_000)
+	params := url.Values{"status": {"new"}, "source": {"v1/source/forum"}, "olderThan": {"6h"}, "order": {"desc"}, "limit": {"10"}}
+	query, err := ParseLedgerListQuery(params, now)
+	assert.NoError(t, err)
+	assert.Equal(t, tables.NEW_LEDGER, query.Filter.Status)
+	assert.Equal(t, "v1/source/forum", query.Filter.Source)
+	assert.Equal(t, now.Add(-6*time.Hour).UnixMilli(), query.Filter.CreatedBeforeEpochMilli)
+	assert.True(t, query.Filter.NewestFirst)
+	assert.Equal(t, 10, query.Limit)
+
+	query, err = ParseLedgerListQuery(url.Values{}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultListLimit, query.Limit)
+	assert.Equal(t, tables.LedgerStatus(""), query.Filter.Status)
+
+	// The tighter bound wins.
+	query, err = ParseLedgerListQuery(url.Values{"createdBefore": {"1000"}, "olderThan": {"1m"}}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), query.Filter.CreatedBeforeEpochMilli)
+
+	for _, bad := range []url.Values{
+		{"status": {"Stuck"}},
+		{"limit": {"0"}},
+		{"limit": {"1000"}},
+		{"order": {"sideways"}},
+		{"createdAfter": {"yesterday"}},
+		{"olderThan": {"6 hours"}},
+		{"createdAfter": {"5000"}, "createdBefore": {"5000"}},
+	} {
+		_, err = ParseLedgerListQuery(bad, now)
+		assert.ErrorIs(t, err, ErrInvalidListQuery, bad.Encode())
+	}
+}
+
+func TestToLedgerSummaryView(t *testing.T) {
+	ledger := tables.Ledger{LedgerID: "l1", LedgerStatus: tables.NEW_LEDGER, TriggerEventPayload: strings.Repeat("é", payloadPreviewChars+1)}
+	view := ToLedgerSummaryView(ledger)
+	assert.Equal(t, strings.Repeat("é", payloadPreviewChars)+"...", view.PayloadPreview)
+	assert.Equal(t, []string{}, view.GeneratedTitles)
+	assert.Equal(t, "New", view.LedgerStatus)
+}
+
+func TestToLedgerSummaryViewQuarantined(t *testing.T) {
+	ledger := tables.Ledger{LedgerID: "l1", LedgerStatus: tables.QUARANTINED_LEDGER, QuarantineReason: "heartbeat count 24 reached max 24",
+		QuarantinedAtEpochMilli: 5, LastWorkflowErrors: []tables.WorkflowError{{WorkflowName: "AssignmentWorkflow", Error: "no publisher profile"}}}
+	view := ToLedgerSummaryView(ledger)
+	assert.Equal(t, "Quarantined", view.LedgerStatus)
+	assert.Equal(t, "heartbeat count 24 reached max 24", view.QuarantineReason)
+	assert.Equal(t, int64(5), view.QuarantinedAtEpochMilli)
+	assert.Equal(t, []WorkflowErrorView{{WorkflowName: "AssignmentWorkflow", Error: "no publisher profile"}}, view.LastWorkflowErrors)
+
+	query, err := ParseLedgerListQuery(url.Values{"status": {"quarantined"}}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, tables.QUARANTINED_LEDGER, query.Filter.Status)
+}
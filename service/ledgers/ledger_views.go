@@ -0,0 +1,122 @@
+package ledgers
+
+import (
+	"encoding/json"
+	"log"
+
+	dal "github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Read-only view of a ledger's progress; omits prompts and payloads.
+type LedgerTrackingView struct {
+	LedgerID                   string             `json:"ledgerId"`
+	LedgerStatus               string             `json:"ledgerStatus"`
+	LedgerCreatedAtEpochMilli  int64              `json:"ledgerCreatedAtEpochMilli"`
+	TriggerEventSource         string             `json:"triggerEventSource"`
+	TriggerEventTargetLanguage string             `json:"triggerEventTargetLanguage"`
+	TriggerEventContentHash    string             `json:"triggerEventContentHash"`
+	TriggerEventRedactions     json.RawMessage    `json:"triggerEventRedactions,omitempty"` // Audit of stripped personal information.
+	HeartbeatCount             int64              `json:"heartbeatCount"`
+	MediaEvents                []MediaEventView   `json:"mediaEvents"`
+	PublishEvents              []PublishEventView `json:"publishEvents"`
+}
+
+type MediaEventView struct {
+	EventID               string `json:"eventId"`
+	ParentEventID         string `json:"parentEventId,omitempty"`
+	MediaType             string `json:"mediaType"`
+	DistributionFormat    string `json:"distributionFormat,omitempty"`
+	Niche                 string `json:"niche,omitempty"`
+	Language              string `json:"language,omitempty"`
+	ContentLookupKey      string `json:"contentLookupKey,omitempty"`
+	MetaMediaDescriptor   string `json:"metaMediaDescriptor,omitempty"`
+	RestrictToPublisherID string `json:"restrictToPublisherId,omitempty"`
+}
+
+type PublishEventView struct {
+	EventID              string `json:"eventId"`
+	DistributionChannel  string `json:"distributionChannel"`
+	PublishStatus        string `json:"publishStatus"`
+	PublisherProfileID   string `json:"publisherProfileId"`
+	RootMediaEventID     string `json:"rootMediaEventId"`
+	ExpiresAtTTL         int64  `json:"expiresAtTTL"`
+	ChannelContentIDsCsv string `json:"channelContentIdsCsv,omitempty"`
+}
+
+// Returns false when no ledger exists for the ID, e.g. expired by TTL.
+func GetLedgerTrackingView(ledgerID string) (LedgerTrackingView, bool, error) {
+	ledgerItem, err := dal.GetLedger(ledgerID)
+	if err != nil {
+		log.Printf("correlationID: %s error retrieving ledger for tracking view: %s", ledgerID, err)
+		return LedgerTrackingView{}, false, err
+	}
+	if ledgerItem.LedgerID == "" {
+		return LedgerTrackingView{}, false, nil
+	}
+
+	view, err := ToLedgerTrackingView(ledgerItem)
+	return view, true, err
+}
+
+func ToLedgerTrackingView(ledgerItem tables.Ledger) (LedgerTrackingView, error) {
+	view := LedgerTrackingView{
+		LedgerID:                   ledgerItem.LedgerID,
+		LedgerStatus:               string(ledgerItem.LedgerStatus),
+		LedgerCreatedAtEpochMilli:  ledgerItem.LedgerCreatedAtEpochMilli,
+		TriggerEventSource:         ledgerItem.TriggerEventSource,
+		TriggerEventTargetLanguage: ledgerItem.TriggerEventTargetLanguage,
+		TriggerEventContentHash:    ledgerItem.TriggerEventContentHash,
+		HeartbeatCount:             ledgerItem.HeartbeatCount,
+		MediaEvents:                []MediaEventView{},
+		PublishEvents:              []PublishEventView{},
+	}
+	if ledgerItem.TriggerEventRedactions != "" {
+		view.TriggerEventRedactions = json.RawMessage(ledgerItem.TriggerEventRedactions)
+	}
+
+	mediaEvents, err := ledgerItem.GetExistingMediaEvents()
+	if err != nil {
+		log.Printf("correlationID: %s error retrieving media events for tracking view: %s", ledgerItem.LedgerID, err)
+		return view, err
+	}
+	for _, m := range mediaEvents {
+		view.MediaEvents = append(view.MediaEvents, toMediaEventView(m))
+	}
+
+	publishEvents, err := ledgerItem.GetExistingPublishEvents()
+	if err != nil {
+		log.Printf("correlationID: %s error retrieving publish events for tracking view: %s", ledgerItem.LedgerID, err)
+		return view, err
+	}
+	for _, p := range publishEvents {
+		view.PublishEvents = append(view.PublishEvents, toPublishEventView(p))
+	}
+	return view, nil
+}
+
+func toMediaEventView(m tables.MediaEvent) MediaEventView {
+	return MediaEventView{
+		EventID:               m.EventID,
+		ParentEventID:         m.ParentEventID,
+		MediaType:             string(m.MediaType),
+		DistributionFormat:    string(m.DistributionFormat),
+		Niche:                 m.Niche,
+		Language:              m.Language,
+		ContentLookupKey:      m.ContentLookupKey,
+		MetaMediaDescriptor:   string(m.MetaMediaDescriptor),
+		RestrictToPublisherID: m.RestrictToPublisherID,
+	}
+}
+
+func toPublishEventView(p tables.PublishEvent) PublishEventView {
+	return PublishEventView{
+		EventID:              p.GetEventID(),
+		DistributionChannel:  p.DistributionChannel,
+		PublishStatus:        string(p.PublishStatus),
+		PublisherProfileID:   p.PublisherProfileID,
+		RootMediaEventID:     p.RootMediaEventID,
+		ExpiresAtTTL:         p.ExpiresAtTTL,
+		ChannelContentIDsCsv: p.ChannelContentIDsCsv,
+	}
+}
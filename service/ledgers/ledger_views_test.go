@@ -0,0 +1,43 @@
+package ledgers
+
+import (
+	"encoding/json"
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToLedgerTrackingView(t *testing.T) {
+	mediaEvents, _ := json.Marshal([]tables.MediaEvent{{
+		EventID:           "Text.abc",
+		MediaType:         tables.MEDIA_TEXT,
+		PromptInstruction: "not exposed",
+	}})
+	publishEvents, _ := json.Marshal([]tables.PublishEvent{{
+		DistributionChannel: "Medium",
+		PublishStatus:       tables.COMPLETE,
+		PublisherProfileID:  "profile-1",
+		AccountID:           "account-1",
+	}})
+	ledgerItem := tables.Ledger{
+		LedgerID:      "ledger-1",
+		LedgerStatus:  tables.NEW_LEDGER,
+		MediaEvents:   mediaEvents,
+		PublishEvents: publishEvents,
+	}
+
+	view, err := ToLedgerTrackingView(ledgerItem)
+	assert.Nil(t, err)
+	assert.Equal(t, "ledger-1", view.LedgerID)
+	assert.Equal(t, "New", view.LedgerStatus)
+	assert.Equal(t, 1, len(view.MediaEvents))
+	assert.Equal(t, "Text.abc", view.MediaEvents[0].EventID)
+	assert.Equal(t, 1, len(view.PublishEvents))
+	assert.Equal(t, "Complete", view.PublishEvents[0].PublishStatus)
+	assert.Equal(t, "Medium.account-1.profile-1.Complete", view.PublishEvents[0].EventID)
+
+	empty, err := ToLedgerTrackingView(tables.Ledger{LedgerID: "ledger-2"})
+	assert.Nil(t, err)
+	assert.NotNil(t, empty.MediaEvents, "expected empty arrays rather than null")
+}
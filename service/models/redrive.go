@@ -0,0 +1,8 @@
+package models
+
+// Body of POST /v1/ledger/{id}/redrive.
+type LedgerRedriveRequest struct {
+	Stage   string `json:"stage"`             // Script, Enrichment, Assignment, FinalRender or Publish.
+	Channel string `json:"channel,omitempty"` // Distribution channel, from the Assignment stage on.
+	Force   bool   `json:"force,omitempty"`   // Reopens a Finished ledger.
+}
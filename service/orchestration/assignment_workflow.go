@@ -130,7 +130,7 @@ func (s *AssignmentWorkflow) assignMediaToPublisher(ledgerItem tables.Ledger, me
 	}
 	publishProfileEvent := s.buildPublishEvent(ledgerItem.LedgerID,
 		assignedPublisherProfile, mediaEvent, distributionChannelName, processId)
-	err = dal.AppendLedgerPublishEvents(ledgerItem.LedgerID, []tables.PublishEvent{publishProfileEvent})
+	err = ledgerStore.AppendLedgerPublishEvents(newLedgerAudit(s, processId), ledgerItem.LedgerID, []tables.PublishEvent{publishProfileEvent})
 	if err != nil {
 		log.Printf("unable to write publish-event to ledger: %s", err)
 		// Try release assignment
@@ -5,11 +5,10 @@ import (
 	"time"
 
 	"github.com/bezalel-media-core/v2/dal"
-	dao "github.com/bezalel-media-core/v2/dal"
 	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
 )
 
-func HandleMediaGeneration(ledgerItem tables.Ledger, mediaEvents []tables.MediaEvent) error {
+func HandleMediaGeneration(audit dal.LedgerAudit, ledgerItem tables.Ledger, mediaEvents []tables.MediaEvent) error {
 	existsInLedger, err := ExistsInLedger(ledgerItem, mediaEvents)
 	if err != nil {
 		log.Printf("correlationID: %s unable to determine idempotency: %s", ledgerItem.LedgerID, err)
@@ -29,7 +28,7 @@ func HandleMediaGeneration(ledgerItem tables.Ledger, mediaEvents []tables.MediaE
 	if err != nil {
 		return err
 	}
-	err = dao.AppendLedgerMediaEvents(ledgerItem.LedgerID, mediaEvents)
+	err = ledgerStore.AppendLedgerMediaEvents(audit, ledgerItem.LedgerID, mediaEvents)
 	return err
 }
 
@@ -82,7 +81,7 @@ func IsParentMediaEvent(mediaEvent tables.MediaEvent) bool {
 func WaitOptimisticVerifyWroteLedger(expectedPublisherEventID string, ledgerId string) (bool, error) {
 	time.Sleep(time.Duration(5) * time.Second)
 
-	ledgerItem, err := dal.GetLedger(ledgerId)
+	ledgerItem, err := ledgerStore.GetLedger(ledgerId)
 	if err != nil {
 		log.Printf("correlationID: %s failed to fetch event ledger for verification: %s", ledgerId, err)
 		return false, err
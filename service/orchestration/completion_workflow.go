@@ -24,28 +24,30 @@ func (s *CompletionWorkflow) Run(ledgerItem tables.Ledger, processId string) err
 	}
 
 	if !isSyndicated {
+		if _, exceeded := QuarantineReason(ledgerItem, time.Now()); exceeded {
+			return ErrLedgerNotConverging
+		}
 		log.Printf("correlationID: %s ledger is not fully syndicated - cannot complete", ledgerItem.LedgerID)
 		return dal.CreateFutureHeartbeat(ledgerItem.LedgerID)
 	}
 
-	err = s.expireLocks(ledgerItem)
+	err = s.expireLocks(ledgerItem, processId)
 	if err != nil {
 		log.Fatalf("correlationID: %s error with expireLocks in completion workflow: %s", ledgerItem.LedgerID, err)
 		return err
 	}
 
-	err = dal.SetLedgerStatus(ledgerItem, tables.FINISHED_LEDGER)
+	err = ledgerStore.SetLedgerStatus(ledgerItem, tables.FINISHED_LEDGER)
 	if err != nil {
 		log.Fatalf("correlationID: %s unable to mark ledger as completed: %s", ledgerItem.LedgerID, err)
 		return err
 	}
 
-	err = dal.RecordOverflowPoolCompletion(ledgerItem.TriggerEventSource)
+	err = dal.ReleaseLedgerAdmission(ledgerItem)
 	if err != nil {
-		log.Fatalf("correlationID: %s unable to mark item completed from overflow pool: %s", ledgerItem.LedgerID, err)
-		return err
+		log.Printf("correlationID: %s unable to release ingestion admission of completed item: %s", ledgerItem.LedgerID, err)
 	}
-	return nil
+	return err
 }
 
 func (s CompletionWorkflow) isFullySyndicated(ledgerItem tables.Ledger) (bool, error) {
@@ -91,7 +93,7 @@ func (s CompletionWorkflow) isPublishedOnAllChannels(channelNames []string,
 	return true
 }
 
-func (s CompletionWorkflow) expireLocks(ledgerItem tables.Ledger) error {
+func (s CompletionWorkflow) expireLocks(ledgerItem tables.Ledger, processId string) error {
 	pubEvents, err := ledgerItem.GetExistingPublishEvents()
 	if err != nil {
 		log.Printf("correlationID: %s error retrieving publish events in expireLocks: %s", ledgerItem.LedgerID, err)
@@ -99,7 +101,7 @@ func (s CompletionWorkflow) expireLocks(ledgerItem tables.Ledger) error {
 	pubIdToPubs := PubStateByPubEventID(pubEvents)
 	for _, p := range pubEvents {
 		if s.isUnmarkedExpired(p, pubIdToPubs) {
-			err = s.setExpiredPubEvent(p)
+			err = s.setExpiredPubEvent(p, processId)
 			if err != nil {
 				return err
 			}
@@ -131,10 +133,10 @@ func (s CompletionWorkflow) isUnmarkedExpired(pubEvent tables.PublishEvent, pubS
 	return pubEvent.ExpiresAtTTL < timeNow
 }
 
-func (s CompletionWorkflow) setExpiredPubEvent(pubEvent tables.PublishEvent) error {
+func (s CompletionWorkflow) setExpiredPubEvent(pubEvent tables.PublishEvent, processId string) error {
 	expiredEvent := pubEvent
 	expiredEvent.PublishStatus = tables.EXPIRED
-	err := dal.AppendLedgerPublishEvents(pubEvent.LedgerID, []tables.PublishEvent{expiredEvent})
+	err := ledgerStore.AppendLedgerPublishEvents(newLedgerAudit(&s, processId), pubEvent.LedgerID, []tables.PublishEvent{expiredEvent})
 	if err != nil {
 		log.Printf("correlationID: %s error appending expired event in setExpired: %s", pubEvent.LedgerID, err)
 		return err
@@ -0,0 +1,39 @@
+package orchestration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetExpiredPubEventWithMemoryStore(t *testing.T) {
+	store := dal.NewMemoryLedgerStore()
+	SetLedgerStore(store)
+	defer SetLedgerStore(dal.NewDynamoLedgerStore())
+
+	assigned := tables.PublishEvent{
+		LedgerID:            "offline-ledger",
+		DistributionChannel: string(tables.Channel_Medium),
+		PublishStatus:       tables.ASSIGNED,
+		ExpiresAtTTL:        time.Now().Add(-time.Minute).UnixMilli(),
+		AccountID:           "acc",
+		PublisherProfileID:  "pub",
+		RootMediaEventID:    "root",
+	}
+	store.CreateLedger(tables.Ledger{LedgerID: "offline-ledger"})
+	store.AppendLedgerPublishEvents(dal.LedgerAudit{}, "offline-ledger", []tables.PublishEvent{assigned})
+
+	workflow := CompletionWorkflow{}
+	ledger, _ := store.GetLedger("offline-ledger")
+	pubEvents, _ := ledger.GetExistingPublishEvents()
+	assert.True(t, workflow.isUnmarkedExpired(assigned, PubStateByPubEventID(pubEvents)))
+
+	assert.NoError(t, workflow.setExpiredPubEvent(assigned, "process-1"))
+	ledger, _ = store.GetLedger("offline-ledger")
+	pubEvents, _ = ledger.GetExistingPublishEvents()
+	assert.Len(t, pubEvents, 2)
+	assert.False(t, workflow.isUnmarkedExpired(assigned, PubStateByPubEventID(pubEvents)))
+}
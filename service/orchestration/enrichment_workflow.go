@@ -51,10 +51,10 @@ func (s *EnrichmentWorkflow) Run(ledgerItem tables.Ledger, processId string) err
 
 		if strings.Contains(textPayload, "EDITOR_FORBIDDEN") {
 			log.Printf("correlationID: %s detected forbidden media, marking workflow as finished.", ledgerItem.LedgerID)
-			return dal.SetLedgerStatus(ledgerItem, tables.FINISHED_LEDGER)
+			return ledgerStore.SetLedgerStatus(ledgerItem, tables.FINISHED_LEDGER)
 		}
 
-		err = spawnChildMediaEvents(ledgerItem, parentMedia, mediaEvents)
+		err = spawnChildMediaEvents(newLedgerAudit(s, processId), ledgerItem, parentMedia, mediaEvents)
 		if err != nil {
 			log.Printf("correlationID: %s failed to spawn child media events: %s", ledgerItem.LedgerID, err)
 			return err
@@ -64,23 +64,30 @@ func (s *EnrichmentWorkflow) Run(ledgerItem tables.Ledger, processId string) err
 	return err
 }
 
-func spawnChildMediaEvents(ledgerItem tables.Ledger, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) error {
-	childEvents, err := buildEventsByDistFormat(parentMediaEvent, existingMediaEvents)
+func spawnChildMediaEvents(audit dal.LedgerAudit, ledgerItem tables.Ledger, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) error {
+	childEvents, title, err := buildEventsByDistFormat(parentMediaEvent, existingMediaEvents)
 	if err != nil {
 		return err
 	}
+	if title != "" {
+		err = ledgerStore.AddGeneratedTitle(ledgerItem.LedgerID, title)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Metadata entry to indicate enrichment finished.
 	enrichedEntry := parentMediaEvent.ToMetadataEventEntry(tables.SCRIPT_ENRICHED, parentMediaEvent.RestrictToPublisherID, parentMediaEvent.MediaType)
 	childEvents = append(childEvents, enrichedEntry)
-	return HandleMediaGeneration(ledgerItem, childEvents)
+	return HandleMediaGeneration(audit, ledgerItem, childEvents)
 }
 
-func buildEventsByDistFormat(parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, error) {
+// Returns the child events and the title of the script.
+func buildEventsByDistFormat(parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, string, error) {
 	distForm := parentMediaEvent.DistributionFormat
 	jsonPayload, err := drivers.LoadAsString(parentMediaEvent.ContentLookupKey)
 	if err != nil {
-		return []tables.MediaEvent{}, err
+		return []tables.MediaEvent{}, "", err
 	}
 	if manifest.DIST_FORMAT_BLOG == distForm || manifest.DIST_FORMAT_INTEG_BLOG == distForm {
 		return enrichBlog(jsonPayload, parentMediaEvent, existingMediaEvents)
@@ -89,36 +96,36 @@ func buildEventsByDistFormat(parentMediaEvent tables.MediaEvent, existingMediaEv
 	} else if manifest.DIST_FORMAT_SHORT_VIDEO == distForm {
 		return enrichShortVideo(jsonPayload, parentMediaEvent, existingMediaEvents)
 	}
-	return []tables.MediaEvent{}, fmt.Errorf("no matching enrichment process for distributionFormat: %s", distForm)
+	return []tables.MediaEvent{}, "", fmt.Errorf("no matching enrichment process for distributionFormat: %s", distForm)
 }
 
-func enrichBlog(jsonPayload string, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, error) {
+func enrichBlog(jsonPayload string, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, string, error) {
 	events := []tables.MediaEvent{}
 
 	schemaResult, err := drivers.ScriptPayloadToBlogSchema(jsonPayload)
 	if err != nil {
-		return events, err
+		return events, "", err
 	}
 	// TODO: Perform other enrichment activities for blogs here.
-	return createBlogChildEventsFromImageDescriptions(schemaResult.ImageDescriptionTexts, parentMediaEvent, existingMediaEvents), nil
+	return createBlogChildEventsFromImageDescriptions(schemaResult.ImageDescriptionTexts, parentMediaEvent, existingMediaEvents), schemaResult.BlogTitle, nil
 }
 
-func enrichTinyBlog(jsonPayload string, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, error) {
+func enrichTinyBlog(jsonPayload string, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, string, error) {
 	events := []tables.MediaEvent{}
 	schemaResult, err := drivers.ScriptPayloadToTinyBlogSchema(jsonPayload)
 	if err != nil {
-		return events, err
+		return events, "", err
 	}
 	// TODO: perform other enrichment activities for tiny blogs here.
-	return createBlogChildEventsFromImageDescriptions(schemaResult.ImageDescriptionTexts, parentMediaEvent, existingMediaEvents), nil
+	return createBlogChildEventsFromImageDescriptions(schemaResult.ImageDescriptionTexts, parentMediaEvent, existingMediaEvents), schemaResult.BlogTitle, nil
 }
 
-func enrichShortVideo(jsonPayload string, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, error) {
+func enrichShortVideo(jsonPayload string, parentMediaEvent tables.MediaEvent, existingMediaEvents []tables.MediaEvent) ([]tables.MediaEvent, string, error) {
 	events := []tables.MediaEvent{}
 	schemaResult, err := drivers.ScriptPayloadToShortVideoSchema(jsonPayload)
 	if err != nil {
-		return events, err
+		return events, "", err
 	}
 
-	return createShortVideoChildEvents(schemaResult, parentMediaEvent, existingMediaEvents), err
+	return createShortVideoChildEvents(schemaResult, parentMediaEvent, existingMediaEvents), schemaResult.VideoTitle, err
 }
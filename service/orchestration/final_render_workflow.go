@@ -32,7 +32,7 @@ func (s *FinalRenderWorkflow) Run(ledgerItem tables.Ledger, processId string) er
 	if len(rootMediasReadyForPublish) == 0 {
 		return nil
 	}
-	err = s.spawnFinalRenderMediaEvent(ledgerItem, rootMediasReadyForPublish, assignedPublishEvents)
+	err = s.spawnFinalRenderMediaEvent(newLedgerAudit(s, processId), ledgerItem, rootMediasReadyForPublish, assignedPublishEvents)
 	return err
 }
 
@@ -81,7 +81,7 @@ func (s *FinalRenderWorkflow) getRootMediaAllChildrenReady(ledgerItem tables.Led
 	return rootMedias, nil
 }
 
-func (s *FinalRenderWorkflow) spawnFinalRenderMediaEvent(ledgerItem tables.Ledger, rootMediaEventsToFinalize []tables.MediaEvent,
+func (s *FinalRenderWorkflow) spawnFinalRenderMediaEvent(audit dal.LedgerAudit, ledgerItem tables.Ledger, rootMediaEventsToFinalize []tables.MediaEvent,
 	assignedPublisherProfiles []tables.PublishEvent) error {
 	mediaEvents, err := ledgerItem.GetExistingMediaEvents()
 	if err != nil {
@@ -98,13 +98,13 @@ func (s *FinalRenderWorkflow) spawnFinalRenderMediaEvent(ledgerItem tables.Ledge
 			continue
 		}
 		finalMediaEvents := s.collectFinalRenderMedia(ledgerItem, r, children, assignedPubs)
-		err = HandleMediaGeneration(ledgerItem, finalMediaEvents)
+		err = HandleMediaGeneration(audit, ledgerItem, finalMediaEvents)
 		if err != nil {
 			log.Printf("correlationID: %s failed to append finalRender media event: %s", ledgerItem.LedgerID, err)
 			return err
 		}
 		renderEvents := s.createPublishEventRenders(assignedPubs)
-		err = dal.AppendLedgerPublishEvents(ledgerItem.LedgerID, renderEvents)
+		err = ledgerStore.AppendLedgerPublishEvents(audit, ledgerItem.LedgerID, renderEvents)
 		if err != nil {
 			log.Printf("correlationID: %s failed to append RENDERING publish event: %s", ledgerItem.LedgerID, err)
 			return err
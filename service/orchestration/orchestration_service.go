@@ -1,8 +1,10 @@
 package orchestration
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/bezalel-media-core/v2/dal"
 	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
@@ -14,6 +16,14 @@ type Workflow interface {
 	Run(tables.Ledger, string) error
 }
 
+// Ledger reads and writes of every workflow; tests swap in dal.NewMemoryLedgerStore().
+var ledgerStore dal.LedgerStore = dal.NewDynamoLedgerStore()
+
+// Not synchronized; tests swapping the store must not run in parallel.
+func SetLedgerStore(store dal.LedgerStore) {
+	ledgerStore = store
+}
+
 // Workflows run in order.
 var workflowsToRun = []Workflow{
 	&ScriptWorkflow{},
@@ -26,26 +36,46 @@ var workflowsToRun = []Workflow{
 }
 
 func RunWorkflows(triggerLedger tables.Ledger) error {
-	latestLedger, err := dal.GetLedger(triggerLedger.LedgerID)
+	latestLedger, err := ledgerStore.GetLedger(triggerLedger.LedgerID)
 	if err != nil {
 		log.Printf("correlationID: %s run workflows error retrieving latest ledger: %s", triggerLedger.LedgerID, err)
 		return err
 	}
+	if latestLedger.LedgerID == "" {
+		// Not created yet, e.g. media promoted just ahead of its ledger; the ledger's creation runs the workflows.
+		log.Printf("correlationID: %s run workflows skipped for missing ledger", triggerLedger.LedgerID)
+		return nil
+	}
 
-	if isCompleteWorkflow(latestLedger) {
+	if isCompleteWorkflow(latestLedger) || isQuarantinedWorkflow(latestLedger) {
 		return nil
 	}
 
 	processId := fmt.Sprintf("%s.LedgerID:%s", uuid.New().String(), latestLedger.LedgerID)
+	workflowErrors := []tables.WorkflowError{}
 	for _, w := range workflowsToRun {
 		err := w.Run(latestLedger, processId)
+		if errors.Is(err, ErrLedgerNotConverging) {
+			return quarantineLedger(latestLedger, workflowErrors, time.Now())
+		}
 		if err != nil {
 			log.Printf("correlationID: %s workflow %s failed: %s", latestLedger.LedgerID, w.GetWorkflowName(), err)
+			workflowErrors = append(workflowErrors, tables.WorkflowError{WorkflowName: w.GetWorkflowName(), Error: err.Error()})
 		}
 	}
 	return nil
 }
 
+// Attributes a workflow's ledger appends in the ledger history.
+func newLedgerAudit(w Workflow, processId string) dal.LedgerAudit {
+	return dal.LedgerAudit{WorkflowName: w.GetWorkflowName(), ProcessID: processId}
+}
+
 func isCompleteWorkflow(ledgerItem tables.Ledger) bool {
 	return ledgerItem.LedgerStatus == tables.FINISHED_LEDGER
 }
+
+// Quarantined ledgers run no workflows until redriven.
+func isQuarantinedWorkflow(ledgerItem tables.Ledger) bool {
+	return ledgerItem.LedgerStatus == tables.QUARANTINED_LEDGER
+}
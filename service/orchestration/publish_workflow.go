@@ -46,7 +46,7 @@ func (s *PublishWorkFlow) handlePublish(pubCommand drivers.PublishCommand, ledge
 	renderEvent := pubCommand.RootPublishEvent
 	renderEvent.ProcessOwner = processId
 	renderEvent.PublishStatus = tables.PUBLISHING
-	err = dal.AppendLedgerPublishEvents(ledgerId, []tables.PublishEvent{renderEvent})
+	err = ledgerStore.AppendLedgerPublishEvents(newLedgerAudit(s, processId), ledgerId, []tables.PublishEvent{renderEvent})
 	if err != nil {
 		log.Printf("correlationID: %s error appending publisher publishing-event to ledger: %s", ledgerId, err)
 		// Try release publish lock
@@ -65,8 +65,8 @@ func (s *PublishWorkFlow) handlePublish(pubCommand drivers.PublishCommand, ledge
 	contentIds, err := driver.Publish(pubCommand)
 	if err != nil {
 		log.Printf("correlationID: %s error publishing: %s", ledgerId, err)
-		s.handleBadRequestCode(err, ledgerId, pubCommand.RootPublishEvent)
-		s.handlePoisonMessageForChannel(err, ledgerId, pubCommand.RootPublishEvent)
+		s.handleBadRequestCode(err, ledgerId, pubCommand.RootPublishEvent, processId)
+		s.handlePoisonMessageForChannel(err, ledgerId, pubCommand.RootPublishEvent, processId)
 		// Try release publish lock
 		dal.ReleasePublishLock(pubCommand.RootPublishEvent.AccountID, pubCommand.RootPublishEvent.PublisherProfileID, processId)
 		return err
@@ -75,7 +75,7 @@ func (s *PublishWorkFlow) handlePublish(pubCommand drivers.PublishCommand, ledge
 	completionEventRecord := pubCommand.RootPublishEvent
 	completionEventRecord.PublishStatus = tables.COMPLETE
 	completionEventRecord.ChannelContentIDsCsv = contentIds
-	err = dal.AppendLedgerPublishEvents(ledgerId, []tables.PublishEvent{completionEventRecord})
+	err = ledgerStore.AppendLedgerPublishEvents(newLedgerAudit(s, processId), ledgerId, []tables.PublishEvent{completionEventRecord})
 	if err != nil {
 		log.Printf("correlationID: %s error appending completion publish event: %s", ledgerId, err)
 		return err
@@ -96,7 +96,7 @@ func (s *PublishWorkFlow) handlePublish(pubCommand drivers.PublishCommand, ledge
 	return err
 }
 
-func (s *PublishWorkFlow) handleBadRequestCode(err error, ledgerId string, pubEvent tables.PublishEvent) {
+func (s *PublishWorkFlow) handleBadRequestCode(err error, ledgerId string, pubEvent tables.PublishEvent, processId string) {
 	if !strings.Contains(fmt.Sprintf("%s", err), drivers.BAD_REQUEST_PROFILE_CODE) {
 		return
 	}
@@ -104,12 +104,12 @@ func (s *PublishWorkFlow) handleBadRequestCode(err error, ledgerId string, pubEv
 		ledgerId, pubEvent.AccountID, pubEvent.PublisherProfileID)
 	expiredEvent := pubEvent
 	expiredEvent.PublishStatus = tables.EXPIRED
-	dal.AppendLedgerPublishEvents(ledgerId, []tables.PublishEvent{expiredEvent})
+	ledgerStore.AppendLedgerPublishEvents(newLedgerAudit(s, processId), ledgerId, []tables.PublishEvent{expiredEvent})
 	dal.SetProfileStaleFlag(pubEvent.AccountID, pubEvent.PublisherProfileID, true)
 	dal.ForceAllLocksFree(pubEvent.AccountID, pubEvent.PublisherProfileID)
 }
 
-func (s *PublishWorkFlow) handlePoisonMessageForChannel(err error, ledgerId string, pubEvent tables.PublishEvent) {
+func (s *PublishWorkFlow) handlePoisonMessageForChannel(err error, ledgerId string, pubEvent tables.PublishEvent, processId string) {
 	if !strings.Contains(fmt.Sprintf("%s", err), drivers.BAD_REQUEST_POISON_FOR_CHANNEL) {
 		return
 	}
@@ -122,7 +122,7 @@ func (s *PublishWorkFlow) handlePoisonMessageForChannel(err error, ledgerId stri
 	andonPublication.AccountID = "ANDON - stop publishing to this channel"
 	andonPublication.PublisherProfileID = "ANDON - stop publishing to this channel"
 	andonPublication.PublishStatus = tables.COMPLETE
-	dal.AppendLedgerPublishEvents(ledgerId, []tables.PublishEvent{expiredEvent, andonPublication})
+	ledgerStore.AppendLedgerPublishEvents(newLedgerAudit(s, processId), ledgerId, []tables.PublishEvent{expiredEvent, andonPublication})
 	dal.ForceAllLocksFree(pubEvent.AccountID, pubEvent.PublisherProfileID)
 }
 
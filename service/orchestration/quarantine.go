@@ -0,0 +1,64 @@
+package orchestration
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	env "github.com/bezalel-media-core/v2/configuration"
+	"github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+)
+
+// Returned by the CompletionWorkflow in place of another heartbeat once the quarantine policy is exceeded.
+var ErrLedgerNotConverging = errors.New("ledger is not converging")
+
+// The QuarantineMaxHeartbeats or QuarantineMaxAgeHours limit the unfinished ledger exceeded.
+func QuarantineReason(ledgerItem tables.Ledger, now time.Time) (string, bool) {
+	envConfigs := env.GetEnvConfigs()
+	return quarantineReason(ledgerItem, now, envConfigs.QuarantineMaxHeartbeats,
+		time.Duration(envConfigs.QuarantineMaxAgeHours)*time.Hour)
+}
+
+// Age counts from the latest redrive, if any; a zero limit is disabled.
+func quarantineReason(ledgerItem tables.Ledger, now time.Time, maxHeartbeats int64, maxAge time.Duration) (string, bool) {
+	if maxHeartbeats > 0 && ledgerItem.HeartbeatCount >= maxHeartbeats {
+		return fmt.Sprintf("heartbeat count %d reached max %d", ledgerItem.HeartbeatCount, maxHeartbeats), true
+	}
+	startedAtMilli := max(ledgerItem.LedgerCreatedAtEpochMilli, ledgerItem.RedrivenAtEpochMilli)
+	age := now.Sub(time.UnixMilli(startedAtMilli))
+	if maxAge > 0 && age >= maxAge {
+		return fmt.Sprintf("age %s reached max %s", age.Truncate(time.Minute), maxAge), true
+	}
+	return "", false
+}
+
+// Quarantines the ledger with the errors of the workflows that ran before the CompletionWorkflow, then releases its
+// publisher locks and its admission so the source's in-flight slot frees as if the ledger completed.
+func quarantineLedger(ledgerItem tables.Ledger, workflowErrors []tables.WorkflowError, now time.Time) error {
+	reason, _ := QuarantineReason(ledgerItem, now)
+	quarantined, err := ledgerStore.QuarantineLedger(ledgerItem.LedgerID, reason, workflowErrors)
+	if err != nil {
+		log.Printf("correlationID: %s error quarantining ledger: %s", ledgerItem.LedgerID, err)
+		return err
+	}
+	if !quarantined {
+		// Finished or quarantined by another process.
+		return nil
+	}
+	log.Printf("correlationID: %s ledger quarantined: %s, %d workflow errors", ledgerItem.LedgerID, reason, len(workflowErrors))
+
+	publishEvents, err := ledgerItem.GetExistingPublishEvents()
+	if err != nil {
+		log.Printf("correlationID: %s WARN unable to release publisher locks of quarantined ledger: %s", ledgerItem.LedgerID, err)
+	} else {
+		releasePublisherLocks(ledgerItem.LedgerID, publishEvents, publishEvents)
+	}
+
+	err = dal.ReleaseLedgerAdmission(ledgerItem)
+	if err != nil {
+		log.Printf("correlationID: %s unable to release ingestion admission of quarantined ledger: %s", ledgerItem.LedgerID, err)
+	}
+	return err
+}
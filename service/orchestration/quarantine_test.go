@@ -0,0 +1,34 @@
+package orchestration
+
+import (
+	"testing"
+	"time"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantineReason(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-10 * time.Hour).UnixMilli()
+
+	_, exceeded := quarantineReason(tables.Ledger{HeartbeatCount: 23, LedgerCreatedAtEpochMilli: createdAt}, now, 24, 72*time.Hour)
+	assert.False(t, exceeded)
+
+	reason, exceeded := quarantineReason(tables.Ledger{HeartbeatCount: 24, LedgerCreatedAtEpochMilli: createdAt}, now, 24, 72*time.Hour)
+	assert.True(t, exceeded)
+	assert.Equal(t, "heartbeat count 24 reached max 24", reason)
+
+	reason, exceeded = quarantineReason(tables.Ledger{LedgerCreatedAtEpochMilli: createdAt}, now, 24, 10*time.Hour)
+	assert.True(t, exceeded)
+	assert.Equal(t, "age 10h0m0s reached max 10h0m0s", reason)
+
+	// Age counts from the latest redrive.
+	redriven := tables.Ledger{LedgerCreatedAtEpochMilli: createdAt, RedrivenAtEpochMilli: now.Add(-time.Hour).UnixMilli()}
+	_, exceeded = quarantineReason(redriven, now, 24, 10*time.Hour)
+	assert.False(t, exceeded)
+
+	// Zero limits are disabled.
+	_, exceeded = quarantineReason(tables.Ledger{HeartbeatCount: 25, LedgerCreatedAtEpochMilli: 1}, now, 0, 0)
+	assert.False(t, exceeded)
+}
@@ -0,0 +1,376 @@
+package orchestration
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	env "github.com/bezalel-media-core/v2/configuration"
+	"github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/google/uuid"
+)
+
+// WorkflowName of redrive appends in the ledger history.
+const REDRIVE_WORKFLOW_NAME = "Redrive"
+
+// Workflow stage a redrive re-executes from; the events of the stage and every later stage are invalidated.
+type RedriveStage string
+
+const (
+	REDRIVE_SCRIPT       RedriveStage = "Script"      // All media and publish events; a changed script prompt is picked up.
+	REDRIVE_ENRICHMENT   RedriveStage = "Enrichment"  // Child media of the scripts and all publish events.
+	REDRIVE_ASSIGNMENT   RedriveStage = "Assignment"  // Publish events and final renders.
+	REDRIVE_FINAL_RENDER RedriveStage = "FinalRender" // Final renders and publish progress of the latest assignments.
+	REDRIVE_PUBLISH      RedriveStage = "Publish"     // Publish progress of the latest assignments.
+)
+
+var REDRIVE_STAGES = []RedriveStage{REDRIVE_SCRIPT, REDRIVE_ENRICHMENT, REDRIVE_ASSIGNMENT, REDRIVE_FINAL_RENDER, REDRIVE_PUBLISH}
+
+var ErrInvalidRedrive = errors.New("invalid ledger redrive")
+var ErrRedriveRequiresForce = errors.New("ledger is finished; redrive requires force")
+var ErrAssignmentTaken = errors.New("publisher profile of the assignment is locked by another process")
+
+// Publisher profile locks released or moved by redrives and quarantines; tests swap in a fake.
+type PublisherLocks interface {
+	ReleaseAssignment(accountId string, publisherProfileId string, processId string) error
+	ReleasePublishLock(accountId string, publisherProfileId string, processId string) error
+	RenewAssignment(accountId string, publisherProfileId string, oldProcessId string, processId string, expiryTimeMilli int64) error
+}
+
+type dalPublisherLocks struct{}
+
+func (dalPublisherLocks) ReleaseAssignment(accountId string, publisherProfileId string, processId string) error {
+	return dal.ReleaseAssignment(accountId, publisherProfileId, processId)
+}
+
+func (dalPublisherLocks) ReleasePublishLock(accountId string, publisherProfileId string, processId string) error {
+	return dal.ReleasePublishLock(accountId, publisherProfileId, processId)
+}
+
+func (dalPublisherLocks) RenewAssignment(accountId string, publisherProfileId string, oldProcessId string, processId string, expiryTimeMilli int64) error {
+	return dal.RenewAssignment(accountId, publisherProfileId, oldProcessId, processId, expiryTimeMilli)
+}
+
+var publisherLocks PublisherLocks = dalPublisherLocks{}
+
+func ParseRedriveStage(stage string) (RedriveStage, error) {
+	for _, s := range REDRIVE_STAGES {
+		if strings.EqualFold(string(s), strings.TrimSpace(stage)) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown stage %s, expected one of %v", ErrInvalidRedrive, stage, REDRIVE_STAGES)
+}
+
+type RedriveRequest struct {
+	LedgerID string
+	Stage    RedriveStage
+	Channel  string // Optional from the Assignment stage on: redrive only this distribution channel.
+	Force    bool   // Required to reopen a FINISHED_LEDGER; a QUARANTINED_LEDGER reopens without it.
+}
+
+type RedrivePlan struct {
+	MediaEvents        []tables.MediaEvent   // Invalidated.
+	PublishEvents      []tables.PublishEvent // Invalidated.
+	CompensatingEvents []tables.PublishEvent // Renewed ASSIGNED events, so FinalRender and Publish redrives keep their assignment.
+}
+
+type RedriveResult struct {
+	LedgerID                    string   `json:"ledgerId"`
+	Stage                       string   `json:"stage"`
+	Channel                     string   `json:"channel,omitempty"`
+	Reopened                    bool     `json:"reopened"` // The ledger was FINISHED_LEDGER or QUARANTINED_LEDGER.
+	InvalidatedMediaEventIDs    []string `json:"invalidatedMediaEventIds"`
+	InvalidatedPublishEventIDs  []string `json:"invalidatedPublishEventIds"`
+	CompensatingPublishEventIDs []string `json:"compensatingPublishEventIds"`
+}
+
+// Invalidates the stage's events, which fires the ledger stream so workflowsToRun append them again.
+// Returns false when no ledger exists for the ID.
+func RedriveLedger(req RedriveRequest) (RedriveResult, bool, error) {
+	return redriveLedger(req, time.Now().UnixMilli()+env.GetEnvConfigs().PublishLockMilliTTL)
+}
+
+func redriveLedger(req RedriveRequest, assignedExpiresAt int64) (RedriveResult, bool, error) {
+	result := RedriveResult{LedgerID: req.LedgerID, Stage: string(req.Stage), Channel: req.Channel}
+	ledgerItem, err := ledgerStore.GetLedger(req.LedgerID)
+	if err != nil {
+		log.Printf("correlationID: %s error retrieving ledger to redrive: %s", req.LedgerID, err)
+		return result, false, err
+	}
+	if ledgerItem.LedgerID == "" {
+		return result, false, nil
+	}
+	result.Reopened = isCompleteWorkflow(ledgerItem) || isQuarantinedWorkflow(ledgerItem)
+	if isCompleteWorkflow(ledgerItem) && !req.Force {
+		return result, true, ErrRedriveRequiresForce
+	}
+
+	processId := fmt.Sprintf("%s.LedgerID:%s", uuid.New().String(), ledgerItem.LedgerID)
+	plan, err := PlanRedrive(ledgerItem, req, assignedExpiresAt, processId)
+	if err != nil {
+		return result, true, err
+	}
+	publishEvents, err := ledgerItem.GetExistingPublishEvents()
+	if err != nil {
+		return result, true, err
+	}
+	err = transferPublisherLocks(ledgerItem.LedgerID, plan)
+	if err != nil {
+		return result, true, err
+	}
+
+	// The compensating events are written with the invalidation, so the reopened ledger keeps its assignments.
+	invalidation := plan.toInvalidation(req)
+	audit := dal.LedgerAudit{WorkflowName: REDRIVE_WORKFLOW_NAME, ProcessID: processId}
+	err = ledgerStore.InvalidateLedgerEvents(audit, invalidation)
+	if err != nil {
+		log.Printf("correlationID: %s error invalidating ledger events for redrive: %s", ledgerItem.LedgerID, err)
+		restorePublisherLocks(ledgerItem.LedgerID, plan, plan.CompensatingEvents)
+		return result, true, err
+	}
+	releasePublisherLocks(ledgerItem.LedgerID, plan.releasedAssignments(), publishEvents)
+	if ledgerItem.AdmissionReleased {
+		// After the invalidation, so a failed redrive holds no slot; a failed readmit leaves the ledger released.
+		err = dal.ReadmitLedger(ledgerItem)
+		if err != nil {
+			log.Printf("correlationID: %s WARN redriven ledger runs without admission slots: %s", ledgerItem.LedgerID, err)
+		}
+	}
+	result.InvalidatedMediaEventIDs = invalidation.MediaEventIDs
+	result.InvalidatedPublishEventIDs = invalidation.PublishEventIDs
+	result.CompensatingPublishEventIDs = []string{}
+	for _, p := range plan.CompensatingEvents {
+		result.CompensatingPublishEventIDs = append(result.CompensatingPublishEventIDs, p.GetEventID())
+	}
+	log.Printf("correlationID: %s redriven from %s stage, reopened: %t", ledgerItem.LedgerID, req.Stage, result.Reopened)
+	return result, true, nil
+}
+
+func (p RedrivePlan) toInvalidation(req RedriveRequest) dal.LedgerInvalidation {
+	invalidation := dal.LedgerInvalidation{
+		LedgerID:        req.LedgerID,
+		Stage:           string(req.Stage),
+		Channel:         req.Channel,
+		MediaEventIDs:   []string{},
+		PublishEventIDs: []string{},
+	}
+	for _, m := range p.MediaEvents {
+		invalidation.MediaEventIDs = append(invalidation.MediaEventIDs, m.EventID)
+	}
+	for _, e := range p.PublishEvents {
+		invalidation.PublishEventIDs = append(invalidation.PublishEventIDs, e.GetEventID())
+	}
+	invalidation.CompensatingEvents = p.CompensatingEvents
+	return invalidation
+}
+
+// Events to invalidate and append for the redrive; renewed ASSIGNED events expire at assignedExpiresAt.
+func PlanRedrive(ledgerItem tables.Ledger, req RedriveRequest, assignedExpiresAt int64, processId string) (RedrivePlan, error) {
+	plan := RedrivePlan{}
+	mediaEvents, err := ledgerItem.GetExistingMediaEvents()
+	if err != nil {
+		log.Printf("correlationID: %s error getting media events for redrive: %s", ledgerItem.LedgerID, err)
+		return plan, err
+	}
+	publishEvents, err := ledgerItem.GetExistingPublishEvents()
+	if err != nil {
+		log.Printf("correlationID: %s error getting publish events for redrive: %s", ledgerItem.LedgerID, err)
+		return plan, err
+	}
+	if req.Channel != "" && (req.Stage == REDRIVE_SCRIPT || req.Stage == REDRIVE_ENRICHMENT) {
+		return plan, fmt.Errorf("%w: channel applies from the %s stage on", ErrInvalidRedrive, REDRIVE_ASSIGNMENT)
+	}
+	inChannel := func(p tables.PublishEvent) bool {
+		return req.Channel == "" || p.DistributionChannel == req.Channel
+	}
+
+	switch req.Stage {
+	case REDRIVE_SCRIPT:
+		plan.MediaEvents = mediaEvents
+		plan.PublishEvents = publishEvents
+	case REDRIVE_ENRICHMENT:
+		for _, m := range mediaEvents {
+			if !IsParentMediaEvent(m) {
+				plan.MediaEvents = append(plan.MediaEvents, m)
+			}
+		}
+		plan.PublishEvents = publishEvents
+	case REDRIVE_ASSIGNMENT:
+		assignedRenders := map[string]bool{}
+		for _, p := range publishEvents {
+			if inChannel(p) {
+				plan.PublishEvents = append(plan.PublishEvents, p)
+				assignedRenders[finalRenderKey(p.RootMediaEventID, p.PublisherProfileID)] = true
+			}
+		}
+		for _, m := range mediaEvents {
+			if m.MetaMediaDescriptor == tables.FINAL_RENDER && (req.Channel == "" || assignedRenders[finalRenderKey(m.ParentEventID, m.RestrictToPublisherID)]) {
+				plan.MediaEvents = append(plan.MediaEvents, m)
+			}
+		}
+	case REDRIVE_FINAL_RENDER, REDRIVE_PUBLISH:
+		return planAssignmentRedrive(ledgerItem.LedgerID, req, mediaEvents, publishEvents, inChannel, assignedExpiresAt, processId)
+	default:
+		return plan, fmt.Errorf("%w: unknown stage %s", ErrInvalidRedrive, req.Stage)
+	}
+	return plan, nil
+}
+
+// Redrives the latest assignment of each channel and root media; earlier assignments are left as recorded.
+// Assignments without an ASSIGNED event, such as poison channel markers, are invalidated.
+func planAssignmentRedrive(ledgerId string, req RedriveRequest, mediaEvents []tables.MediaEvent, publishEvents []tables.PublishEvent,
+	inChannel func(tables.PublishEvent) bool, assignedExpiresAt int64, processId string) (RedrivePlan, error) {
+	plan := RedrivePlan{}
+	assignments := map[string][]tables.PublishEvent{}
+	assignmentOrder := []string{}
+	latestAssignment := map[string]string{} // <channel>.<root media> -> assignment key
+	for _, p := range publishEvents {
+		if !inChannel(p) {
+			continue
+		}
+		key := publishAssignmentKey(p)
+		if _, ok := assignments[key]; !ok {
+			assignmentOrder = append(assignmentOrder, key)
+		}
+		assignments[key] = append(assignments[key], p)
+		if p.PublishStatus == tables.ASSIGNED {
+			latestAssignment[fmt.Sprintf("%s.%s", p.DistributionChannel, p.RootMediaEventID)] = key
+		}
+	}
+	if len(latestAssignment) == 0 {
+		return plan, fmt.Errorf("%w: correlationID: %s has no assignment to redrive from the %s stage", ErrInvalidRedrive, ledgerId, req.Stage)
+	}
+
+	redrivenRenders := map[string]bool{}
+	for _, key := range assignmentOrder {
+		events := assignments[key]
+		first := events[0]
+		isMarker := !hasAssignedEvent(events)
+		if !isMarker {
+			if latestAssignment[fmt.Sprintf("%s.%s", first.DistributionChannel, first.RootMediaEventID)] != key {
+				continue
+			}
+			redrivenRenders[finalRenderKey(first.RootMediaEventID, first.PublisherProfileID)] = true
+		}
+		for _, p := range events {
+			if p.PublishStatus == tables.ASSIGNED {
+				renewed := p
+				renewed.ExpiresAtTTL = assignedExpiresAt
+				renewed.ProcessOwner = processId
+				plan.PublishEvents = append(plan.PublishEvents, p)
+				plan.CompensatingEvents = append(plan.CompensatingEvents, renewed)
+			} else if isMarker || req.Stage == REDRIVE_FINAL_RENDER || isPublishProgress(p.PublishStatus) {
+				plan.PublishEvents = append(plan.PublishEvents, p)
+			}
+		}
+	}
+	if req.Stage == REDRIVE_FINAL_RENDER {
+		for _, m := range mediaEvents {
+			if m.MetaMediaDescriptor == tables.FINAL_RENDER && redrivenRenders[finalRenderKey(m.ParentEventID, m.RestrictToPublisherID)] {
+				plan.MediaEvents = append(plan.MediaEvents, m)
+			}
+		}
+	}
+	return plan, nil
+}
+
+func hasAssignedEvent(events []tables.PublishEvent) bool {
+	for _, p := range events {
+		if p.PublishStatus == tables.ASSIGNED {
+			return true
+		}
+	}
+	return false
+}
+
+// States the publish workflow appends after the final render.
+func isPublishProgress(status tables.PublishStatus) bool {
+	return status == tables.PUBLISHING || status == tables.COMPLETE || status == tables.EXPIRED
+}
+
+func publishAssignmentKey(p tables.PublishEvent) string {
+	return fmt.Sprintf("%s.%s.%s.%s", p.DistributionChannel, p.AccountID, p.PublisherProfileID, p.RootMediaEventID)
+}
+
+func finalRenderKey(rootMediaEventId string, publisherProfileId string) string {
+	return fmt.Sprintf("%s.%s", rootMediaEventId, publisherProfileId)
+}
+
+// Moves the assignment locks of the renewed ASSIGNED events to their new ProcessOwner, so the profile stays
+// assigned to the ledger; on failure the locks already moved are restored.
+func transferPublisherLocks(ledgerId string, plan RedrivePlan) error {
+	for i, renewed := range plan.CompensatingEvents {
+		previous := plan.invalidatedEvent(renewed.GetEventID())
+		err := publisherLocks.RenewAssignment(renewed.AccountID, renewed.PublisherProfileID, previous.ProcessOwner,
+			renewed.ProcessOwner, renewed.ExpiresAtTTL)
+		if err != nil {
+			log.Printf("correlationID: %s unable to renew assignment lock of %s: %s", ledgerId, renewed.GetEventID(), err)
+			restorePublisherLocks(ledgerId, plan, plan.CompensatingEvents[:i])
+			return fmt.Errorf("%w: %s; redrive from the %s stage: %s", ErrAssignmentTaken, renewed.GetEventID(), REDRIVE_ASSIGNMENT, err)
+		}
+	}
+	return nil
+}
+
+// Moves the assignment locks of the renewed events back to the ProcessOwner of the events they renew.
+func restorePublisherLocks(ledgerId string, plan RedrivePlan, renewedEvents []tables.PublishEvent) {
+	for _, renewed := range renewedEvents {
+		previous := plan.invalidatedEvent(renewed.GetEventID())
+		err := publisherLocks.RenewAssignment(renewed.AccountID, renewed.PublisherProfileID, renewed.ProcessOwner,
+			previous.ProcessOwner, previous.ExpiresAtTTL)
+		if err != nil {
+			// Non-critical; the lock expires by its TTL.
+			log.Printf("correlationID: %s WARN unable to restore assignment lock of %s: %s", ledgerId, renewed.GetEventID(), err)
+		}
+	}
+}
+
+func (p RedrivePlan) invalidatedEvent(eventId string) tables.PublishEvent {
+	for _, e := range p.PublishEvents {
+		if e.GetEventID() == eventId {
+			return e
+		}
+	}
+	return tables.PublishEvent{}
+}
+
+// Invalidated assignments that no compensating event renews.
+func (p RedrivePlan) releasedAssignments() []tables.PublishEvent {
+	renewed := map[string]bool{}
+	for _, e := range p.CompensatingEvents {
+		renewed[e.GetEventID()] = true
+	}
+	released := []tables.PublishEvent{}
+	for _, e := range p.PublishEvents {
+		if !renewed[e.GetEventID()] {
+			released = append(released, e)
+		}
+	}
+	return released
+}
+
+// Frees the publisher profile locks still held by the given assignments that never reached a terminal state.
+func releasePublisherLocks(ledgerId string, assignments []tables.PublishEvent, publishEvents []tables.PublishEvent) {
+	pubStateMap := PubStateByPubEventID(publishEvents)
+	for _, p := range assignments {
+		_, isComplete := pubStateMap[p.GetEventIDByState(tables.COMPLETE)]
+		_, isExpired := pubStateMap[p.GetEventIDByState(tables.EXPIRED)]
+		if isComplete || isExpired {
+			continue
+		}
+		var err error
+		if p.PublishStatus == tables.ASSIGNED {
+			err = publisherLocks.ReleaseAssignment(p.AccountID, p.PublisherProfileID, p.ProcessOwner)
+		} else if p.PublishStatus == tables.PUBLISHING {
+			err = publisherLocks.ReleasePublishLock(p.AccountID, p.PublisherProfileID, p.ProcessOwner)
+		}
+		if err != nil {
+			// Non-critical; the lock expires by its TTL.
+			log.Printf("correlationID: %s WARN unable to release publisher lock of assignment: %s", ledgerId, err)
+		}
+	}
+}
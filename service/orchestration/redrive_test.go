@@ -0,0 +1,211 @@
+package orchestration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bezalel-media-core/v2/dal"
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func redriveTestPublishEvent(channel string, profile string, status tables.PublishStatus) tables.PublishEvent {
+	return tables.PublishEvent{LedgerID: "l1", DistributionChannel: channel, AccountID: "acc", PublisherProfileID: profile,
+		RootMediaEventID: "root", PublishStatus: status, ProcessOwner: "process-" + profile, ExpiresAtTTL: 1}
+}
+
+func redriveTestLedger(t *testing.T) tables.Ledger {
+	ledger, err := redriveTestStore().GetLedger("l1")
+	assert.NoError(t, err)
+	return ledger
+}
+
+func redriveTestStore() *dal.MemoryLedgerStore {
+	store := dal.NewMemoryLedgerStore()
+	store.CreateLedger(tables.Ledger{LedgerID: "l1"})
+	root := tables.MediaEvent{LedgerID: "l1", EventID: "root"}
+	store.AppendLedgerMediaEvents(dal.LedgerAudit{}, "l1", []tables.MediaEvent{
+		root,
+		{LedgerID: "l1", EventID: "child", ParentEventID: "root"},
+		{LedgerID: "l1", EventID: "enriched", ParentEventID: "root", MetaMediaDescriptor: tables.SCRIPT_ENRICHED},
+		{LedgerID: "l1", EventID: "render-p1", ParentEventID: "root", MetaMediaDescriptor: tables.FINAL_RENDER, RestrictToPublisherID: "p1"},
+		{LedgerID: "l1", EventID: "render-p2", ParentEventID: "root", MetaMediaDescriptor: tables.FINAL_RENDER, RestrictToPublisherID: "p2"},
+	})
+	andon := redriveTestPublishEvent("Medium", "ANDON", tables.COMPLETE)
+	store.AppendLedgerPublishEvents(dal.LedgerAudit{}, "l1", []tables.PublishEvent{
+		redriveTestPublishEvent("Medium", "p1", tables.ASSIGNED),
+		redriveTestPublishEvent("Medium", "p1", tables.EXPIRED),
+		andon,
+		redriveTestPublishEvent("Medium", "p2", tables.ASSIGNED),
+		redriveTestPublishEvent("Medium", "p2", tables.RENDERING),
+		redriveTestPublishEvent("Medium", "p2", tables.COMPLETE),
+		redriveTestPublishEvent("YouTube", "p3", tables.ASSIGNED),
+	})
+	return store
+}
+
+func planRedriveIDs(t *testing.T, ledger tables.Ledger, stage RedriveStage, channel string) dal.LedgerInvalidation {
+	plan, err := PlanRedrive(ledger, RedriveRequest{LedgerID: "l1", Stage: stage, Channel: channel}, 100, "redrive-process")
+	assert.NoError(t, err)
+	return plan.toInvalidation(RedriveRequest{LedgerID: "l1", Stage: stage})
+}
+
+func TestPlanRedriveStages(t *testing.T) {
+	ledger := redriveTestLedger(t)
+	allPublish := []string{"Medium.acc.p1.Assigned", "Medium.acc.p1.Expired", "Medium.acc.ANDON.Complete",
+		"Medium.acc.p2.Assigned", "Medium.acc.p2.Rendering", "Medium.acc.p2.Complete", "YouTube.acc.p3.Assigned"}
+
+	script := planRedriveIDs(t, ledger, REDRIVE_SCRIPT, "")
+	assert.Equal(t, []string{"root", "child", "enriched", "render-p1", "render-p2"}, script.MediaEventIDs)
+	assert.Equal(t, allPublish, script.PublishEventIDs)
+
+	enrichment := planRedriveIDs(t, ledger, REDRIVE_ENRICHMENT, "")
+	assert.Equal(t, []string{"child", "enriched", "render-p1", "render-p2"}, enrichment.MediaEventIDs)
+	assert.Equal(t, allPublish, enrichment.PublishEventIDs)
+
+	assignment := planRedriveIDs(t, ledger, REDRIVE_ASSIGNMENT, "YouTube")
+	assert.Empty(t, assignment.MediaEventIDs)
+	assert.Equal(t, []string{"YouTube.acc.p3.Assigned"}, assignment.PublishEventIDs)
+	assignment = planRedriveIDs(t, ledger, REDRIVE_ASSIGNMENT, "Medium")
+	assert.Equal(t, []string{"render-p1", "render-p2"}, assignment.MediaEventIDs)
+	assert.Equal(t, allPublish[:6], assignment.PublishEventIDs)
+}
+
+func TestPlanRedriveLatestAssignment(t *testing.T) {
+	ledger := redriveTestLedger(t)
+
+	// Only the latest Medium assignment (p2) is redriven; the poison marker goes with it.
+	plan, err := PlanRedrive(ledger, RedriveRequest{LedgerID: "l1", Stage: REDRIVE_PUBLISH, Channel: "Medium"}, 100, "redrive-process")
+	assert.NoError(t, err)
+	invalidation := plan.toInvalidation(RedriveRequest{LedgerID: "l1"})
+	assert.Empty(t, invalidation.MediaEventIDs)
+	assert.Equal(t, []string{"Medium.acc.ANDON.Complete", "Medium.acc.p2.Assigned", "Medium.acc.p2.Complete"}, invalidation.PublishEventIDs)
+	assert.Len(t, plan.CompensatingEvents, 1)
+	assert.Equal(t, "Medium.acc.p2.Assigned", plan.CompensatingEvents[0].GetEventID())
+	assert.Equal(t, int64(100), plan.CompensatingEvents[0].ExpiresAtTTL)
+	assert.Equal(t, "redrive-process", plan.CompensatingEvents[0].ProcessOwner)
+
+	finalRender := planRedriveIDs(t, ledger, REDRIVE_FINAL_RENDER, "")
+	assert.Equal(t, []string{"render-p2"}, finalRender.MediaEventIDs)
+	assert.Equal(t, []string{"Medium.acc.ANDON.Complete", "Medium.acc.p2.Assigned", "Medium.acc.p2.Rendering",
+		"Medium.acc.p2.Complete", "YouTube.acc.p3.Assigned"}, finalRender.PublishEventIDs)
+}
+
+func TestPlanRedriveInvalid(t *testing.T) {
+	ledger := redriveTestLedger(t)
+	_, err := PlanRedrive(ledger, RedriveRequest{LedgerID: "l1", Stage: REDRIVE_SCRIPT, Channel: "Medium"}, 100, "p")
+	assert.ErrorIs(t, err, ErrInvalidRedrive)
+	_, err = PlanRedrive(ledger, RedriveRequest{LedgerID: "l1", Stage: REDRIVE_PUBLISH, Channel: "Twitter"}, 100, "p")
+	assert.ErrorIs(t, err, ErrInvalidRedrive)
+	_, err = ParseRedriveStage("Render")
+	assert.ErrorIs(t, err, ErrInvalidRedrive)
+	stage, err := ParseRedriveStage("finalrender")
+	assert.NoError(t, err)
+	assert.Equal(t, REDRIVE_FINAL_RENDER, stage)
+}
+
+type fakePublisherLock struct {
+	assignmentLockID  string
+	assignmentLockTTL int64
+	publishLockID     string
+}
+
+// Publisher locks by profile ID; accounts are ignored.
+type fakePublisherLocks map[string]*fakePublisherLock
+
+func (f fakePublisherLocks) ReleaseAssignment(accountId string, publisherProfileId string, processId string) error {
+	if lock, ok := f[publisherProfileId]; ok && lock.assignmentLockID == processId {
+		lock.assignmentLockID, lock.assignmentLockTTL = "", 0
+	}
+	return nil
+}
+
+func (f fakePublisherLocks) ReleasePublishLock(accountId string, publisherProfileId string, processId string) error {
+	if lock, ok := f[publisherProfileId]; ok && lock.publishLockID == processId {
+		lock.publishLockID = ""
+	}
+	return nil
+}
+
+func (f fakePublisherLocks) RenewAssignment(accountId string, publisherProfileId string, oldProcessId string, processId string, expiryTimeMilli int64) error {
+	lock := f[publisherProfileId]
+	if lock.assignmentLockID != oldProcessId && lock.assignmentLockID != "" {
+		return errors.New("held by another process")
+	}
+	lock.assignmentLockID, lock.assignmentLockTTL = processId, expiryTimeMilli
+	return nil
+}
+
+func TestTransferPublisherLocksFinalRender(t *testing.T) {
+	ledger := redriveTestLedger(t)
+	locks := fakePublisherLocks{
+		"p2": {assignmentLockID: "process-p2", assignmentLockTTL: 1},
+		"p3": {assignmentLockID: "process-p3", assignmentLockTTL: 1},
+	}
+	defer func(previous PublisherLocks) { publisherLocks = previous }(publisherLocks)
+	publisherLocks = locks
+
+	plan, err := PlanRedrive(ledger, RedriveRequest{LedgerID: "l1", Stage: REDRIVE_FINAL_RENDER}, 100, "redrive-process")
+	assert.NoError(t, err)
+	assert.NoError(t, transferPublisherLocks("l1", plan))
+	assert.Equal(t, fakePublisherLock{assignmentLockID: "redrive-process", assignmentLockTTL: 100}, *locks["p2"],
+		"expected the completed assignment's profile locked again for the renewed event")
+	assert.Equal(t, fakePublisherLock{assignmentLockID: "redrive-process", assignmentLockTTL: 100}, *locks["p3"],
+		"expected the open assignment's lock moved, not released")
+
+	locks["p2"].assignmentLockID = "process-p2"
+	locks["p3"].assignmentLockID = "other-ledger"
+	err = transferPublisherLocks("l1", plan)
+	assert.ErrorIs(t, err, ErrAssignmentTaken)
+	assert.Equal(t, fakePublisherLock{assignmentLockID: "process-p2", assignmentLockTTL: 1}, *locks["p2"],
+		"expected the lock moved before the failure restored")
+}
+
+// Fails invalidations like a canceled transaction.
+type failingInvalidationStore struct {
+	*dal.MemoryLedgerStore
+}
+
+func (failingInvalidationStore) InvalidateLedgerEvents(audit dal.LedgerAudit, invalidation dal.LedgerInvalidation) error {
+	return dal.ErrLedgerAppendConflict
+}
+
+func TestRedriveLedgerWritesCompensatingEventsWithReopen(t *testing.T) {
+	store := redriveTestStore()
+	store.SetLedgerStatus(tables.Ledger{LedgerID: "l1"}, tables.FINISHED_LEDGER)
+	locks := fakePublisherLocks{
+		"p2": {assignmentLockID: "process-p2", assignmentLockTTL: 1},
+		"p3": {assignmentLockID: "process-p3", assignmentLockTTL: 1},
+	}
+	defer func(previous PublisherLocks) { publisherLocks = previous }(publisherLocks)
+	publisherLocks = locks
+	defer SetLedgerStore(dal.NewDynamoLedgerStore())
+	req := RedriveRequest{LedgerID: "l1", Stage: REDRIVE_PUBLISH, Force: true}
+
+	SetLedgerStore(failingInvalidationStore{store})
+	_, found, err := redriveLedger(req, 100)
+	assert.True(t, found)
+	assert.ErrorIs(t, err, dal.ErrLedgerAppendConflict)
+	ledger, _ := store.GetLedger("l1")
+	assert.Equal(t, tables.FINISHED_LEDGER, ledger.LedgerStatus, "expected the ledger not reopened")
+	publishEvents, _ := ledger.GetExistingPublishEvents()
+	assert.Len(t, publishEvents, 7)
+	assert.Equal(t, fakePublisherLock{assignmentLockID: "process-p2", assignmentLockTTL: 1}, *locks["p2"])
+	assert.Equal(t, fakePublisherLock{assignmentLockID: "process-p3", assignmentLockTTL: 1}, *locks["p3"])
+
+	SetLedgerStore(store)
+	result, found, err := redriveLedger(req, 100)
+	assert.True(t, found)
+	assert.NoError(t, err)
+	ledger, _ = store.GetLedger("l1")
+	assert.Equal(t, tables.NEW_LEDGER, ledger.LedgerStatus)
+	history, _ := store.GetLedgerHistory("l1")
+	renewed, err := history[len(history)-1].GetAddedPublishEvents()
+	assert.NoError(t, err)
+	assert.Len(t, renewed, 2, "expected the renewed ASSIGNED events recorded with the invalidation")
+	assert.Equal(t, []string{renewed[0].GetEventID(), renewed[1].GetEventID()}, result.CompensatingPublishEventIDs)
+	assert.Equal(t, fakePublisherLock{assignmentLockID: renewed[0].ProcessOwner, assignmentLockTTL: 100}, *locks["p2"])
+	publishEvents, _ = ledger.GetExistingPublishEvents()
+	assert.Contains(t, publishEvents, renewed[0])
+	assert.Contains(t, publishEvents, renewed[1])
+}
@@ -42,7 +42,7 @@ func (s *ScriptWorkflow) Run(ledgerItem tables.Ledger, processId string) error {
 		mediaEventsToRender = append(mediaEventsToRender, mediaEvent)
 	}
 
-	err := HandleMediaGeneration(ledgerItem, mediaEventsToRender)
+	err := HandleMediaGeneration(newLedgerAudit(s, processId), ledgerItem, mediaEventsToRender)
 	if err != nil {
 		log.Printf("correlationID: %s failed to handle media generation for script workflow: %s", ledgerItem.LedgerID, err)
 		return err
@@ -50,6 +50,11 @@ func (s *ScriptWorkflow) Run(ledgerItem tables.Ledger, processId string) error {
 	return nil
 }
 
+// Renders a script prompt exactly as the script workflow does; used by ingestion previews.
+func RenderScriptPrompt(prompt manifest.Prompt, ledgerItem tables.Ledger) (tables.MediaEvent, error) {
+	return getMediaEventFromPrompt(prompt, ledgerItem)
+}
+
 func getMediaEventFromPrompt(prompt manifest.Prompt, ledgerItem tables.Ledger) (tables.MediaEvent, error) {
 	result := tables.MediaEvent{}
 	result.LedgerID = ledgerItem.LedgerID
@@ -72,6 +77,9 @@ func getMediaEventFromPrompt(prompt manifest.Prompt, ledgerItem tables.Ledger) (
 	en := display.English.Languages()
 	enrichedPrompt := strings.Replace(prompt.PromptText, manifest.PROMPT_SCRIPT_VAR_RAW_TEXT, ledgerItem.TriggerEventPayload, -1)
 	enrichedPrompt = strings.Replace(enrichedPrompt, manifest.PROMPT_SCRIPT_VAR_LANGUAGE, en.Name(lang), -1)
+	if ledgerItem.TriggerEventWebsiteUrls != "" {
+		enrichedPrompt += strings.Replace(prompt.CitationText, manifest.PROMPT_SCRIPT_VAR_SOURCE_URLS, ledgerItem.TriggerEventWebsiteUrls, -1)
+	}
 	result.PromptInstruction = enrichedPrompt
 	result.PromptHash = tables.HashString(result.PromptInstruction)
 	result.SetEventID()
@@ -0,0 +1,27 @@
+package orchestration
+
+import (
+	"testing"
+
+	tables "github.com/bezalel-media-core/v2/dal/tables/v1"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMediaEventFromPromptCitation(t *testing.T) {
+	prompt := manifest.Prompt{
+		PromptCategoryKey: "Blog.NewsUS",
+		PromptText:        "###\n$RAW_TEXT\n###\n",
+		CitationText:      "Sources: $SOURCE_URLS\n",
+	}
+	ledgerItem := tables.Ledger{LedgerID: "l1", TriggerEventPayload: "story", TriggerEventTargetLanguage: "en"}
+
+	uncited, err := getMediaEventFromPrompt(prompt, ledgerItem)
+	assert.Nil(t, err)
+	assert.Equal(t, "###\nstory\n###\n", uncited.PromptInstruction, "expected no citation without website urls")
+
+	ledgerItem.TriggerEventWebsiteUrls = "https://a.example.com,https://b.example.com"
+	cited, err := getMediaEventFromPrompt(prompt, ledgerItem)
+	assert.Nil(t, err)
+	assert.Equal(t, "###\nstory\n###\nSources: https://a.example.com,https://b.example.com\n", cited.PromptInstruction)
+}
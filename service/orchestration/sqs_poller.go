@@ -108,6 +108,9 @@ func asyncProcessMessage(message *sqs.Message, queueUrl *string, wg *sync.WaitGr
 
 func executeRelevantWorkflow(message *sqs.Message) error {
 	ledgerItem, err := decode(message)
+	if errors.Is(err, errStagedUpload) {
+		return nil // Promoted to its ledger's key just before the ledger is saved.
+	}
 	if err != nil {
 		return err
 	}
@@ -149,11 +152,16 @@ func transformDynamoEventToLedger(cdc sqs_model.DynamoCDC) tables.Ledger {
 	return resultItem
 }
 
+var errStagedUpload = errors.New("upload staged for a ledger not yet saved")
+
 func transformS3EventToLedger(cdc sqs_model.S3CDC) (tables.Ledger, error) {
 	if len(cdc.Records) == 0 {
 		return tables.Ledger{}, errors.New("empty s3 event given, no records")
 	}
 	key := cdc.Records[0].S3.Object.Key
+	if strings.HasPrefix(key, tables.UPLOAD_STAGING_PREFIX) {
+		return tables.Ledger{}, errStagedUpload
+	}
 	contentLookupKeySegments := strings.Split(key, ".")
 	if len(contentLookupKeySegments) < 3 {
 		log.Printf("malformed s3-media-bucket key, exptect 3, was: %d for key: %s", len(contentLookupKeySegments), key)
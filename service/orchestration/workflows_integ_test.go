@@ -78,14 +78,16 @@ func TestWorkflows(t *testing.T) {
 	// 2. Wait for mediaEvent to be created
 	time.Sleep(time.Duration(stepWaitSec) * time.Second)
 	ledgerItem, _ = dal.GetLedger(Test_Ledger_Blog.LedgerID)
-	assert.NotEmpty(t, ledgerItem.MediaEvents, "media events should not be empty")
+	mediaEvents, _ := ledgerItem.GetExistingMediaEvents()
+	assert.NotEmpty(t, mediaEvents, "media events should not be empty")
 	b, _ := json.MarshalIndent(ledgerItem, "", "  ")
 	log.Print("\n MediaEventsDebug: " + string(b) + "\n")
 
 	// 3. Assert publisher profile assignment
 	ledgerItem, _ = dal.GetLedger(Test_Ledger_Blog.LedgerID)
 	publisherAcc, _ = dal.GetPublisherAccount(PubProfile_EN_Medium_1.AccountID, PubProfile_EN_Medium_1.PublisherProfileID)
-	assert.NotEmpty(t, ledgerItem.PublishEvents, "publish events should not be empty")
+	publishEvents, _ := ledgerItem.GetExistingPublishEvents()
+	assert.NotEmpty(t, publishEvents, "publish events should not be empty")
 
 	// 4. Verify final render media event created
 	ledgerItem, _ = dal.GetLedger(Test_Ledger_Blog.LedgerID)
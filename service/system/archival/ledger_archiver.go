@@ -0,0 +1,30 @@
+package archival
+
+import (
+	"log"
+	"time"
+
+	config "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+	archive "github.com/bezalel-media-core/v2/service/archive"
+	"github.com/google/uuid"
+)
+
+func StartLedgerArchiveWatch() {
+	err := dal.InitDaemonEntry(dal.SYSTEM_LEDGER_ARCHIVER)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	go processWatch(uuid.New().String())
+}
+
+func processWatch(processId string) {
+	for { // infinite
+		archivePeriod := time.Duration(config.GetEnvConfigs().LedgerArchivePeriodMin) * time.Minute
+		lockExpiryMilli := archivePeriod.Milliseconds() + time.Minute.Milliseconds()
+		dal.WaitForSystemLockOwnership(dal.SYSTEM_LEDGER_ARCHIVER, processId, lockExpiryMilli, time.Duration(3)*time.Minute)
+		archive.ArchiveExpiringLedgers()
+		time.Sleep(archivePeriod)
+	}
+}
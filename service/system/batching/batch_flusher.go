@@ -0,0 +1,30 @@
+package batching
+
+import (
+	"log"
+	"time"
+
+	config "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+	ingestion "github.com/bezalel-media-core/v2/service/ingestion"
+	"github.com/google/uuid"
+)
+
+func StartBatchFlushWatch() {
+	err := dal.InitDaemonEntry(dal.SYSTEM_BATCH_FLUSHER)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	go processWatch(uuid.New().String())
+}
+
+func processWatch(processId string) {
+	for { // infinite
+		flushPeriod := time.Duration(config.GetEnvConfigs().BatchFlushPeriodSec) * time.Second
+		lockExpiryMilli := flushPeriod.Milliseconds() + time.Minute.Milliseconds()
+		dal.WaitForSystemLockOwnership(dal.SYSTEM_BATCH_FLUSHER, processId, lockExpiryMilli, time.Duration(3)*time.Minute)
+		ingestion.FlushStaleBatches()
+		time.Sleep(flushPeriod)
+	}
+}
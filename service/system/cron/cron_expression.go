@@ -0,0 +1,151 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Standard 5-field expression: minute hour day-of-month month day-of-week, evaluated in UTC.
+// Fields support *, lists (1,15), ranges (1-5), and steps (*/15, 0-30/10). Day-of-week 0 and 7 are Sunday.
+type Expression struct {
+	minutes     []bool
+	hours       []bool
+	daysOfMonth []bool
+	months      []bool
+	daysOfWeek  []bool
+	// Per cron convention, when both day fields are restricted a day matching either fires.
+	domRestricted bool
+	dowRestricted bool
+}
+
+// Bounds the search for expressions that can never fire, e.g. Feb 30.
+const maxSearchYears = 5
+
+func ParseExpression(expr string) (Expression, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Expression{}, fmt.Errorf("cron expression must have 5 fields, given %d: %q", len(fields), expr)
+	}
+
+	var err error
+	result := Expression{}
+	if result.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return Expression{}, fmt.Errorf("minute field: %w", err)
+	}
+	if result.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return Expression{}, fmt.Errorf("hour field: %w", err)
+	}
+	if result.daysOfMonth, err = parseField(fields[2], 1, 31); err != nil {
+		return Expression{}, fmt.Errorf("day-of-month field: %w", err)
+	}
+	if result.months, err = parseField(fields[3], 1, 12); err != nil {
+		return Expression{}, fmt.Errorf("month field: %w", err)
+	}
+	if result.daysOfWeek, err = parseField(fields[4], 0, 7); err != nil {
+		return Expression{}, fmt.Errorf("day-of-week field: %w", err)
+	}
+	if result.daysOfWeek[7] {
+		result.daysOfWeek[0] = true
+	}
+	result.domRestricted = fields[2] != "*"
+	result.dowRestricted = fields[4] != "*"
+
+	if result.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return Expression{}, fmt.Errorf("cron expression never fires: %q", expr)
+	}
+	return result, nil
+}
+
+// First fire time strictly after the given time, truncated to the minute; zero if none within maxSearchYears.
+func (e Expression) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	for t.Before(limit) {
+		if !e.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !e.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !e.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !e.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (e Expression) matchesDay(t time.Time) bool {
+	dom := e.daysOfMonth[t.Day()]
+	dow := e.daysOfWeek[int(t.Weekday())]
+	if e.domRestricted && e.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func parseField(field string, min int, max int) ([]bool, error) {
+	result := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step: %q", part)
+			}
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseValue(lo, min, max); err != nil {
+				return nil, err
+			}
+			if end, err = parseValue(hi, min, max); err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, fmt.Errorf("invalid range: %q", part)
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangePart, min, max); err != nil {
+				return nil, err
+			}
+			end = start
+			if hasStep {
+				end = max // 5/15 means every 15 starting at 5.
+			}
+		}
+		for v := start; v <= end; v += step {
+			result[v] = true
+		}
+	}
+	return result, nil
+}
+
+func parseValue(value string, min int, max int) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("invalid value: " + value)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, min, max)
+	}
+	return v, nil
+}
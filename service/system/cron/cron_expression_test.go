@@ -0,0 +1,42 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339, value)
+	return t
+}
+
+func TestExpressionNext(t *testing.T) {
+	cases := []struct {
+		expr     string
+		after    string
+		expected string
+	}{
+		{"*/15 * * * *", "2024-03-10T10:07:30Z", "2024-03-10T10:15:00Z"},
+		{"0 14 * * *", "2024-03-10T14:00:00Z", "2024-03-11T14:00:00Z"},
+		{"30 9 * * 1-5", "2024-03-08T10:00:00Z", "2024-03-11T09:30:00Z"}, // Friday -> Monday
+		{"0 0 1 * *", "2024-01-31T12:00:00Z", "2024-02-01T00:00:00Z"},
+		{"0 0 29 2 *", "2024-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"0 12 1 * 0", "2024-03-02T13:00:00Z", "2024-03-03T12:00:00Z"}, // day-of-month OR Sunday
+		{"0 8 * * 7", "2024-03-04T00:00:00Z", "2024-03-10T08:00:00Z"},  // 7 is Sunday
+		{"5/20 1,3 * * *", "2024-03-10T01:30:00Z", "2024-03-10T01:45:00Z"},
+	}
+	for _, c := range cases {
+		e, err := ParseExpression(c.expr)
+		assert.Nil(t, err, c.expr)
+		assert.Equal(t, at(c.expected), e.Next(at(c.after)), c.expr)
+	}
+}
+
+func TestParseExpressionInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "0 0 30 2 *", "a * * * *"} {
+		_, err := ParseExpression(expr)
+		assert.NotNil(t, err, "expected error for: "+expr)
+	}
+}
@@ -0,0 +1,206 @@
+package cron
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"text/template"
+	"time"
+
+	config "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	ingestion "github.com/bezalel-media-core/v2/service/ingestion"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/google/uuid"
+)
+
+const (
+	CATCH_UP_SKIP   = "skip"
+	CATCH_UP_LATEST = "latest"
+	CATCH_UP_ALL    = "all"
+)
+
+const (
+	RUN_OUTCOME_SKIPPED     = "skipped"
+	RUN_OUTCOME_INITIALIZED = "initialized"
+)
+
+type schedule struct {
+	manifest.CronSchedule
+	expression Expression
+	payload    *template.Template
+}
+
+type payloadVars struct {
+	ScheduleName string
+	Date         string
+	Time         string
+	Weekday      string
+}
+
+func StartCronWatch() {
+	schedules, err := loadSchedules(manifest.GetManifestLoader())
+	if err != nil {
+		log.Fatalf("invalid manifest cron schedules: %s", err)
+	}
+
+	err = dal.InitDaemonEntry(dal.SYSTEM_CRON_SCHEDULER)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	go processWatch(uuid.New().String(), schedules)
+}
+
+func processWatch(processId string, schedules []schedule) {
+	for { // infinite
+		tickPeriod := time.Duration(config.GetEnvConfigs().CronTickPeriodSec) * time.Second
+		lockExpiryMilli := tickPeriod.Milliseconds() + time.Minute.Milliseconds()
+		dal.WaitForSystemLockOwnership(dal.SYSTEM_CRON_SCHEDULER, processId, lockExpiryMilli, time.Duration(3)*time.Minute)
+		for _, s := range schedules {
+			err := runSchedule(s, time.Now().UTC())
+			if err != nil {
+				log.Printf("error running cron schedule %s: %s", s.Name, err)
+			}
+		}
+		time.Sleep(tickPeriod)
+	}
+}
+
+func runSchedule(s schedule, now time.Time) error {
+	entry, err := dal.GetCronScheduleEntry(s.Name)
+	if err != nil {
+		return err
+	}
+	if entry.ScheduleName == "" {
+		// New schedules start from now rather than backfilling history.
+		return dal.RecordCronScheduleRun(s.Name, now.UnixMilli(), RUN_OUTCOME_INITIALIZED, "", 0)
+	}
+
+	envConfig := config.GetEnvConfigs()
+	lastRun := time.UnixMilli(entry.LastRunAtEpochMilli).UTC()
+	toFire, advanceTo := dueOccurrences(s.expression, lastRun, now, s.CatchUp,
+		time.Duration(envConfig.CronMissedGraceSec)*time.Second, envConfig.CronMaxCatchUpRuns)
+
+	version := entry.Version
+	for _, runAt := range toFire {
+		result, err := fireSchedule(s, runAt)
+		if result.Outcome == models_v1.OUTCOME_FAILED {
+			// Not recorded, so the occurrence is retried next tick.
+			return fmt.Errorf("occurrence %s failed: %w", runAt.Format(time.RFC3339), err)
+		}
+		err = dal.RecordCronScheduleRun(s.Name, runAt.UnixMilli(), result.Outcome, result.LedgerID, version)
+		if err != nil {
+			return err
+		}
+		version++
+	}
+
+	if len(toFire) == 0 && !advanceTo.IsZero() {
+		log.Printf("skipping missed cron occurrences of %s through %s", s.Name, advanceTo.Format(time.RFC3339))
+		return dal.RecordCronScheduleRun(s.Name, advanceTo.UnixMilli(), RUN_OUTCOME_SKIPPED, "", version)
+	}
+	return nil
+}
+
+func fireSchedule(s schedule, runAt time.Time) (models_v1.IngestionResult, error) {
+	payload, err := renderPayload(s, runAt)
+	if err != nil {
+		return models_v1.IngestionResult{Source: s.SourceName, Outcome: models_v1.OUTCOME_INVALID, Error: err.Error()}, err
+	}
+	result, err := ingestion.SaveSourcePayloadToLedger(s.SourceName, io.NopCloser(bytes.NewReader(payload)))
+	log.Printf("cron schedule %s fired for %s: %s %s", s.Name, runAt.Format(time.RFC3339), result.Outcome, result.LedgerID)
+	return result, err
+}
+
+// Occurrences after lastRun up to now to fire, per the catch-up mode, and the latest occurrence to record as run.
+func dueOccurrences(expr Expression, lastRun time.Time, now time.Time, catchUp string,
+	grace time.Duration, maxRuns int) ([]time.Time, time.Time) {
+	missed := []time.Time{}
+	for t := expr.Next(lastRun); !t.IsZero() && !t.After(now); t = expr.Next(t) {
+		missed = append(missed, t)
+		if catchUp == CATCH_UP_ALL && len(missed) > maxRuns {
+			missed = missed[1:]
+		}
+	}
+	if len(missed) == 0 {
+		return []time.Time{}, time.Time{}
+	}
+
+	latest := missed[len(missed)-1]
+	switch catchUp {
+	case CATCH_UP_ALL:
+		return missed, latest
+	case CATCH_UP_SKIP:
+		if now.Sub(latest) <= grace {
+			return []time.Time{latest}, latest
+		}
+		return []time.Time{}, latest
+	}
+	return []time.Time{latest}, latest
+}
+
+func renderPayload(s schedule, runAt time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	err := s.payload.Execute(&buf, payloadVars{
+		ScheduleName: s.Name,
+		Date:         runAt.Format("2006-01-02"),
+		Time:         runAt.Format(time.RFC3339),
+		Weekday:      runAt.Weekday().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("payload template did not render valid json")
+	}
+	return buf.Bytes(), nil
+}
+
+// Validates every manifest schedule; the returned error joins all inconsistencies.
+func loadSchedules(m *manifest.ManifestLoader) ([]schedule, error) {
+	errs := []error{}
+	result := []schedule{}
+	names := map[string]bool{}
+	for _, c := range m.CronSchedules.Schedules {
+		if c.Name == "" || names[c.Name] {
+			errs = append(errs, fmt.Errorf("cron schedule name must be unique and non-empty: %q", c.Name))
+			continue
+		}
+		names[c.Name] = true
+
+		if c.CatchUp == "" {
+			c.CatchUp = CATCH_UP_LATEST
+		}
+		if c.CatchUp != CATCH_UP_SKIP && c.CatchUp != CATCH_UP_LATEST && c.CatchUp != CATCH_UP_ALL {
+			errs = append(errs, fmt.Errorf("cron schedule %s has unknown catchUp: %s", c.Name, c.CatchUp))
+		}
+		if _, ok := m.GetSource(c.SourceName); !ok {
+			errs = append(errs, fmt.Errorf("cron schedule %s references unregistered source: %s", c.Name, c.SourceName))
+		}
+
+		expression, err := ParseExpression(c.Cron)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cron schedule %s: %w", c.Name, err))
+			continue
+		}
+		payload, err := template.New(c.Name).Option("missingkey=error").Parse(c.PayloadTemplate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cron schedule %s payloadTemplate: %w", c.Name, err))
+			continue
+		}
+
+		s := schedule{CronSchedule: c, expression: expression, payload: payload}
+		_, err = renderPayload(s, time.Now().UTC())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cron schedule %s payloadTemplate: %w", c.Name, err))
+			continue
+		}
+		result = append(result, s)
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,70 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDueOccurrences(t *testing.T) {
+	hourly, _ := ParseExpression("0 * * * *")
+	lastRun := at("2024-03-10T10:00:00Z")
+	now := at("2024-03-10T13:20:00Z") // Missed 11:00, 12:00, 13:00.
+	grace := 5 * time.Minute
+
+	toFire, advanceTo := dueOccurrences(hourly, lastRun, now, CATCH_UP_ALL, grace, 24)
+	assert.Equal(t, []time.Time{at("2024-03-10T11:00:00Z"), at("2024-03-10T12:00:00Z"), at("2024-03-10T13:00:00Z")}, toFire)
+	assert.Equal(t, at("2024-03-10T13:00:00Z"), advanceTo)
+
+	toFire, _ = dueOccurrences(hourly, lastRun, now, CATCH_UP_ALL, grace, 2)
+	assert.Equal(t, []time.Time{at("2024-03-10T12:00:00Z"), at("2024-03-10T13:00:00Z")}, toFire, "expected most recent runs when capped")
+
+	toFire, advanceTo = dueOccurrences(hourly, lastRun, now, CATCH_UP_LATEST, grace, 24)
+	assert.Equal(t, []time.Time{at("2024-03-10T13:00:00Z")}, toFire)
+	assert.Equal(t, at("2024-03-10T13:00:00Z"), advanceTo)
+
+	toFire, advanceTo = dueOccurrences(hourly, lastRun, now, CATCH_UP_SKIP, grace, 24)
+	assert.Empty(t, toFire, "expected late occurrence to be skipped")
+	assert.Equal(t, at("2024-03-10T13:00:00Z"), advanceTo, "expected skipped occurrences to still advance")
+
+	toFire, _ = dueOccurrences(hourly, lastRun, at("2024-03-10T13:02:00Z"), CATCH_UP_SKIP, grace, 24)
+	assert.Equal(t, []time.Time{at("2024-03-10T13:00:00Z")}, toFire, "expected on-time occurrence within grace to fire")
+
+	toFire, advanceTo = dueOccurrences(hourly, at("2024-03-10T13:00:00Z"), now, CATCH_UP_ALL, grace, 24)
+	assert.Empty(t, toFire)
+	assert.True(t, advanceTo.IsZero())
+}
+
+func TestLoadSchedules(t *testing.T) {
+	m := &manifest.ManifestLoader{
+		SourceToScriptCategoryCollection: manifest.SourceCollection{
+			Sources: []manifest.Source{{SourceName: "v1/source/prompt"}},
+		},
+		CronSchedules: manifest.CronScheduleCollection{Schedules: []manifest.CronSchedule{
+			{Name: "ok", Cron: "0 14 * * *", SourceName: "v1/source/prompt",
+				PayloadTemplate: `{"promptText": "Write about {{.Weekday}}, {{.Date}}."}`},
+		}},
+	}
+	schedules, err := loadSchedules(m)
+	assert.Nil(t, err)
+	assert.Equal(t, CATCH_UP_LATEST, schedules[0].CatchUp, "expected default catch up")
+
+	payload, err := renderPayload(schedules[0], at("2024-03-10T14:00:00Z"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"promptText": "Write about Sunday, 2024-03-10."}`, string(payload))
+
+	m.CronSchedules.Schedules = []manifest.CronSchedule{
+		{Name: "bad-cron", Cron: "0 25 * * *", SourceName: "v1/source/prompt", PayloadTemplate: `{}`},
+		{Name: "bad-source", Cron: "0 1 * * *", SourceName: "v1/source/missing", PayloadTemplate: `{}`},
+		{Name: "bad-json", Cron: "0 1 * * *", SourceName: "v1/source/prompt", PayloadTemplate: `{"a": {{.Date}}}`},
+		{Name: "bad-var", Cron: "0 1 * * *", SourceName: "v1/source/prompt", PayloadTemplate: `{"a": "{{.Missing}}"}`},
+		{Name: "bad-catch-up", Cron: "0 1 * * *", SourceName: "v1/source/prompt", CatchUp: "sometimes", PayloadTemplate: `{}`},
+	}
+	_, err = loadSchedules(m)
+	assert.NotNil(t, err)
+	for _, name := range []string{"bad-cron", "bad-source", "bad-json", "bad-var", "bad-catch-up"} {
+		assert.Contains(t, err.Error(), name)
+	}
+}
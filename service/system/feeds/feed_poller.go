@@ -0,0 +1,107 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	config "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+	manifest "github.com/bezalel-media-core/v2/manifest"
+	ingestion "github.com/bezalel-media-core/v2/service/ingestion"
+	"github.com/bezalel-media-core/v2/service/ingestion/drivers"
+	models_v1 "github.com/bezalel-media-core/v2/service/ingestion/models/v1"
+	"github.com/google/uuid"
+)
+
+func StartFeedWatch() {
+	err := dal.InitDaemonEntry(dal.SYSTEM_FEED_POLLER)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	go processWatch(uuid.New().String())
+}
+
+func processWatch(processId string) {
+	for { // infinite
+		pollPeriod := time.Duration(config.GetEnvConfigs().FeedPollPeriodMin) * time.Minute
+		// Hold the lock a minute past the poll period so the owner keeps it between polls.
+		lockExpiryMilli := pollPeriod.Milliseconds() + time.Minute.Milliseconds()
+		dal.WaitForSystemLockOwnership(dal.SYSTEM_FEED_POLLER, processId, lockExpiryMilli, time.Duration(3)*time.Minute)
+		pollFeeds()
+		time.Sleep(pollPeriod)
+	}
+}
+
+func pollFeeds() {
+	client := &http.Client{
+		Timeout: time.Duration(config.GetEnvConfigs().FeedFetchTimeoutSec) * time.Second,
+	}
+	for _, f := range manifest.GetManifestLoader().FeedSources.Feeds {
+		err := pollFeed(client, f)
+		if err != nil {
+			log.Printf("error polling feed %s for source %s: %s", f.FeedUrl, f.SourceName, err)
+		}
+	}
+}
+
+func pollFeed(client *http.Client, feed manifest.FeedSource) error {
+	items, err := fetchFeedItems(client, feed.FeedUrl)
+	if err != nil {
+		return err
+	}
+
+	maxAge := time.Duration(config.GetEnvConfigs().FeedItemMaxAgeHours) * time.Hour
+	for _, item := range items {
+		if !item.PublishedAt.IsZero() && time.Since(item.PublishedAt) > maxAge {
+			continue
+		}
+
+		payload, err := json.Marshal(toFeedItemRequest(feed, item))
+		if err != nil {
+			log.Printf("error marshalling feed item %s: %s", item.Link, err)
+			continue
+		}
+		// Already-ingested items are dropped by the content hash dedupe.
+		_, err = ingestion.SaveSourcePayloadToLedger(feed.SourceName, io.NopCloser(bytes.NewReader(payload)))
+		if err != nil {
+			log.Printf("error saving feed item %s to ledger: %s", item.Link, err)
+		}
+	}
+	return nil
+}
+
+func fetchFeedItems(client *http.Client, feedUrl string) ([]drivers.FeedItem, error) {
+	resp, err := client.Get(feedUrl)
+	if err != nil {
+		return []drivers.FeedItem{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return []drivers.FeedItem{}, fmt.Errorf("unexpected status code fetching feed: %d", resp.StatusCode)
+	}
+	return drivers.ParseFeed(resp.Body)
+}
+
+func toFeedItemRequest(feed manifest.FeedSource, item drivers.FeedItem) models_v1.FeedItemRequest {
+	publishedAt := int64(0)
+	if !item.PublishedAt.IsZero() {
+		publishedAt = item.PublishedAt.Unix()
+	}
+	return models_v1.FeedItemRequest{
+		Source:         feed.SourceName,
+		TargetLanguage: feed.TargetLanguage,
+		FeedUrl:        feed.FeedUrl,
+		Guid:           item.Guid,
+		Title:          item.Title,
+		Link:           item.Link,
+		Summary:        item.Summary,
+		PublishedAt:    publishedAt,
+	}
+}
@@ -41,7 +41,7 @@ func processHeartbeats() {
 			log.Printf("correlationID: %s error retrieving ledger for heartbeat: %s", h.LedgerID, err)
 		}
 
-		if ledger.LedgerStatus == v1.FINISHED_LEDGER {
+		if ledger.LedgerStatus == v1.FINISHED_LEDGER || ledger.LedgerStatus == v1.QUARANTINED_LEDGER {
 			continue
 		}
 
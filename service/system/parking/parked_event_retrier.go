@@ -0,0 +1,30 @@
+package parking
+
+import (
+	"log"
+	"time"
+
+	config "github.com/bezalel-media-core/v2/configuration"
+	dal "github.com/bezalel-media-core/v2/dal"
+	ingestion "github.com/bezalel-media-core/v2/service/ingestion"
+	"github.com/google/uuid"
+)
+
+func StartParkedEventWatch() {
+	err := dal.InitDaemonEntry(dal.SYSTEM_PARKED_EVENT_RETRIER)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	go processWatch(uuid.New().String())
+}
+
+func processWatch(processId string) {
+	for { // infinite
+		retryPeriod := time.Duration(config.GetEnvConfigs().ParkedRetryPeriodSec) * time.Second
+		lockExpiryMilli := retryPeriod.Milliseconds() + time.Minute.Milliseconds()
+		dal.WaitForSystemLockOwnership(dal.SYSTEM_PARKED_EVENT_RETRIER, processId, lockExpiryMilli, time.Duration(3)*time.Minute)
+		ingestion.RetryParkedEvents()
+		time.Sleep(retryPeriod)
+	}
+}